@@ -16,6 +16,9 @@ package function
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 )
@@ -148,6 +151,52 @@ func sliceFloatTotal(s []interface{}) (float64, error) {
 	return total, nil
 }
 
+// sliceNumberTotal sums up all the numbers in the slice as float64 and returns the count of them. The nil elements are skipped.
+func sliceNumberTotal(s []interface{}) (float64, int, error) {
+	var (
+		total float64
+		count int
+	)
+	for _, v := range s {
+		if v == nil {
+			continue
+		}
+		vf, err := cast.ToFloat64(v, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return 0, 0, fmt.Errorf("requires number but found %[1]T(%[1]v)", v)
+		}
+		total += vf
+		count++
+	}
+	return total, count, nil
+}
+
+// slicePercentile returns the p-th percentile of the numbers in the slice by linear interpolation between the closest
+// ranks. The nil values are skipped. Returns nil if there is no number.
+func slicePercentile(s []interface{}, p float64) (interface{}, bool) {
+	nums := make([]float64, 0, len(s))
+	for _, v := range s {
+		if v == nil {
+			continue
+		}
+		vf, err := cast.ToFloat64(v, cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return fmt.Errorf("requires number but found %[1]T(%[1]v)", v), false
+		}
+		nums = append(nums, vf)
+	}
+	if len(nums) == 0 {
+		return nil, true
+	}
+	sort.Float64s(nums)
+	rank := p / 100 * float64(len(nums)-1)
+	lower := int(rank)
+	if lower == len(nums)-1 {
+		return nums[lower], true
+	}
+	return nums[lower] + (nums[lower+1]-nums[lower])*(rank-float64(lower)), true
+}
+
 func sliceIntMax(s []interface{}, max int64) (int64, error) {
 	for _, v := range s {
 		if v == nil {
@@ -270,3 +319,96 @@ func dedup(r []interface{}, col []interface{}, all bool) (interface{}, error) {
 		return result, nil
 	}
 }
+
+// sortArray returns a sorted copy of the array whose elements must be all numbers or all strings.
+// The nil elements are placed at the end.
+func sortArray(arr []interface{}, desc bool) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(arr))
+	nils := 0
+	var (
+		hasNum, hasStr bool
+		first          interface{}
+	)
+	for _, v := range arr {
+		if v == nil {
+			nils++
+			continue
+		}
+		if first == nil {
+			first = v
+		}
+		switch v.(type) {
+		case string:
+			if hasNum {
+				return nil, fmt.Errorf("cannot sort array with incomparable elements %[1]T(%[1]v) and %[2]T(%[2]v)", first, v)
+			}
+			hasStr = true
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			if hasStr {
+				return nil, fmt.Errorf("cannot sort array with incomparable elements %[1]T(%[1]v) and %[2]T(%[2]v)", first, v)
+			}
+			hasNum = true
+		default:
+			return nil, fmt.Errorf("cannot sort array with incomparable element %[1]T(%[1]v)", v)
+		}
+		result = append(result, v)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if hasStr {
+			if desc {
+				return result[i].(string) > result[j].(string)
+			}
+			return result[i].(string) < result[j].(string)
+		}
+		a, _ := cast.ToFloat64(result[i], cast.CONVERT_SAMEKIND)
+		b, _ := cast.ToFloat64(result[j], cast.CONVERT_SAMEKIND)
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+	for i := 0; i < nils; i++ {
+		result = append(result, nil)
+	}
+	return result, nil
+}
+
+// isHashable returns whether the value can be used as a map key to do deduplication
+func isHashable(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64,
+		string,
+		bool,
+		nil:
+		return true
+	default:
+		return false
+	}
+}
+
+// containsDeepEqual checks if the array has an element deeply equal to v. It is used for the un-hashable values.
+func containsDeepEqual(array []interface{}, v interface{}) bool {
+	for _, a := range array {
+		if reflect.DeepEqual(a, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// getByPath gets the value of the map by the key path split by dot like a.b.c.
+// Return nil if the path does not exist.
+func getByPath(m map[string]interface{}, path string) interface{} {
+	keys := strings.Split(path, ".")
+	var v interface{} = m
+	for _, k := range keys {
+		mm, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = mm[k]
+	}
+	return v
+}
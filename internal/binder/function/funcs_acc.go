@@ -21,6 +21,7 @@ import (
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 )
 
 func registerGlobalAggFunc() {
@@ -124,6 +125,93 @@ func registerGlobalAggFunc() {
 			return nil
 		},
 	}
+	// running_sum is acc_sum keeping the integer type, the sum is an int64 until a float value is received
+	builtins["running_sum"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			status, err := handleAccFunc(ctx, args, accSumFunc{keepInt: true})
+			if err != nil {
+				return err, false
+			}
+			return status.Value, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			argsLen := len(args)
+			if argsLen != 1 && argsLen != 3 {
+				return fmt.Errorf("Expect 1/3 arguments but found %d.", argsLen)
+			}
+			if ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "number")
+			}
+			return nil
+		},
+	}
+	// ema keeps the exponential moving average of the value. It starts with the first non-null value and then
+	// calculates alpha*value + (1-alpha)*prev for each value.
+	builtins["ema"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if len(args) != 4 {
+				return fmt.Errorf("expect 2 args but got %d", len(args)-2), false
+			}
+			alpha, err := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("the alpha should be a number but got %v", args[1]), false
+			}
+			if alpha <= 0 || alpha > 1 {
+				return fmt.Errorf("the alpha should be in range (0, 1] but got %v", alpha), false
+			}
+			validData, ok := args[2].(bool)
+			if !ok {
+				return fmt.Errorf("when arg is not a bool but got %v", args[2]), false
+			}
+			key := args[3].(string)
+			v, err := ctx.GetState(key)
+			if err != nil {
+				return fmt.Errorf("error getting state for %s: %v", key, err), false
+			}
+			st, _ := v.(*emaState)
+			if st == nil {
+				st = &emaState{}
+			}
+			if !validData || args[0] == nil {
+				return st.result(), true
+			}
+			f, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("the value should be number but got %v", args[0]), false
+			}
+			st.add(f, alpha)
+			if err := ctx.PutState(key, st); err != nil {
+				return fmt.Errorf("error setting state for %s: %v", key, err), false
+			}
+			return st.result(), true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "number")
+			}
+			if ast.IsStringArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+				return ProduceErrInfo(1, "number")
+			}
+			var alpha float64
+			switch a := args[1].(type) {
+			case *ast.IntegerLiteral:
+				alpha = float64(a.Val)
+			case *ast.NumberLiteral:
+				alpha = a.Val
+			default:
+				return nil
+			}
+			if alpha <= 0 || alpha > 1 {
+				return fmt.Errorf("the alpha should be in range (0, 1] but got %v", alpha)
+			}
+			return nil
+		},
+	}
 }
 
 func handleAccFunc(ctx api.FunctionContext, args []interface{}, accFunc accFunc) (*accStatus, error) {
@@ -246,13 +334,15 @@ func (a accCountFunc) accReset(status *accStatus) {
 	status.Value = int64(0)
 }
 
-type accSumFunc struct{}
+// accSumFunc sums the values as float64. If keepInt is set, the sum is kept as int64 until a float value is received.
+type accSumFunc struct {
+	keepInt bool
+}
 
 func (a accSumFunc) accFuncExec(ctx api.FunctionContext, value interface{}, validData bool, partitionKey string, status *accStatus, skipStatusSave bool) {
 	if status.Value == nil {
-		status.Value = float64(0)
+		a.accReset(status)
 	}
-	sum := status.Value.(float64)
 	if !validData {
 		return
 	}
@@ -260,14 +350,16 @@ func (a accSumFunc) accFuncExec(ctx api.FunctionContext, value interface{}, vali
 		switch v := value.(type) {
 		// only for unit test
 		case int:
-			sum += float64(v)
-			status.Value = sum
+			status.Value = addIntSum(status.Value, int64(v))
 		case int64:
-			sum += float64(v)
-			status.Value = sum
+			status.Value = addIntSum(status.Value, v)
 		case float64:
-			sum += v
-			status.Value = sum
+			switch sum := status.Value.(type) {
+			case int64:
+				status.Value = float64(sum) + v
+			case float64:
+				status.Value = sum + v
+			}
 		default:
 			status.Err = fmt.Errorf("the value should be number")
 		}
@@ -280,7 +372,18 @@ func (a accSumFunc) accFuncExec(ctx api.FunctionContext, value interface{}, vali
 }
 
 func (a accSumFunc) accReset(status *accStatus) {
-	status.Value = float64(0)
+	if a.keepInt {
+		status.Value = int64(0)
+	} else {
+		status.Value = float64(0)
+	}
+}
+
+func addIntSum(sum interface{}, v int64) interface{} {
+	if s, ok := sum.(int64); ok {
+		return s + v
+	}
+	return sum.(float64) + float64(v)
 }
 
 type accMinFunc struct{}
@@ -399,3 +502,24 @@ type accAvgStatus struct {
 	count int64
 	avg   float64
 }
+
+type emaState struct {
+	Value       float64
+	Initialized bool
+}
+
+func (s *emaState) add(value float64, alpha float64) {
+	if !s.Initialized {
+		s.Value = value
+		s.Initialized = true
+		return
+	}
+	s.Value = alpha*value + (1-alpha)*s.Value
+}
+
+func (s *emaState) result() interface{} {
+	if !s.Initialized {
+		return nil
+	}
+	return s.Value
+}
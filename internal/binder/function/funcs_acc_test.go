@@ -1,6 +1,8 @@
 package function
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"testing"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
 
 func TestAccumulateAggCond(t *testing.T) {
@@ -233,3 +236,128 @@ func TestAccumulateAgg(t *testing.T) {
 		require.Equal(t, test.result, result)
 	}
 }
+
+func TestRunningSum(t *testing.T) {
+	f, ok := builtins["running_sum"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{args: []interface{}{int64(1), true, "self"}, result: int64(1)},
+		{args: []interface{}{2, true, "self"}, result: int64(3)},
+		{args: []interface{}{nil, true, "self"}, result: int64(3)},
+		{args: []interface{}{int64(10), false, "self"}, result: int64(3)},
+		{args: []interface{}{"a", true, "self"}, result: fmt.Errorf("the value should be number")},
+		{args: []interface{}{1.5, true, "self"}, result: 4.5},
+		{args: []interface{}{int64(1), true, "self"}, result: 5.5},
+		// with the begin and reset conditions like acc_sum
+		{args: []interface{}{int64(2), true, false, true, "cond"}, result: int64(2)},
+		{args: []interface{}{int64(3), false, true, true, "cond"}, result: int64(5)},
+		{args: []interface{}{int64(4), false, false, true, "cond"}, result: int64(0)},
+		{args: []interface{}{int64(3), "a", true, true, "cond"}, result: fmt.Errorf("onBegin should be boolean")},
+	}
+	for i, tt := range tests {
+		result, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, result, "case %d", i)
+	}
+	// The state is checkpointed by gob
+	v, err := fctx.GetState("self")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&v))
+	var restored interface{}
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&restored))
+	require.Equal(t, v, restored)
+}
+
+func TestRunningSumValidation(t *testing.T) {
+	f, ok := builtins["running_sum"]
+	require.True(t, ok)
+	tests := []struct {
+		args []ast.Expr
+		err  string
+	}{
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}}},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.BooleanLiteral{Val: true}, &ast.BooleanLiteral{Val: false}}},
+		{args: []ast.Expr{}, err: "Expect 1/3 arguments but found 0."},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.BooleanLiteral{Val: true}}, err: "Expect 1/3 arguments but found 2."},
+		{args: []ast.Expr{&ast.StringLiteral{Val: "a"}}, err: "Expect number type for parameter 1"},
+	}
+	for i, tt := range tests {
+		err := f.val(nil, tt.args)
+		if tt.err == "" {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.EqualError(t, err, tt.err, "case %d", i)
+		}
+	}
+}
+
+func TestEma(t *testing.T) {
+	f, ok := builtins["ema"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{args: []interface{}{nil, 0.5, true, "self"}, result: nil},
+		{args: []interface{}{int64(10), 0.5, true, "self"}, result: float64(10)},
+		{args: []interface{}{20, 0.5, true, "self"}, result: float64(15)},
+		{args: []interface{}{nil, 0.5, true, "self"}, result: float64(15)},
+		{args: []interface{}{float64(100), 0.5, false, "self"}, result: float64(15)},
+		{args: []interface{}{float64(5), 0.5, true, "self"}, result: float64(10)},
+		{args: []interface{}{float64(3), 1, true, "self"}, result: float64(3)},
+		{args: []interface{}{float64(7), 0.25, true, "other"}, result: float64(7)},
+		{args: []interface{}{"a", 0.5, true, "self"}, result: fmt.Errorf("the value should be number but got a")},
+		{args: []interface{}{float64(1), 0, true, "self"}, result: fmt.Errorf("the alpha should be in range (0, 1] but got 0")},
+		{args: []interface{}{float64(1), 1.5, true, "self"}, result: fmt.Errorf("the alpha should be in range (0, 1] but got 1.5")},
+		{args: []interface{}{float64(1), "a", true, "self"}, result: fmt.Errorf("the alpha should be a number but got a")},
+	}
+	for i, tt := range tests {
+		result, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, result, "case %d", i)
+	}
+	// The state is checkpointed by gob
+	v, err := fctx.GetState("self")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&v))
+	var restored interface{}
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&restored))
+	require.Equal(t, v, restored)
+}
+
+func TestEmaValidation(t *testing.T) {
+	f, ok := builtins["ema"]
+	require.True(t, ok)
+	tests := []struct {
+		args []ast.Expr
+		err  string
+	}{
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.NumberLiteral{Val: 0.3}}},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}}, err: "Expect 2 arguments but found 1."},
+		{args: []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.NumberLiteral{Val: 0.3}}, err: "Expect number type for parameter 1"},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.BooleanLiteral{Val: true}}, err: "Expect number type for parameter 2"},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 0}}, err: "the alpha should be in range (0, 1] but got 0"},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.NumberLiteral{Val: 1.1}}, err: "the alpha should be in range (0, 1] but got 1.1"},
+	}
+	for i, tt := range tests {
+		err := f.val(nil, tt.args)
+		if tt.err == "" {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.EqualError(t, err, tt.err, "case %d", i)
+		}
+	}
+}
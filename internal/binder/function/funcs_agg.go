@@ -84,6 +84,36 @@ func registerAggFunc() {
 		val:   ValidateOneNumberArg,
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["avg_nonzero"] = builtinFunc{
+		fType: ast.FuncTypeAgg,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arg0 := args[0].([]interface{})
+			var (
+				total float64
+				c     int
+			)
+			for _, v := range arg0 {
+				if v == nil {
+					continue
+				}
+				vf, err := cast.ToFloat64(v, cast.CONVERT_SAMEKIND)
+				if err != nil {
+					return fmt.Errorf("run avg_nonzero function error: found invalid arg %[1]T(%[1]v)", v), false
+				}
+				if vf == 0 {
+					continue
+				}
+				total += vf
+				c++
+			}
+			if c == 0 {
+				return nil, true
+			}
+			return total / float64(c), true
+		},
+		val:   ValidateOneNumberArg,
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["count"] = builtinFunc{
 		fType: ast.FuncTypeAgg,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
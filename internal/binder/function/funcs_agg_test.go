@@ -193,6 +193,60 @@ func TestAggExec(t *testing.T) {
 	}
 }
 
+func TestAvgNonZeroExec(t *testing.T) {
+	f, ok := builtins["avg_nonzero"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "with zeros",
+			args:   []interface{}{int64(0), int64(10), int64(0), int64(20)},
+			result: float64(15),
+		},
+		{
+			name:   "with nils",
+			args:   []interface{}{nil, 1.5, nil, 2.5, 0.0},
+			result: float64(2),
+		},
+		{
+			name:   "all zeros",
+			args:   []interface{}{0, 0.0, int64(0)},
+			result: nil,
+		},
+		{
+			name:   "all nils",
+			args:   []interface{}{nil, nil},
+			result: nil,
+		},
+		{
+			name:   "empty",
+			args:   []interface{}{},
+			result: nil,
+		},
+		{
+			name:   "invalid",
+			args:   []interface{}{1, "foo"},
+			result: fmt.Errorf("run avg_nonzero function error: found invalid arg string(foo)"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := f.exec(fctx, []interface{}{tt.args})
+			require.Equal(t, tt.result, r)
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+	require.Error(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "a"}}))
+	require.Error(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}))
+}
+
 func TestPercentileExec(t *testing.T) {
 	pCont, ok := builtins["percentile_cont"]
 	if !ok {
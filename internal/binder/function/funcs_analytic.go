@@ -23,6 +23,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
 // registerAnalyticFunc registers the analytic functions
@@ -71,45 +72,7 @@ func registerAnalyticFunc() {
 	}
 	builtins["had_changed"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
-		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			l := len(args) - 2
-			if l <= 1 {
-				return fmt.Errorf("expect more than one arg but got %d", len(args)), false
-			}
-			validData, ok := args[len(args)-2].(bool)
-			if !ok {
-				return fmt.Errorf("when arg is not a bool but got %v", args[len(args)-2]), false
-			}
-			if !validData {
-				return false, true
-			}
-			ignoreNull, ok := args[0].(bool)
-			if !ok {
-				return fmt.Errorf("first arg is not a bool but got %v", args[0]), false
-			}
-			key := args[len(args)-1].(string)
-			paraLen := len(args) - 2
-			result := false
-			for i := 1; i < paraLen; i++ {
-				v := args[i]
-				k := key + strconv.Itoa(i)
-				if ignoreNull && v == nil {
-					continue
-				}
-				lv, err := ctx.GetState(k)
-				if err != nil {
-					return fmt.Errorf("error getting state for %s: %v", k, err), false
-				}
-				if !reflect.DeepEqual(v, lv) {
-					result = true
-					err := ctx.PutState(k, v)
-					if err != nil {
-						return fmt.Errorf("error setting state for %s: %v", k, err), false
-					}
-				}
-			}
-			return result, true
-		},
+		exec:  hadChanged,
 		val: func(_ api.FunctionContext, args []ast.Expr) error {
 			if len(args) <= 1 {
 				return fmt.Errorf("expect more than one arg but got %d", len(args))
@@ -121,6 +84,35 @@ func registerAnalyticFunc() {
 		},
 	}
 
+	// changed returns true for the first record or if the value differs from the previous one in the partition. It shares the state logic of had_changed.
+	builtins["changed"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if len(args) != 3 {
+				return fmt.Errorf("expect one arg but got %d", len(args)-2), false
+			}
+			r, ok := hadChanged(ctx, append([]interface{}{false}, args...))
+			if !ok || !args[1].(bool) {
+				return r, ok
+			}
+			// the first record is a change even if it is null, which hadChanged cannot tell from the empty state
+			key := args[2].(string) + "_seen"
+			seen, err := ctx.GetState(key)
+			if err != nil {
+				return fmt.Errorf("error getting state for %s: %v", key, err), false
+			}
+			if seen == nil {
+				if err := ctx.PutState(key, true); err != nil {
+					return fmt.Errorf("error setting state for %s: %v", key, err), false
+				}
+				return true, true
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			return ValidateLen(1, len(args))
+		},
+	}
 	builtins["lag"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
@@ -205,6 +197,59 @@ func registerAnalyticFunc() {
 		},
 	}
 
+	// prev returns the value of n records ago in the partition. Unlike lag, null values are counted as records.
+	builtins["prev"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if len(args) != 4 {
+				return fmt.Errorf("expect two args but got %d", len(args)-2), false
+			}
+			n, err := cast.ToInt(args[1], cast.STRICT)
+			if err != nil {
+				return fmt.Errorf("error converting second arg %v to int: %v", args[1], err), false
+			}
+			if n < 1 {
+				return fmt.Errorf("the n should be a positive integer but got %d", n), false
+			}
+			validData, ok := args[2].(bool)
+			if !ok {
+				return fmt.Errorf("when arg is not a bool but got %v", args[2]), false
+			}
+			key := args[3].(string)
+			v, err := ctx.GetState(key)
+			if err != nil {
+				return fmt.Errorf("error getting state for %s: %v", key, err), false
+			}
+			rq, _ := v.(*ringqueue)
+			if rq == nil {
+				rq = newRingqueue(n)
+				rq.fill(nil)
+			}
+			if !validData {
+				r, _ := rq.peek()
+				return r, true
+			}
+			r, _ := rq.fetch()
+			rq.append(args[0])
+			if err := ctx.PutState(key, rq); err != nil {
+				return fmt.Errorf("error setting state for %s: %v", key, err), false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsFloatArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) || ast.IsStringArg(args[1]) {
+				return ProduceErrInfo(1, "int")
+			}
+			if s, ok := args[1].(*ast.IntegerLiteral); ok && s.Val < 1 {
+				return fmt.Errorf("the n should be a positive integer but got %d", s.Val)
+			}
+			return nil
+		},
+	}
+
 	builtins["latest"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
@@ -246,6 +291,56 @@ func registerAnalyticFunc() {
 			return nil
 		},
 	}
+	builtins["collect_window"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if len(args) != 4 {
+				return fmt.Errorf("expect two args but got %d", len(args)-2), false
+			}
+			duration, err := cast.ToInt64(args[1], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("the duration must be an int but got %v", args[1]), false
+			}
+			if duration <= 0 {
+				return fmt.Errorf("the duration must be positive but got %d", duration), false
+			}
+			key := args[len(args)-1].(string)
+			validData, ok := args[len(args)-2].(bool)
+			if !ok {
+				return fmt.Errorf("when arg is not a bool but got %v", args[len(args)-2]), false
+			}
+			v, err := ctx.GetState(key)
+			if err != nil {
+				return fmt.Errorf("error getting state for %s: %v", key, err), false
+			}
+			tq, _ := v.(*timedqueue)
+			if tq == nil {
+				tq = newTimedqueue()
+			}
+			now := timex.GetNowInMilli()
+			if validData && args[0] != nil {
+				tq.add(now, args[0])
+			}
+			tq.evict(now - duration)
+			err = ctx.PutState(key, tq)
+			if err != nil {
+				return fmt.Errorf("error setting state for %s: %v", key, err), false
+			}
+			return tq.values(), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsFloatArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) || ast.IsStringArg(args[1]) {
+				return ProduceErrInfo(1, "int")
+			}
+			if s, ok := args[1].(*ast.IntegerLiteral); ok && s.Val <= 0 {
+				return fmt.Errorf("the duration should be a positive integer")
+			}
+			return nil
+		},
+	}
 }
 
 func getMax(a, b float64) float64 {
@@ -261,3 +356,43 @@ func getMin(a, b float64) float64 {
 	}
 	return b
 }
+
+func hadChanged(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+	l := len(args) - 2
+	if l <= 1 {
+		return fmt.Errorf("expect more than one arg but got %d", len(args)), false
+	}
+	validData, ok := args[len(args)-2].(bool)
+	if !ok {
+		return fmt.Errorf("when arg is not a bool but got %v", args[len(args)-2]), false
+	}
+	if !validData {
+		return false, true
+	}
+	ignoreNull, ok := args[0].(bool)
+	if !ok {
+		return fmt.Errorf("first arg is not a bool but got %v", args[0]), false
+	}
+	key := args[len(args)-1].(string)
+	paraLen := len(args) - 2
+	result := false
+	for i := 1; i < paraLen; i++ {
+		v := args[i]
+		k := key + strconv.Itoa(i)
+		if ignoreNull && v == nil {
+			continue
+		}
+		lv, err := ctx.GetState(k)
+		if err != nil {
+			return fmt.Errorf("error getting state for %s: %v", k, err), false
+		}
+		if !reflect.DeepEqual(v, lv) {
+			result = true
+			err := ctx.PutState(k, v)
+			if err != nil {
+				return fmt.Errorf("error setting state for %s: %v", k, err), false
+			}
+		}
+	}
+	return result, true
+}
@@ -15,6 +15,8 @@
 package function
 
 import (
+	"bytes"
+	"encoding/gob"
 	"errors"
 	"fmt"
 	"reflect"
@@ -27,6 +29,7 @@ import (
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
 func TestChangedColValidation(t *testing.T) {
@@ -779,6 +782,91 @@ func TestHadChangedPartitionWithWhen(t *testing.T) {
 	}
 }
 
+func TestChangedValidation(t *testing.T) {
+	f, ok := builtins["changed"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	err := f.val(nil, []ast.Expr{&ast.FieldRef{Name: "foo"}})
+	assert.NoError(t, err)
+	err = f.val(nil, []ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.FieldRef{Name: "bar"}})
+	assert.EqualError(t, err, "Expect 1 arguments but found 2.")
+}
+
+func TestChangedExec(t *testing.T) {
+	f, ok := builtins["changed"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{ // 1 the first record is changed
+			args:   []interface{}{1, true, "self"},
+			result: true,
+		},
+		{ // 2
+			args:   []interface{}{1, true, "self"},
+			result: false,
+		},
+		{ // 3
+			args:   []interface{}{2, true, "self"},
+			result: true,
+		},
+		{ // 4 when condition is false, the cache is not updated
+			args:   []interface{}{3, false, "self"},
+			result: false,
+		},
+		{ // 5
+			args:   []interface{}{2, true, "self"},
+			result: false,
+		},
+		{ // 6
+			args:   []interface{}{nil, true, "self"},
+			result: true,
+		},
+		{ // 7
+			args:   []interface{}{nil, true, "self"},
+			result: false,
+		},
+		{ // 8
+			args:   []interface{}{map[string]any{"a": 1}, true, "self"},
+			result: true,
+		},
+		{ // 9
+			args:   []interface{}{map[string]any{"a": 1}, true, "self"},
+			result: false,
+		},
+		{ // 10
+			args:   []interface{}{1, "true", "self"},
+			result: errors.New("when arg is not a bool but got true"),
+		},
+		{ // 11 the first record is changed even if it is null
+			args:   []interface{}{nil, true, "other"},
+			result: true,
+		},
+		{ // 12
+			args:   []interface{}{nil, true, "other"},
+			result: false,
+		},
+		{ // 13
+			args:   []interface{}{1, true, "other"},
+			result: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
+
 func TestLagValidation(t *testing.T) {
 	f, ok := builtins["lag"]
 	if !ok {
@@ -1237,6 +1325,114 @@ func TestLagExecIndex(t *testing.T) {
 	}
 }
 
+func TestPrevValidation(t *testing.T) {
+	f, ok := builtins["prev"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	tests := []struct {
+		args []ast.Expr
+		err  error
+	}{
+		{
+			args: []ast.Expr{
+				&ast.StringLiteral{Val: "foo"},
+			},
+			err: errors.New("Expect 2 arguments but found 1."),
+		}, {
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+				&ast.IntegerLiteral{Val: 2},
+			},
+			err: nil,
+		}, {
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+				&ast.StringLiteral{Val: "bar"},
+			},
+			err: errors.New("Expect int type for parameter 2"),
+		}, {
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+				&ast.IntegerLiteral{Val: 0},
+			},
+			err: errors.New("the n should be a positive integer but got 0"),
+		},
+	}
+	for i, tt := range tests {
+		err := f.val(nil, tt.args)
+		if !reflect.DeepEqual(err, tt.err) {
+			t.Errorf("%d result mismatch,\ngot:\t%v \nwant:\t%v", i, err, tt.err)
+		}
+	}
+}
+
+func TestPrevExec(t *testing.T) {
+	f, ok := builtins["prev"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{ // 1
+			args:   []interface{}{"a", 2, true, "self"},
+			result: nil,
+		},
+		{ // 2
+			args:   []interface{}{nil, 2, true, "self"},
+			result: nil,
+		},
+		{ // 3
+			args:   []interface{}{"c", 2, true, "self"},
+			result: "a",
+		},
+		{ // 4 null is also counted as a record
+			args:   []interface{}{"d", 2, true, "self"},
+			result: nil,
+		},
+		{ // 5 when condition is false, the buffer is not updated
+			args:   []interface{}{"e", 2, false, "self"},
+			result: "c",
+		},
+		{ // 6
+			args:   []interface{}{"f", 2, true, "self"},
+			result: "c",
+		},
+		{ // 7
+			args:   []interface{}{"g", 2, true, "self"},
+			result: "d",
+		},
+		{ // 8
+			args:   []interface{}{"h", 0, true, "self"},
+			result: errors.New("the n should be a positive integer but got 0"),
+		},
+		{ // 9
+			args:   []interface{}{"h", 2, "true", "self"},
+			result: errors.New("when arg is not a bool but got true"),
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+	// The state is checkpointed by gob
+	v, err := fctx.GetState("self")
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(&v))
+	var restored interface{}
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&restored))
+	assert.Equal(t, v, restored)
+}
+
 func TestLatestExec(t *testing.T) {
 	f, ok := builtins["latest"]
 	if !ok {
@@ -1431,3 +1627,130 @@ func TestLatestPartition(t *testing.T) {
 		}
 	}
 }
+
+func TestCollectWindowExec(t *testing.T) {
+	f, ok := builtins["collect_window"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		now    int64
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			now:    1000,
+			args:   []interface{}{1, 100, true, "self"},
+			result: []interface{}{1},
+		},
+		{
+			now:    1050,
+			args:   []interface{}{2, 100, true, "self"},
+			result: []interface{}{1, 2},
+		},
+		{
+			now:    1080,
+			args:   []interface{}{nil, 100, true, "self"},
+			result: []interface{}{1, 2},
+		},
+		{
+			now:    1090,
+			args:   []interface{}{3, 100, false, "self"},
+			result: []interface{}{1, 2},
+		},
+		{ // value at 1000 expired
+			now:    1100,
+			args:   []interface{}{4, 100, true, "self"},
+			result: []interface{}{2, 4},
+		},
+		{ // other partition
+			now:    1100,
+			args:   []interface{}{5, 100, true, "other"},
+			result: []interface{}{5},
+		},
+		{ // all expired
+			now:    1300,
+			args:   []interface{}{nil, 100, true, "self"},
+			result: []interface{}{},
+		},
+		{
+			now:    1300,
+			args:   []interface{}{6, -1, true, "self"},
+			result: errors.New("the duration must be positive but got -1"),
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			timex.Set(tt.now)
+			result, _ := f.exec(fctx, tt.args)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestCollectWindowGrow(t *testing.T) {
+	f, ok := builtins["collect_window"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	var (
+		result   interface{}
+		expected []interface{}
+	)
+	for i := 0; i < 30; i++ {
+		timex.Set(int64(i * 10))
+		result, _ = f.exec(fctx, []interface{}{i, 200, true, "self"})
+		// values added within the last 200ms are kept
+		if i >= 10 {
+			expected = append(expected, i)
+		}
+	}
+	assert.Equal(t, expected, result)
+}
+
+func TestCollectWindowValidation(t *testing.T) {
+	f, ok := builtins["collect_window"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	tests := []struct {
+		args []ast.Expr
+		err  error
+	}{
+		{
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+			},
+			err: errors.New("Expect 2 arguments but found 1."),
+		}, {
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+				&ast.StringLiteral{Val: "100"},
+			},
+			err: errors.New("Expect int type for parameter 2"),
+		}, {
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+				&ast.IntegerLiteral{Val: 0},
+			},
+			err: errors.New("the duration should be a positive integer"),
+		}, {
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+				&ast.IntegerLiteral{Val: 1000},
+			},
+		},
+	}
+	for i, tt := range tests {
+		err := f.val(nil, tt.args)
+		assert.Equal(t, tt.err, err, fmt.Sprintf("test %d", i))
+	}
+}
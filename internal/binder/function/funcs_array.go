@@ -23,6 +23,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 )
@@ -37,13 +38,14 @@ var (
 	errorArraySecondArgumentNotStringError = fmt.Errorf("second argument should be string")
 	errorArrayThirdArgumentNotIntError     = fmt.Errorf("third argument should be int")
 	errorArrayThirdArgumentNotStringError  = fmt.Errorf("third argument should be string")
+	errorArrayThirdArgumentNotBoolError    = fmt.Errorf("third argument should be bool")
 	errorArrayNotArrayElementError         = fmt.Errorf("array elements should be array")
-	errorArrayNotStringElementError        = fmt.Errorf("array elements should be string")
 )
 
 func registerArrayFunc() {
 	builtins["array_create"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			var index int
 			for _, arg := range args {
@@ -63,6 +65,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_position"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return -1, true
@@ -84,6 +87,7 @@ func registerArrayFunc() {
 	}
 	builtins["element_at"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			switch args[0].(type) {
 			case []interface{}:
@@ -117,6 +121,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_contains"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return false, true
@@ -138,6 +143,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_remove"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return nil, true
@@ -163,6 +169,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_last_position"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return -1, true
@@ -184,8 +191,32 @@ func registerArrayFunc() {
 			return ValidateLen(2, len(args))
 		},
 	}
+	builtins["array_positions"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if args[0] == nil {
+				return []interface{}{}, true
+			}
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			positions := make([]interface{}, 0)
+			for i, item := range array {
+				if item == args[1] {
+					positions = append(positions, i)
+				}
+			}
+			return positions, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			return ValidateLen(2, len(args))
+		},
+	}
 	builtins["array_contains_any"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return false, true
@@ -213,8 +244,49 @@ func registerArrayFunc() {
 			return ValidateLen(2, len(args))
 		},
 	}
+	builtins["array_contains_all"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if args[0] == nil {
+				return false, true
+			}
+			array1, ok1 := args[0].([]interface{})
+			if !ok1 {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			array2, ok2 := args[1].([]interface{})
+			if !ok2 {
+				return errorArraySecondArgumentNotArrayError, false
+			}
+
+			set := make(map[interface{}]struct{}, len(array1))
+			// un-hashable elements like maps and arrays are compared by deep equal
+			var unhashable []interface{}
+			for _, a := range array1 {
+				if isHashable(a) {
+					set[a] = struct{}{}
+				} else {
+					unhashable = append(unhashable, a)
+				}
+			}
+			for _, b := range array2 {
+				if isHashable(b) {
+					if _, ok := set[b]; !ok {
+						return false, true
+					}
+				} else if !containsDeepEqual(unhashable, b) {
+					return false, true
+				}
+			}
+
+			return true, true
+		},
+		val: validateTwoArrayArgs,
+	}
 	builtins["array_intersect"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			array1, ok1 := args[0].([]interface{})
 			if !ok1 {
@@ -232,27 +304,38 @@ func registerArrayFunc() {
 
 			intersection := make([]interface{}, 0, capacity)
 			set := make(map[interface{}]bool)
+			// un-hashable elements like maps and arrays are compared by deep equal
+			var unhashable, matched []interface{}
 
-			for _, a := range array1 {
-				set[a] = true
+			for _, b := range array2 {
+				if isHashable(b) {
+					set[b] = true
+				} else {
+					unhashable = append(unhashable, b)
+				}
 			}
 
-			for _, b := range array2 {
-				if set[b] {
-					intersection = append(intersection, b)
-					set[b] = false
+			// keep the order of the first array
+			for _, a := range array1 {
+				if !isHashable(a) {
+					if containsDeepEqual(unhashable, a) && !containsDeepEqual(matched, a) {
+						intersection = append(intersection, a)
+						matched = append(matched, a)
+					}
+				} else if set[a] {
+					intersection = append(intersection, a)
+					set[a] = false
 				}
 			}
 
 			return intersection, true
 		},
-		val: func(ctx api.FunctionContext, args []ast.Expr) error {
-			return ValidateLen(2, len(args))
-		},
+		val:   validateTwoArrayArgs,
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["array_union"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			var array1, array2 []interface{}
 			var ok bool
@@ -292,6 +375,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_max"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			array, ok := args[0].([]interface{})
 			if !ok {
@@ -306,6 +390,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_min"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			array, ok := args[0].([]interface{})
 			if !ok {
@@ -318,8 +403,115 @@ func registerArrayFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["array_sum"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			sum, count, err := sliceNumberTotal(array)
+			if err != nil {
+				return err, false
+			}
+			if count == 0 {
+				return nil, true
+			}
+			return sum, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			return ValidateLen(1, len(args))
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["array_avg"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			sum, count, err := sliceNumberTotal(array)
+			if err != nil {
+				return err, false
+			}
+			if count == 0 {
+				return nil, true
+			}
+			return sum / float64(count), true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			return ValidateLen(1, len(args))
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["array_percentile"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			p, err := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("the second parameter requires number but found %[1]T(%[1]v)", args[1]), false
+			}
+			if p < 0 || p > 100 {
+				return fmt.Errorf("the percentile must be in range [0, 100] but got %v", p), false
+			}
+			return slicePercentile(array, p)
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			if ast.IsStringArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+				return ProduceErrInfo(1, "number - float or int")
+			}
+			var p float64
+			switch v := args[1].(type) {
+			case *ast.IntegerLiteral:
+				p = float64(v.Val)
+			case *ast.NumberLiteral:
+				p = v.Val
+			}
+			if p < 0 || p > 100 {
+				return fmt.Errorf("the percentile must be in range [0, 100] but got %v", p)
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["array_median"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			return slicePercentile(array, 50)
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(1, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["array_except"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return nil, true
@@ -358,6 +550,7 @@ func registerArrayFunc() {
 	}
 	builtins["repeat"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			elemt := args[0]
 			count, ok := args[1].(int)
@@ -379,6 +572,7 @@ func registerArrayFunc() {
 	}
 	builtins["sequence"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			var step, start, stop int
 			var ok bool
@@ -427,8 +621,60 @@ func registerArrayFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["range"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			argErrors := []error{errorArrayFirstArgumentNotIntError, errorArraySecondArgumentNotIntError, errorArrayThirdArgumentNotIntError}
+			ints := make([]int, len(args))
+			for i, arg := range args {
+				v, err := cast.ToInt(arg, cast.STRICT)
+				if err != nil {
+					return argErrors[i], false
+				}
+				ints[i] = v
+			}
+			start, end, step := 0, ints[0], 1
+			if len(ints) > 1 {
+				start, end = ints[0], ints[1]
+			}
+			if len(ints) == 3 {
+				step = ints[2]
+			}
+			if step == 0 {
+				return fmt.Errorf("invalid step: should not be zero"), false
+			}
+			n, err := rangeLength(start, end, step)
+			if err != nil {
+				return err, false
+			}
+			arr := make([]interface{}, n)
+			for i := range arr {
+				arr[i] = start + i*step
+			}
+			return arr, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) < 1 || len(args) > 3 {
+				return fmt.Errorf("the arguments for range should be 1 to 3")
+			}
+			for i, arg := range args {
+				if ast.IsFloatArg(arg) || ast.IsStringArg(arg) || ast.IsTimeArg(arg) || ast.IsBooleanArg(arg) {
+					return ProduceErrInfo(i, "int")
+				}
+			}
+			if len(args) == 3 {
+				if step, ok := args[2].(*ast.IntegerLiteral); ok && step.Val == 0 {
+					return fmt.Errorf("invalid step: should not be zero")
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["array_cardinality"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			array, ok := args[0].([]interface{})
 			if !ok {
@@ -443,6 +689,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_flatten"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			array, ok := args[0].([]interface{})
 			if !ok {
@@ -468,6 +715,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_distinct"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			array, ok := args[0].([]interface{})
 			if !ok {
@@ -478,19 +726,12 @@ func registerArrayFunc() {
 			set := make(map[interface{}]bool)
 
 			for _, val := range array {
-				switch val.(type) {
-				case int, int8, int16, int32, int64,
-					uint, uint8, uint16, uint32, uint64,
-					float32, float64,
-					string,
-					bool,
-					nil:
-					if !set[val] {
-						output = append(output, val)
-						set[val] = true
-					}
-				default: // all un-hashable types are not deduplicated, including array, map, etc.
+				// all un-hashable types are not deduplicated, including array, map, etc.
+				if !isHashable(val) {
 					output = append(output, val)
+				} else if !set[val] {
+					output = append(output, val)
+					set[val] = true
 				}
 			}
 
@@ -501,6 +742,105 @@ func registerArrayFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["dedup_by"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			if len(args) == 1 {
+				return builtins["array_distinct"].exec(ctx, args)
+			}
+			key, ok := args[1].(string)
+			if !ok {
+				return errorArraySecondArgumentNotStringError, false
+			}
+
+			output := make([]interface{}, 0, len(array))
+			set := make(map[interface{}]bool)
+			for _, val := range array {
+				m, ok := val.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("dedup_by requires map elements when the key is specified but got %[1]T(%[1]v)", val), false
+				}
+				kv := getByPath(m, key)
+				// elements with un-hashable key values are not deduplicated
+				if !isHashable(kv) {
+					output = append(output, val)
+				} else if !set[kv] {
+					output = append(output, val)
+					set[kv] = true
+				}
+			}
+			return output, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("Expect one or two arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			if len(args) == 2 && (ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1])) {
+				return ProduceErrInfo(1, "string")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["json_pluck"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			field, ok := args[1].(string)
+			if !ok {
+				return errorArraySecondArgumentNotStringError, false
+			}
+			nullFill := false
+			if len(args) > 2 {
+				nullFill, ok = args[2].(bool)
+				if !ok {
+					return errorArrayThirdArgumentNotBoolError, false
+				}
+			}
+			output := make([]interface{}, 0, len(array))
+			for _, val := range array {
+				// non-object elements are treated as lacking the field
+				if m, ok := val.(map[string]interface{}); ok {
+					if v, exists := m[field]; exists {
+						output = append(output, v)
+						continue
+					}
+				}
+				if nullFill {
+					output = append(output, nil)
+				}
+			}
+			return output, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 2 && len(args) != 3 {
+				return fmt.Errorf("Expect two or three arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+				return ProduceErrInfo(1, "string")
+			}
+			if len(args) == 3 && (ast.IsNumericArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsStringArg(args[2])) {
+				return ProduceErrInfo(2, "bool")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["array_map"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
@@ -547,6 +887,7 @@ func registerArrayFunc() {
 	}
 	builtins["array_join"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arr, ok := args[0].([]interface{})
 			if !ok {
@@ -559,7 +900,9 @@ func registerArrayFunc() {
 			}
 
 			var nullReplacement string
-			if len(args) == 3 {
+			// nil elements are skipped if the null replacement is not set
+			hasNullReplacement := len(args) == 3
+			if hasNullReplacement {
 				nullReplacement, ok = args[2].(string)
 				if !ok {
 					return errorArrayThirdArgumentNotStringError, false
@@ -569,13 +912,13 @@ func registerArrayFunc() {
 			array := make([]string, 0, len(arr))
 			for _, v := range arr {
 				if v == nil {
-					if len(nullReplacement) != 0 {
+					if hasNullReplacement {
 						array = append(array, nullReplacement)
 					}
 				} else {
 					vs, err := cast.ToString(v, cast.CONVERT_ALL)
 					if err != nil {
-						return errorArrayNotStringElementError, false
+						vs = cast.ToStringAlways(v)
 					}
 					array = append(array, vs)
 				}
@@ -588,6 +931,14 @@ func registerArrayFunc() {
 					return fmt.Errorf("Expect two or three arguments but found %d.", len(args))
 				}
 			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			for i := 1; i < len(args); i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "string")
+				}
+			}
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
@@ -612,8 +963,62 @@ func registerArrayFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["sample"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arr, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			k, err := cast.ToInt(args[1], cast.STRICT)
+			if err != nil {
+				return errorArraySecondArgumentNotIntError, false
+			}
+			if k < 0 {
+				return fmt.Errorf("the sample size should not be negative but got %d", k), false
+			}
+			intn := rand.Intn
+			if len(args) == 3 {
+				seed, err := cast.ToInt64(args[2], cast.STRICT)
+				if err != nil {
+					return errorArrayThirdArgumentNotIntError, false
+				}
+				intn = rand.New(rand.NewSource(seed)).Intn
+			}
+			if k >= len(arr) {
+				result := make([]interface{}, len(arr))
+				copy(result, arr)
+				return result, true
+			}
+			// reservoir sampling to choose k elements without replacement
+			result := make([]interface{}, k)
+			copy(result, arr[:k])
+			for i := k; i < len(arr); i++ {
+				if j := intn(i + 1); j < k {
+					result[j] = arr[i]
+				}
+			}
+			return result, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 2 && len(args) != 3 {
+				return fmt.Errorf("the arguments for sample should be 2 or 3")
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			for i := 1; i < len(args); i++ {
+				if ast.IsFloatArg(args[i]) || ast.IsStringArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "int")
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["array_sort"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			array := args[0]
 			t := reflect.TypeOf(array)
@@ -649,8 +1054,111 @@ func registerArrayFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["sort"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arr, ok := args[0].([]interface{})
+			if !ok {
+				return args[0], true
+			}
+			desc := false
+			if len(args) == 2 {
+				d, ok := args[1].(string)
+				if !ok {
+					return errorArraySecondArgumentNotStringError, false
+				}
+				switch strings.ToLower(d) {
+				case "asc":
+				case "desc":
+					desc = true
+				default:
+					return fmt.Errorf("the sort direction must be asc or desc but got %s", d), false
+				}
+			}
+			r, err := sortArray(arr, desc)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("the arguments for sort should be 1 or 2")
+			}
+			if len(args) == 2 {
+				if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+					return ProduceErrInfo(1, "string")
+				}
+				if d, ok := args[1].(*ast.StringLiteral); ok {
+					if l := strings.ToLower(d.Val); l != "asc" && l != "desc" {
+						return fmt.Errorf("the sort direction must be asc or desc but got %s", d.Val)
+					}
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["slice"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			array, ok := args[0].([]interface{})
+			if !ok {
+				return errorArrayFirstArgumentNotArrayError, false
+			}
+			l := len(array)
+			start, err := cast.ToInt(args[1], cast.STRICT)
+			if err != nil {
+				return errorArraySecondArgumentNotIntError, false
+			}
+			end := l
+			if len(args) == 3 {
+				end, err = cast.ToInt(args[2], cast.STRICT)
+				if err != nil {
+					return errorArrayThirdArgumentNotIntError, false
+				}
+			}
+			// 1-based and negative index counts from the end
+			if start < 0 {
+				start = l + start + 1
+			}
+			if start < 1 {
+				start = 1
+			}
+			if end < 0 {
+				end = l + end + 1
+			}
+			if end > l {
+				end = l
+			}
+			if start > end {
+				return []interface{}{}, true
+			}
+			result := make([]interface{}, end-start+1)
+			copy(result, array[start-1:end])
+			return result, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 2 && len(args) != 3 {
+				return fmt.Errorf("the arguments for slice should be 2 or 3")
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			for i := 1; i < len(args); i++ {
+				if ast.IsFloatArg(args[i]) || ast.IsStringArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "int")
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["array_concat"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			var res []interface{}
 
@@ -677,6 +1185,7 @@ func registerArrayFunc() {
 	}
 	builtins["kvpair_array_to_obj"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arr, ok := args[0].([]interface{})
 			if !ok {
@@ -706,3 +1215,41 @@ func registerArrayFunc() {
 		check: returnNilIfHasAnyNil,
 	}
 }
+
+// validateTwoArrayArgs validates that the function has exactly two arguments and neither is a non-array literal
+func validateTwoArrayArgs(_ api.FunctionContext, args []ast.Expr) error {
+	if err := ValidateLen(2, len(args)); err != nil {
+		return err
+	}
+	for i, arg := range args {
+		if ast.IsNumericArg(arg) || ast.IsStringArg(arg) || ast.IsTimeArg(arg) || ast.IsBooleanArg(arg) {
+			return ProduceErrInfo(i, "array")
+		}
+	}
+	return nil
+}
+
+const defaultRangeMaxLength = 100000
+
+// rangeLength returns the element count of the range from start (inclusive) to end (exclusive) by step. The count is
+// limited by basic.rangeMaxLength to prevent allocating a huge array by mistake.
+func rangeLength(start, end, step int) (int, error) {
+	var span, stride uint64
+	switch {
+	case step > 0 && start < end:
+		span, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && start > end:
+		span, stride = uint64(start)-uint64(end), -uint64(step)
+	default:
+		return 0, nil
+	}
+	n := (span-1)/stride + 1
+	maxLen := defaultRangeMaxLength
+	if conf.Config != nil && conf.Config.Basic.RangeMaxLength > 0 {
+		maxLen = conf.Config.Basic.RangeMaxLength
+	}
+	if n > uint64(maxLen) {
+		return 0, fmt.Errorf("the range length %d exceeds the limit %d", n, maxLen)
+	}
+	return int(n), nil
+}
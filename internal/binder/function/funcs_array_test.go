@@ -17,6 +17,7 @@ package function
 import (
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
@@ -191,6 +192,34 @@ func TestArrayCommonFunctions(t *testing.T) {
 			},
 			result: errorArrayFirstArgumentNotArrayError,
 		},
+		{
+			name: "array_positions",
+			args: []interface{}{
+				[]interface{}{5, nil, 5, "5"}, 5,
+			},
+			result: []interface{}{0, 2},
+		},
+		{
+			name: "array_positions",
+			args: []interface{}{
+				[]interface{}{5, nil, 5}, "hello",
+			},
+			result: []interface{}{},
+		},
+		{
+			name: "array_positions",
+			args: []interface{}{
+				nil, 5,
+			},
+			result: []interface{}{},
+		},
+		{
+			name: "array_positions",
+			args: []interface{}{
+				1, 2,
+			},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
 		{
 			name: "array_last_position",
 			args: []interface{}{
@@ -261,6 +290,84 @@ func TestArrayCommonFunctions(t *testing.T) {
 			},
 			result: errorArrayFirstArgumentNotArrayError,
 		},
+		{
+			name: "array_intersect",
+			args: []interface{}{
+				[]interface{}{"c", "a", "b", "a"}, []interface{}{"a", "b", "c"},
+			},
+			result: []interface{}{"c", "a", "b"},
+		},
+		{
+			name: "array_intersect",
+			args: []interface{}{
+				[]interface{}{map[string]interface{}{"a": 1}, 1, map[string]interface{}{"a": 1}, []interface{}{2}, map[string]interface{}{"b": 1}},
+				[]interface{}{[]interface{}{2}, map[string]interface{}{"a": 1}, 1},
+			},
+			result: []interface{}{map[string]interface{}{"a": 1}, 1, []interface{}{2}},
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				[]interface{}{"red", "green", 1, 2}, []interface{}{2, "red", "red"},
+			},
+			result: true,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				[]interface{}{map[string]interface{}{"a": 1}, []interface{}{1, 2}, 3}, []interface{}{[]interface{}{1, 2}, map[string]interface{}{"a": 1}, 3},
+			},
+			result: true,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				[]interface{}{map[string]interface{}{"a": 1}, 3}, []interface{}{map[string]interface{}{"a": 2}},
+			},
+			result: false,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				[]interface{}{"red", "green", 1, 2}, []interface{}{"red", "blue"},
+			},
+			result: false,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				[]interface{}{1, 2}, []interface{}{int64(1)},
+			},
+			result: false,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				[]interface{}{1, 2}, []interface{}{},
+			},
+			result: true,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				nil, []interface{}{1},
+			},
+			result: false,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				"1", []interface{}{1},
+			},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			name: "array_contains_all",
+			args: []interface{}{
+				[]interface{}{1}, 1,
+			},
+			result: errorArraySecondArgumentNotArrayError,
+		},
 		{
 			name: "array_union",
 			args: []interface{}{
@@ -366,6 +473,139 @@ func TestArrayCommonFunctions(t *testing.T) {
 			},
 			result: int64(1),
 		},
+		{
+			name: "array_sum",
+			args: []interface{}{
+				[]interface{}{1, nil, 2.5, int64(3)},
+			},
+			result: 6.5,
+		},
+		{
+			name: "array_sum",
+			args: []interface{}{
+				[]interface{}{nil},
+			},
+			result: nil,
+		},
+		{
+			name: "array_sum",
+			args: []interface{}{
+				[]interface{}{1, "2"},
+			},
+			result: errors.New("requires number but found string(2)"),
+		},
+		{
+			name: "array_sum",
+			args: []interface{}{
+				1,
+			},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			name: "array_avg",
+			args: []interface{}{
+				[]interface{}{1, nil, 2.5, int64(3)},
+			},
+			result: 6.5 / 3,
+		},
+		{
+			name: "array_avg",
+			args: []interface{}{
+				[]interface{}{},
+			},
+			result: nil,
+		},
+		{
+			name: "array_avg",
+			args: []interface{}{
+				[]interface{}{true},
+			},
+			result: errors.New("requires number but found bool(true)"),
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{15, nil, 20, 35, 40, 50}, 40,
+			},
+			result: 29.0,
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{3.5, 1, int64(2)}, 0,
+			},
+			result: 1.0,
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{3.5, 1, int64(2)}, 100,
+			},
+			result: 3.5,
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{7}, 90,
+			},
+			result: 7.0,
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{nil}, 50,
+			},
+			result: nil,
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{1, 2}, 101,
+			},
+			result: errors.New("the percentile must be in range [0, 100] but got 101"),
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{1, 2}, "50",
+			},
+			result: errors.New("the second parameter requires number but found string(50)"),
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				[]interface{}{1, "a"}, 50,
+			},
+			result: errors.New("requires number but found string(a)"),
+		},
+		{
+			name: "array_percentile",
+			args: []interface{}{
+				1, 50,
+			},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			name: "array_median",
+			args: []interface{}{
+				[]interface{}{5, 1, 3},
+			},
+			result: 3.0,
+		},
+		{
+			name: "array_median",
+			args: []interface{}{
+				[]interface{}{4, 1, nil, 3, 2},
+			},
+			result: 2.5,
+		},
+		{
+			name: "array_median",
+			args: []interface{}{
+				[]interface{}{},
+			},
+			result: nil,
+		},
 		{
 			name: "array_except",
 			args: []interface{}{
@@ -556,6 +796,124 @@ func TestArrayCommonFunctions(t *testing.T) {
 			},
 			result: []interface{}{map[string]any{"a": 1}, map[string]any{"a": 1}, map[string]any{"a": 2}},
 		},
+		{
+			name: "dedup_by",
+			args: []interface{}{
+				[]interface{}{1, 2, 2, nil, 1, nil},
+			},
+			result: []interface{}{1, 2, nil},
+		},
+		{
+			name: "dedup_by",
+			args: []interface{}{
+				[]interface{}{
+					map[string]any{"id": 1, "v": "a"},
+					map[string]any{"id": 2, "v": "b"},
+					map[string]any{"id": 1, "v": "c"},
+					map[string]any{"v": "d"},
+					map[string]any{"v": "e"},
+				},
+				"id",
+			},
+			result: []interface{}{
+				map[string]any{"id": 1, "v": "a"},
+				map[string]any{"id": 2, "v": "b"},
+				map[string]any{"v": "d"},
+			},
+		},
+		{
+			name: "dedup_by",
+			args: []interface{}{
+				[]interface{}{
+					map[string]any{"dev": map[string]any{"id": "x"}, "v": 1},
+					map[string]any{"dev": map[string]any{"id": "y"}, "v": 2},
+					map[string]any{"dev": map[string]any{"id": "x"}, "v": 3},
+				},
+				"dev.id",
+			},
+			result: []interface{}{
+				map[string]any{"dev": map[string]any{"id": "x"}, "v": 1},
+				map[string]any{"dev": map[string]any{"id": "y"}, "v": 2},
+			},
+		},
+		{
+			name: "dedup_by",
+			args: []interface{}{
+				[]interface{}{map[string]any{"id": 1}, 2},
+				"id",
+			},
+			result: fmt.Errorf("dedup_by requires map elements when the key is specified but got int(2)"),
+		},
+		{
+			name: "dedup_by",
+			args: []interface{}{
+				1, "id",
+			},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			name: "dedup_by",
+			args: []interface{}{
+				[]interface{}{map[string]any{"id": 1}}, 1,
+			},
+			result: errorArraySecondArgumentNotStringError,
+		},
+		{
+			name: "json_pluck",
+			args: []interface{}{
+				[]interface{}{
+					map[string]any{"id": 1, "v": "a"},
+					map[string]any{"id": 2},
+					map[string]any{"id": 3, "v": nil},
+					"x",
+					map[string]any{"id": 4, "v": "d"},
+				},
+				"v",
+			},
+			result: []interface{}{"a", nil, "d"},
+		},
+		{
+			name: "json_pluck",
+			args: []interface{}{
+				[]interface{}{
+					map[string]any{"id": 1, "v": "a"},
+					map[string]any{"id": 2},
+					"x",
+					map[string]any{"id": 4, "v": "d"},
+				},
+				"v",
+				true,
+			},
+			result: []interface{}{"a", nil, nil, "d"},
+		},
+		{
+			name: "json_pluck",
+			args: []interface{}{
+				[]interface{}{map[string]any{"id": 1}}, "v", false,
+			},
+			result: []interface{}{},
+		},
+		{
+			name: "json_pluck",
+			args: []interface{}{
+				1, "v",
+			},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			name: "json_pluck",
+			args: []interface{}{
+				[]interface{}{map[string]any{"id": 1}}, 1,
+			},
+			result: errorArraySecondArgumentNotStringError,
+		},
+		{
+			name: "json_pluck",
+			args: []interface{}{
+				[]interface{}{map[string]any{"id": 1}}, "id", "true",
+			},
+			result: errorArrayThirdArgumentNotBoolError,
+		},
 		{
 			name: "array_map",
 			args: []interface{}{
@@ -717,6 +1075,20 @@ func TestArrayCommonFunctions(t *testing.T) {
 			},
 			result: "a,b",
 		},
+		{
+			name: "array_join",
+			args: []interface{}{
+				[]interface{}{"a", nil, "b"}, ",", "",
+			},
+			result: "a,,b",
+		},
+		{
+			name: "array_join",
+			args: []interface{}{
+				[]interface{}{1.5, true, map[string]any{"a": 1}, []interface{}{1, 2}}, "|",
+			},
+			result: "1.5|true|map[a:1]|[1 2]",
+		},
 		{
 			name: "array_concat",
 			args: []interface{}{
@@ -852,6 +1224,85 @@ func TestArrayShuffle(t *testing.T) {
 	}
 }
 
+func TestSample(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["sample"]
+	require.True(t, ok)
+	arr := []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	// random sample has k distinct elements of the array
+	for i := 0; i < 20; i++ {
+		r, ok := f.exec(fctx, []interface{}{arr, 3})
+		require.True(t, ok)
+		result := r.([]interface{})
+		require.Len(t, result, 3)
+		seen := make(map[interface{}]struct{})
+		for _, v := range result {
+			require.Contains(t, arr, v)
+			seen[v] = struct{}{}
+		}
+		require.Len(t, seen, 3)
+	}
+	// the same seed gives the same sample
+	r1, ok := f.exec(fctx, []interface{}{arr, 4, int64(42)})
+	require.True(t, ok)
+	r2, ok := f.exec(fctx, []interface{}{arr, 4, int64(42)})
+	require.True(t, ok)
+	require.Equal(t, r1, r2)
+	require.Len(t, r1, 4)
+
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "k exceeds length",
+			args:   []interface{}{arr, 20},
+			result: arr,
+		},
+		{
+			name:   "zero",
+			args:   []interface{}{arr, 0},
+			result: []interface{}{},
+		},
+		{
+			name:   "negative",
+			args:   []interface{}{arr, -1},
+			result: errors.New("the sample size should not be negative but got -1"),
+		},
+		{
+			name:   "not array",
+			args:   []interface{}{1, 1},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			name:   "invalid k",
+			args:   []interface{}{arr, "1"},
+			result: errorArraySecondArgumentNotIntError,
+		},
+		{
+			name:   "invalid seed",
+			args:   []interface{}{arr, 1, 1.5},
+			result: errorArrayThirdArgumentNotIntError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	require.Equal(t, []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, arr)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 7}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "the arguments for sample should be 2 or 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect array type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.NumberLiteral{Val: 1.5}}), "Expect int type for parameter 2")
+}
+
 func TestArraySort(t *testing.T) {
 	contextLogger := conf.Log.WithField("rule", "testExec")
 	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
@@ -904,6 +1355,258 @@ func TestArraySort(t *testing.T) {
 	}
 }
 
+func TestSort(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["sort"]
+	require.True(t, ok)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "numbers",
+			args:   []interface{}{[]interface{}{3, 1.5, int64(-2), 10}},
+			result: []interface{}{int64(-2), 1.5, 3, 10},
+		},
+		{
+			name:   "numbers desc",
+			args:   []interface{}{[]interface{}{3, 1.5, int64(-2), 10}, "desc"},
+			result: []interface{}{10, 3, 1.5, int64(-2)},
+		},
+		{
+			name:   "strings",
+			args:   []interface{}{[]interface{}{"b", "c", "a"}, "ASC"},
+			result: []interface{}{"a", "b", "c"},
+		},
+		{
+			name:   "strings desc",
+			args:   []interface{}{[]interface{}{"b", "c", "a"}, "desc"},
+			result: []interface{}{"c", "b", "a"},
+		},
+		{
+			name:   "nil at last",
+			args:   []interface{}{[]interface{}{2, nil, 1}, "desc"},
+			result: []interface{}{2, 1, nil},
+		},
+		{
+			name:   "empty",
+			args:   []interface{}{[]interface{}{}},
+			result: []interface{}{},
+		},
+		{
+			name:   "not array",
+			args:   []interface{}{"abc"},
+			result: "abc",
+		},
+		{
+			name:   "mixed",
+			args:   []interface{}{[]interface{}{1, "a"}},
+			result: errors.New("cannot sort array with incomparable elements int(1) and string(a)"),
+		},
+		{
+			name:   "incomparable",
+			args:   []interface{}{[]interface{}{true, false}},
+			result: errors.New("cannot sort array with incomparable element bool(true)"),
+		},
+		{
+			name:   "invalid direction",
+			args:   []interface{}{[]interface{}{1, 2}, "up"},
+			result: errors.New("the sort direction must be asc or desc but got up"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	// the input is not changed
+	input := []interface{}{2, 1}
+	_, _ = f.exec(fctx, []interface{}{input})
+	require.Equal(t, []interface{}{2, 1}, input)
+}
+
+func TestSlice(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["slice"]
+	require.True(t, ok)
+	arr := []interface{}{1, 2, 3, 4, 5}
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "start only",
+			args:   []interface{}{arr, 2},
+			result: []interface{}{2, 3, 4, 5},
+		},
+		{
+			name:   "start and end",
+			args:   []interface{}{arr, 2, 4},
+			result: []interface{}{2, 3, 4},
+		},
+		{
+			name:   "negative start",
+			args:   []interface{}{arr, -2},
+			result: []interface{}{4, 5},
+		},
+		{
+			name:   "negative end",
+			args:   []interface{}{arr, 1, -2},
+			result: []interface{}{1, 2, 3, 4},
+		},
+		{
+			name:   "clamp",
+			args:   []interface{}{arr, -10, 10},
+			result: []interface{}{1, 2, 3, 4, 5},
+		},
+		{
+			name:   "zero start",
+			args:   []interface{}{arr, 0, 1},
+			result: []interface{}{1},
+		},
+		{
+			name:   "start after end",
+			args:   []interface{}{arr, 4, 2},
+			result: []interface{}{},
+		},
+		{
+			name:   "out of range",
+			args:   []interface{}{arr, 6},
+			result: []interface{}{},
+		},
+		{
+			name:   "not array",
+			args:   []interface{}{1, 1},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			name:   "invalid start",
+			args:   []interface{}{arr, "1"},
+			result: errorArraySecondArgumentNotIntError,
+		},
+		{
+			name:   "invalid end",
+			args:   []interface{}{arr, 1, 2.5},
+			result: errorArrayThirdArgumentNotIntError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	require.Equal(t, []interface{}{1, 2, 3, 4, 5}, arr)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.FieldRef{Name: "b"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "the arguments for slice should be 2 or 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect array type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.NumberLiteral{Val: 1.5}}), "Expect int type for parameter 3")
+}
+
+func TestRange(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["range"]
+	require.True(t, ok)
+	oldMax := conf.Config.Basic.RangeMaxLength
+	defer func() {
+		conf.Config.Basic.RangeMaxLength = oldMax
+	}()
+	conf.Config.Basic.RangeMaxLength = 0
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "end only",
+			args:   []interface{}{int64(4)},
+			result: []interface{}{0, 1, 2, 3},
+		},
+		{
+			name:   "start and end",
+			args:   []interface{}{2, 5},
+			result: []interface{}{2, 3, 4},
+		},
+		{
+			name:   "step",
+			args:   []interface{}{1, 10, 3},
+			result: []interface{}{1, 4, 7},
+		},
+		{
+			name:   "negative step",
+			args:   []interface{}{5, 0, -2},
+			result: []interface{}{5, 3, 1},
+		},
+		{
+			name:   "empty",
+			args:   []interface{}{5, 1},
+			result: []interface{}{},
+		},
+		{
+			name:   "non positive end",
+			args:   []interface{}{-1},
+			result: []interface{}{},
+		},
+		{
+			name:   "zero step",
+			args:   []interface{}{1, 5, 0},
+			result: errors.New("invalid step: should not be zero"),
+		},
+		{
+			name:   "invalid end",
+			args:   []interface{}{"5"},
+			result: errorArrayFirstArgumentNotIntError,
+		},
+		{
+			name:   "invalid step",
+			args:   []interface{}{1, 5, 1.5},
+			result: errorArrayThirdArgumentNotIntError,
+		},
+		{
+			name:   "exceed default limit",
+			args:   []interface{}{defaultRangeMaxLength + 1},
+			result: errors.New("the range length 100001 exceeds the limit 100000"),
+		},
+		{
+			name:   "huge span",
+			args:   []interface{}{math.MinInt64, math.MaxInt64},
+			result: errors.New("the range length 18446744073709551615 exceeds the limit 100000"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	conf.Config.Basic.RangeMaxLength = 3
+	r, ok := f.exec(fctx, []interface{}{3})
+	require.True(t, ok)
+	require.Equal(t, []interface{}{0, 1, 2}, r)
+	r, ok = f.exec(fctx, []interface{}{4})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "the range length 4 exceeds the limit 3")
+
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}, &ast.FieldRef{Name: "c"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "the arguments for range should be 1 to 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "a"}}), "Expect int type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 5}, &ast.IntegerLiteral{Val: 0}}), "invalid step: should not be zero")
+}
+
 func TestArrayFuncNil(t *testing.T) {
 	contextLogger := conf.Log.WithField("rule", "testExec")
 	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
@@ -966,6 +1669,221 @@ func TestArrayFuncVal(t *testing.T) {
 			},
 			err: fmt.Errorf("Expect 1 arguments but found 2."),
 		},
+		{
+			name:     "sort with too many args",
+			funcName: "sort",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "desc"},
+				&ast.StringLiteral{Val: "desc"},
+			},
+			err: fmt.Errorf("the arguments for sort should be 1 or 2"),
+		},
+		{
+			name:     "sort with invalid direction",
+			funcName: "sort",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "up"},
+			},
+			err: fmt.Errorf("the sort direction must be asc or desc but got up"),
+		},
+		{
+			name:     "sort with non string direction",
+			funcName: "sort",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.IntegerLiteral{Val: 1},
+			},
+			err: fmt.Errorf("Expect string type for parameter 2"),
+		},
+		{
+			name:     "array_contains_all with non array",
+			funcName: "array_contains_all",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "red"},
+			},
+			err: fmt.Errorf("Expect array type for parameter 2"),
+		},
+		{
+			name:     "array_intersect with non array",
+			funcName: "array_intersect",
+			args: []ast.Expr{
+				&ast.IntegerLiteral{Val: 1},
+				&ast.FieldRef{Name: "a"},
+			},
+			err: fmt.Errorf("Expect array type for parameter 1"),
+		},
+		{
+			name:     "array_intersect with wrong args",
+			funcName: "array_intersect",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+			},
+			err: fmt.Errorf("Expect 2 arguments but found 1."),
+		},
+		{
+			name:     "array_percentile with non array",
+			funcName: "array_percentile",
+			args: []ast.Expr{
+				&ast.IntegerLiteral{Val: 1},
+				&ast.IntegerLiteral{Val: 50},
+			},
+			err: fmt.Errorf("Expect array type for parameter 1"),
+		},
+		{
+			name:     "array_percentile with string p",
+			funcName: "array_percentile",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "50"},
+			},
+			err: fmt.Errorf("Expect number - float or int type for parameter 2"),
+		},
+		{
+			name:     "array_percentile out of range",
+			funcName: "array_percentile",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.NumberLiteral{Val: -0.5},
+			},
+			err: fmt.Errorf("the percentile must be in range [0, 100] but got -0.5"),
+		},
+		{
+			name:     "array_percentile",
+			funcName: "array_percentile",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.IntegerLiteral{Val: 95},
+			},
+		},
+		{
+			name:     "array_median with too many args",
+			funcName: "array_median",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.IntegerLiteral{Val: 95},
+			},
+			err: fmt.Errorf("Expect 1 arguments but found 2."),
+		},
+		{
+			name:     "array_join with non array",
+			funcName: "array_join",
+			args: []ast.Expr{
+				&ast.StringLiteral{Val: "a"},
+				&ast.StringLiteral{Val: ","},
+			},
+			err: fmt.Errorf("Expect array type for parameter 1"),
+		},
+		{
+			name:     "array_join with non string separator",
+			funcName: "array_join",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.IntegerLiteral{Val: 1},
+			},
+			err: fmt.Errorf("Expect string type for parameter 2"),
+		},
+		{
+			name:     "array_join with non string null replacement",
+			funcName: "array_join",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: ","},
+				&ast.BooleanLiteral{Val: true},
+			},
+			err: fmt.Errorf("Expect string type for parameter 3"),
+		},
+		{
+			name:     "json_pluck with non array",
+			funcName: "json_pluck",
+			args: []ast.Expr{
+				&ast.StringLiteral{Val: "a"},
+				&ast.StringLiteral{Val: "id"},
+			},
+			err: fmt.Errorf("Expect array type for parameter 1"),
+		},
+		{
+			name:     "json_pluck with non string field",
+			funcName: "json_pluck",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.IntegerLiteral{Val: 1},
+			},
+			err: fmt.Errorf("Expect string type for parameter 2"),
+		},
+		{
+			name:     "json_pluck with non bool null fill",
+			funcName: "json_pluck",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "id"},
+				&ast.StringLiteral{Val: "true"},
+			},
+			err: fmt.Errorf("Expect bool type for parameter 3"),
+		},
+		{
+			name:     "json_pluck with too few args",
+			funcName: "json_pluck",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+			},
+			err: fmt.Errorf("Expect two or three arguments but found 1."),
+		},
+		{
+			name:     "json_pluck",
+			funcName: "json_pluck",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "id"},
+				&ast.BooleanLiteral{Val: true},
+			},
+		},
+		{
+			name:     "dedup_by with non array",
+			funcName: "dedup_by",
+			args: []ast.Expr{
+				&ast.StringLiteral{Val: "a"},
+				&ast.StringLiteral{Val: "id"},
+			},
+			err: fmt.Errorf("Expect array type for parameter 1"),
+		},
+		{
+			name:     "dedup_by with non string key",
+			funcName: "dedup_by",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.IntegerLiteral{Val: 1},
+			},
+			err: fmt.Errorf("Expect string type for parameter 2"),
+		},
+		{
+			name:     "dedup_by with too many args",
+			funcName: "dedup_by",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "id"},
+				&ast.StringLiteral{Val: "id"},
+			},
+			err: fmt.Errorf("Expect one or two arguments but found 3."),
+		},
+		{
+			name:     "dedup_by",
+			funcName: "dedup_by",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "id"},
+			},
+		},
+		{
+			name:     "sort desc",
+			funcName: "sort",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "a"},
+				&ast.StringLiteral{Val: "desc"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -17,6 +17,7 @@ package function
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
@@ -59,6 +60,54 @@ func registerColsFunc() {
 			return nil
 		},
 	}
+	builtins["array_to_fields"] = builtinFunc{
+		fType: ast.FuncTypeCols,
+		exec:  wrapColFunc(arrayToFieldsFunc),
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			arr, prefix := colFuncArg(args[0]), colFuncArg(args[1])
+			if ast.IsNumericArg(arr) || ast.IsStringArg(arr) || ast.IsTimeArg(arr) || ast.IsBooleanArg(arr) {
+				return ProduceErrInfo(0, "array")
+			}
+			if ast.IsNumericArg(prefix) || ast.IsTimeArg(prefix) || ast.IsBooleanArg(prefix) {
+				return ProduceErrInfo(1, "string")
+			}
+			return nil
+		},
+	}
+}
+
+// colFuncArg unwraps the arg of the cols function which is wrapped as ColFuncField by the parser
+func colFuncArg(arg ast.Expr) ast.Expr {
+	if cf, ok := arg.(*ast.ColFuncField); ok {
+		return cf.Expr
+	}
+	return arg
+}
+
+// arrayToFieldsFunc spreads the array elements to the columns named as prefix_index
+func arrayToFieldsFunc(_ api.FunctionContext, args []interface{}, _ []string) (ResultCols, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expect two args but got %d", len(args))
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	arr, ok := args[0].([]interface{})
+	if !ok {
+		return nil, errorArrayFirstArgumentNotArrayError
+	}
+	prefix, ok := args[1].(string)
+	if !ok {
+		return nil, errorArraySecondArgumentNotStringError
+	}
+	r := make(ResultCols, len(arr))
+	for i, v := range arr {
+		r[prefix+"_"+strconv.Itoa(i)] = v
+	}
+	return r, nil
 }
 
 func changedFunc(ctx api.FunctionContext, args []interface{}, keys []string) (ResultCols, error) {
@@ -19,6 +19,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
@@ -305,3 +307,46 @@ func TestExecIgnoreNull(t *testing.T) {
 		}
 	}
 }
+
+func TestArrayToFields(t *testing.T) {
+	f, ok := builtins["array_to_fields"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 1)
+	var nilResult ResultCols
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			args:   []interface{}{[]interface{}{1, "a", nil}, "v", []string{"arr", "v"}},
+			result: ResultCols{"v_0": 1, "v_1": "a", "v_2": nil},
+		},
+		{
+			args:   []interface{}{[]interface{}{}, "v", []string{"arr", "v"}},
+			result: ResultCols{},
+		},
+		{
+			args:   []interface{}{nil, "v", []string{"arr", "v"}},
+			result: nilResult,
+		},
+		{
+			args:   []interface{}{"a", "v", []string{"arr", "v"}},
+			result: errorArrayFirstArgumentNotArrayError,
+		},
+		{
+			args:   []interface{}{[]interface{}{1}, 1, []string{"arr", "v"}},
+			result: errorArraySecondArgumentNotStringError,
+		},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r)
+	}
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.ColFuncField{Expr: &ast.FieldRef{Name: "arr"}}, &ast.ColFuncField{Expr: &ast.StringLiteral{Val: "v"}}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.ColFuncField{Expr: &ast.StringLiteral{Val: "arr"}}, &ast.ColFuncField{Expr: &ast.StringLiteral{Val: "v"}}}), "Expect array type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.ColFuncField{Expr: &ast.FieldRef{Name: "arr"}}, &ast.ColFuncField{Expr: &ast.IntegerLiteral{Val: 1}}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.ColFuncField{Expr: &ast.FieldRef{Name: "arr"}}}), "Expect 2 arguments but found 1.")
+}
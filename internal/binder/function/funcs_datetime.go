@@ -16,11 +16,15 @@ package function
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
+	"github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 	"github.com/lf-edge/ekuiper/v2/pkg/timex"
@@ -57,8 +61,9 @@ func registerDateTimeFunc() {
 
 	builtins["format_time"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -86,8 +91,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["date_calc"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -129,12 +135,13 @@ func registerDateTimeFunc() {
 	}
 	builtins["date_diff"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
-			arg1, err := cast.InterfaceToTime(args[1], "")
+			arg1, err := interfaceToTime(ctx, args[1])
 			if err != nil {
 				return err, false
 			}
@@ -157,8 +164,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["day_name"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -177,8 +185,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["day_of_month"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -199,8 +208,9 @@ func registerDateTimeFunc() {
 
 	builtins["day_of_week"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -219,8 +229,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["day_of_year"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -239,6 +250,7 @@ func registerDateTimeFunc() {
 	}
 	builtins["from_days"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			days, err := cast.ToInt(args[0], cast.STRICT)
 			if err != nil {
@@ -269,6 +281,7 @@ func registerDateTimeFunc() {
 	}
 	builtins["from_unix_time"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			seconds, err := cast.ToInt(args[0], cast.STRICT)
 			if err != nil {
@@ -298,8 +311,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["hour"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -318,8 +332,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["last_day"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -344,8 +359,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["microsecond"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -364,8 +380,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["minute"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -384,8 +401,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["month"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -404,8 +422,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["month_name"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -424,8 +443,9 @@ func registerDateTimeFunc() {
 	}
 	builtins["second"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
 				return err, false
 			}
@@ -442,6 +462,273 @@ func registerDateTimeFunc() {
 			return nil
 		},
 	}
+	builtins["window_floor"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec:  execWindowAlign(false),
+		val:   validWindowAlignArgs,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["window_ceil"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec:  execWindowAlign(true),
+		val:   validWindowAlignArgs,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["time_bucket"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			t, err := interfaceToTime(ctx, args[0])
+			if err != nil {
+				return err, false
+			}
+			loc := ruleTimeZone(ctx)
+			if len(args) > 2 {
+				loc, err = loadBucketLocation(cast.ToStringAlways(args[2]))
+				if err != nil {
+					return err, false
+				}
+			}
+			r, err := timeBucket(t.In(loc), cast.ToStringAlways(args[1]))
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 2 && len(args) != 3 {
+				return fmt.Errorf("Expect 2 or 3 arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "datetime")
+			}
+			u, ok := args[1].(*ast.StringLiteral)
+			if !ok {
+				return ProduceErrInfo(1, "string literal")
+			}
+			if _, err := timeBucket(time.Time{}, u.Val); err != nil {
+				return err
+			}
+			if len(args) == 3 {
+				if ast.IsNumericArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsBooleanArg(args[2]) {
+					return ProduceErrInfo(2, "string")
+				}
+				if tz, ok := args[2].(*ast.StringLiteral); ok {
+					if _, err := loadBucketLocation(tz.Val); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["parse_duration"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			s, ok := args[0].(string)
+			if !ok {
+				return fmt.Errorf("the duration should be a string but got %v", args[0]), false
+			}
+			d, err := parseDuration(s)
+			if err != nil {
+				return err, false
+			}
+			return d.Milliseconds(), true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(1, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["staleness"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			// The parser fills event_time() as the arg if it is omitted, so it is only missing in direct calls
+			if len(args) == 0 {
+				return fmt.Errorf("the event time is not available"), false
+			}
+			t, err := cast.InterfaceToUnixMilli(args[0], "")
+			if err != nil {
+				return err, false
+			}
+			// Future time results in negative staleness to reveal the clock skew
+			return timex.GetNowInMilli() - t, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) > 1 {
+				return fmt.Errorf("Expect zero or one argument but found %d.", len(args))
+			}
+			if len(args) == 1 && (ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsBooleanArg(args[0])) {
+				return ProduceErrInfo(0, "datetime")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+}
+
+// execWindowAlign returns a function that aligns the time to the start (floor) or the end (ceil) of the
+// fixed size window containing it. The windows are aligned to the epoch plus the optional offset in milliseconds.
+func execWindowAlign(ceil bool) funcExe {
+	return func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+		t, err := interfaceToTime(ctx, args[0])
+		if err != nil {
+			return err, false
+		}
+		size, err := cast.ToInt64(args[1], cast.STRICT)
+		if err != nil {
+			return fmt.Errorf("the window size should be an integer but got %v", args[1]), false
+		}
+		if size <= 0 {
+			return fmt.Errorf("the window size should be positive but got %d", size), false
+		}
+		var offset int64
+		if len(args) > 2 {
+			offset, err = cast.ToInt64(args[2], cast.STRICT)
+			if err != nil {
+				return fmt.Errorf("the window offset should be an integer but got %v", args[2]), false
+			}
+		}
+		ms := t.UnixMilli()
+		rem := (ms - offset) % size
+		if rem < 0 {
+			rem += size
+		}
+		start := ms - rem
+		if ceil && (rem != 0 || t.Nanosecond()%int(time.Millisecond) != 0) {
+			start += size
+		}
+		return time.UnixMilli(start).In(t.Location()), true
+	}
+}
+
+// validWindowAlignArgs validates the args of window_floor and window_ceil: a datetime, an int size and an optional int offset.
+func validWindowAlignArgs(_ api.FunctionContext, args []ast.Expr) error {
+	if len(args) != 2 && len(args) != 3 {
+		return fmt.Errorf("Expect 2 or 3 arguments but found %d.", len(args))
+	}
+	if ast.IsNumericArg(args[0]) || ast.IsStringArg(args[0]) || ast.IsBooleanArg(args[0]) {
+		return ProduceErrInfo(0, "datetime")
+	}
+	for i := 1; i < len(args); i++ {
+		if ast.IsFloatArg(args[i]) || ast.IsStringArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+			return ProduceErrInfo(i, "int")
+		}
+	}
+	if v, ok := args[1].(*ast.IntegerLiteral); ok && v.Val <= 0 {
+		return fmt.Errorf("the window size should be positive but got %d", v.Val)
+	}
+	return nil
+}
+
+// parseDuration parses an ISO 8601 duration such as PT15M or a Go duration such as 1h30m
+func parseDuration(s string) (time.Duration, error) {
+	t := strings.TrimLeft(s, "+-")
+	if strings.HasPrefix(t, "P") || strings.HasPrefix(t, "p") {
+		return parseISODuration(s)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s, expect an ISO 8601 duration such as PT15M or a duration such as 1h30m", s)
+	}
+	return d, nil
+}
+
+// isoDurationUnits are the supported ISO 8601 duration units in order. The years and months are not supported
+// because their lengths vary.
+var isoDurationUnits = []struct {
+	designator byte
+	inTime     bool
+	unit       time.Duration
+}{
+	{'W', false, 7 * 24 * time.Hour},
+	{'D', false, 24 * time.Hour},
+	{'H', true, time.Hour},
+	{'M', true, time.Minute},
+	{'S', true, time.Second},
+}
+
+// parseISODuration parses the ISO 8601 duration in the form of [-]P[nW][nD][T[nH][nM][nS]].
+// The number of any component can be a decimal fraction.
+func parseISODuration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid ISO 8601 duration %s", s)
+	str := strings.ToUpper(s)
+	neg := false
+	switch str[0] {
+	case '-':
+		neg = true
+		str = str[1:]
+	case '+':
+		str = str[1:]
+	}
+	str = str[1:] // skip P
+	var (
+		d        float64
+		inTime   bool
+		next     int // the index of the next allowed unit
+		hasValue bool
+	)
+	for len(str) > 0 {
+		if str[0] == 'T' {
+			if inTime {
+				return 0, invalid
+			}
+			inTime = true
+			str = str[1:]
+			if len(str) == 0 {
+				return 0, invalid
+			}
+			continue
+		}
+		i := 0
+		for i < len(str) && (str[i] >= '0' && str[i] <= '9' || str[i] == '.' || str[i] == ',') {
+			i++
+		}
+		if i == 0 || i == len(str) {
+			return 0, invalid
+		}
+		n, err := strconv.ParseFloat(strings.Replace(str[:i], ",", ".", 1), 64)
+		if err != nil {
+			return 0, invalid
+		}
+		designator := str[i]
+		if !inTime && (designator == 'Y' || designator == 'M') {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %s, years and months are not supported", s)
+		}
+		found := false
+		for j := next; j < len(isoDurationUnits); j++ {
+			u := isoDurationUnits[j]
+			if u.designator == designator && u.inTime == inTime {
+				d += n * float64(u.unit)
+				next = j + 1
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, invalid
+		}
+		hasValue = true
+		str = str[i+1:]
+	}
+	if !hasValue {
+		return 0, invalid
+	}
+	if d >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %s, the duration is out of range", s)
+	}
+	if neg {
+		d = -d
+	}
+	return time.Duration(d), nil
 }
 
 func execGetCurrentDate() funcExe {
@@ -521,3 +808,63 @@ func getCurrentWithFsp(fsp int, timeOnly bool) (string, error) {
 
 	return formatted, nil
 }
+
+// timeBucket returns the start of the calendar unit containing t in the location of t. The week starts on Monday.
+// The minute and hour buckets are aligned by the offset of t so that the repeated hour of a DST transition is
+// bucketed correctly. The others are computed by the calendar date which handles the various day lengths.
+func timeBucket(t time.Time, unit string) (time.Time, error) {
+	switch strings.ToLower(unit) {
+	case "minute", "hour":
+		size := int64(time.Minute / time.Second)
+		if strings.ToLower(unit) == "hour" {
+			size = int64(time.Hour / time.Second)
+		}
+		_, offset := t.Zone()
+		local := t.Unix() + int64(offset)
+		rem := local % size
+		if rem < 0 {
+			rem += size
+		}
+		return time.Unix(t.Unix()-rem, 0).In(t.Location()), nil
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	case "week":
+		days := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, t.Location()), nil
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()), nil
+	case "year":
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location()), nil
+	default:
+		return t, fmt.Errorf("unsupported time unit %s, expect one of minute, hour, day, week, month, year", unit)
+	}
+}
+
+// loadBucketLocation loads the time zone by the IANA name such as Asia/Shanghai or the fixed offset such as +08:00
+func loadBucketLocation(tz string) (*time.Location, error) {
+	if strings.HasPrefix(tz, "+") || strings.HasPrefix(tz, "-") {
+		for _, layout := range []string{"-07:00", "-0700", "-07"} {
+			if ot, err := time.Parse(layout, tz); err == nil {
+				_, offset := ot.Zone()
+				return time.FixedZone(tz, offset), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time zone offset %s, expect the format such as +08:00", tz)
+	}
+	return cast.LoadLocation(tz)
+}
+
+// ruleTimeZone returns the default time zone of the rule if configured, otherwise returns the global time zone
+func ruleTimeZone(ctx api.FunctionContext) *time.Location {
+	if ctx != nil {
+		if loc, ok := ctx.Value(context.TimeZoneKey).(*time.Location); ok && loc != nil {
+			return loc
+		}
+	}
+	return cast.GetConfiguredTimeZone()
+}
+
+// interfaceToTime converts the value to time. The time without zone information is interpreted in the rule time zone.
+func interfaceToTime(ctx api.FunctionContext, v interface{}) (time.Time, error) {
+	return cast.InterfaceToTimeInLocation(v, "", ruleTimeZone(ctx))
+}
@@ -918,3 +918,242 @@ func TestValidateFsp(t *testing.T) {
 	err := f(fctx, []ast.Expr{})
 	require.NoError(t, err)
 }
+
+func TestWindowAlign(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	floor, ok := builtins["window_floor"]
+	require.True(t, ok)
+	ceil, ok := builtins["window_ceil"]
+	require.True(t, ok)
+	tests := []struct {
+		args  []interface{}
+		floor int64
+		ceil  int64
+	}{
+		{args: []interface{}{int64(1700000012345), 10000}, floor: 1700000010000, ceil: 1700000020000},
+		{args: []interface{}{int64(1700000010000), 10000}, floor: 1700000010000, ceil: 1700000010000},
+		{args: []interface{}{int64(1700000012345), 10000, 3000}, floor: 1700000003000, ceil: 1700000013000},
+		{args: []interface{}{int64(1700000012345), int64(60000), -5000}, floor: 1699999975000, ceil: 1700000035000},
+		{args: []interface{}{time.UnixMilli(-1500), 1000}, floor: -2000, ceil: -1000},
+		{args: []interface{}{time.Unix(10, 1), 1000}, floor: 10000, ceil: 11000},
+	}
+	for _, tt := range tests {
+		r, ok := floor.exec(fctx, tt.args)
+		require.True(t, ok)
+		require.Equal(t, tt.floor, r.(time.Time).UnixMilli(), fmt.Sprintf("floor %v", tt.args))
+		r, ok = ceil.exec(fctx, tt.args)
+		require.True(t, ok)
+		require.Equal(t, tt.ceil, r.(time.Time).UnixMilli(), fmt.Sprintf("ceil %v", tt.args))
+	}
+
+	r, ok := floor.exec(fctx, []interface{}{int64(1000), 0})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "the window size should be positive but got 0")
+	r, ok = floor.exec(fctx, []interface{}{int64(1000), "1s"})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "the window size should be an integer but got 1s")
+	r, ok = ceil.exec(fctx, []interface{}{true, 1000})
+	require.False(t, ok)
+	require.Error(t, r.(error))
+
+	require.NoError(t, floor.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ts"}, &ast.IntegerLiteral{Val: 1000}, &ast.IntegerLiteral{Val: 10}}))
+	require.EqualError(t, floor.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ts"}}), "Expect 2 or 3 arguments but found 1.")
+	require.EqualError(t, floor.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "ts"}, &ast.IntegerLiteral{Val: 1000}}), "Expect datetime type for parameter 1")
+	require.EqualError(t, floor.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ts"}, &ast.NumberLiteral{Val: 1.5}}), "Expect int type for parameter 2")
+	require.EqualError(t, ceil.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ts"}, &ast.IntegerLiteral{Val: 1000}, &ast.StringLiteral{Val: "1s"}}), "Expect int type for parameter 3")
+	require.EqualError(t, ceil.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ts"}, &ast.IntegerLiteral{Val: -1}}), "the window size should be positive but got -1")
+}
+
+func TestParseDuration(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["parse_duration"]
+	require.True(t, ok)
+	tests := []struct {
+		arg    interface{}
+		result interface{}
+	}{
+		{"PT15M", int64(900000)},
+		{"PT1H30M", int64(5400000)},
+		{"P1DT2H", int64(93600000)},
+		{"P2W", int64(1209600000)},
+		{"PT0.5S", int64(500)},
+		{"PT1,5M", int64(90000)},
+		{"pt10s", int64(10000)},
+		{"-PT1M", int64(-60000)},
+		{"15m", int64(900000)},
+		{"1h30m", int64(5400000)},
+		{"-1.5s", int64(-1500)},
+		{"P1Y", errors.New("invalid ISO 8601 duration P1Y, years and months are not supported")},
+		{"P1M", errors.New("invalid ISO 8601 duration P1M, years and months are not supported")},
+		{"P", errors.New("invalid ISO 8601 duration P")},
+		{"PT", errors.New("invalid ISO 8601 duration PT")},
+		{"P1H", errors.New("invalid ISO 8601 duration P1H")},
+		{"PT1S1M", errors.New("invalid ISO 8601 duration PT1S1M")},
+		{"PT1M1M", errors.New("invalid ISO 8601 duration PT1M1M")},
+		{"PTS", errors.New("invalid ISO 8601 duration PTS")},
+		{"P1DT", errors.New("invalid ISO 8601 duration P1DT")},
+		{"P99999999W", errors.New("invalid ISO 8601 duration P99999999W, the duration is out of range")},
+		{"15 minutes", errors.New("invalid duration 15 minutes, expect an ISO 8601 duration such as PT15M or a duration such as 1h30m")},
+		{1000, errors.New("the duration should be a string but got 1000")},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, []interface{}{tt.arg})
+		require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.arg))
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "Expect 1 arguments but found 0.")
+}
+
+func TestStaleness(t *testing.T) {
+	now := time.Now()
+	m := mockclock.GetMockClock()
+	m.Set(now)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["staleness"]
+	require.True(t, ok)
+	nowMilli := now.UnixMilli()
+	tests := []struct {
+		arg    interface{}
+		result interface{}
+	}{
+		{nowMilli - 1500, int64(1500)},
+		{now.Add(-time.Minute), int64(60000)},
+		{nowMilli + 1000, int64(-1000)},
+		{true, errors.New("unsupported type to convert to timestamp true")},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, []interface{}{tt.arg})
+		require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.arg))
+	}
+	r, ok := f.exec(fctx, []interface{}{})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "the event time is not available")
+	require.NoError(t, f.val(fctx, []ast.Expr{}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect datetime type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}), "Expect zero or one argument but found 2.")
+}
+
+func TestTimeBucket(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	ctx = kctx.WithValue(ctx, kctx.TimeZoneKey, shanghai)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["time_bucket"]
+	require.True(t, ok)
+	utc := func(s string) time.Time {
+		tt, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return tt
+	}
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result string
+		err    string
+	}{
+		{
+			name:   "hour after spring forward",
+			args:   []interface{}{utc("2024-03-10T07:30:00Z"), "hour", "America/New_York"},
+			result: "2024-03-10T03:00:00-04:00",
+		},
+		{
+			name:   "day of spring forward",
+			args:   []interface{}{utc("2024-03-10T07:30:00Z"), "day", "America/New_York"},
+			result: "2024-03-10T00:00:00-05:00",
+		},
+		{
+			name:   "first repeated hour of fall back",
+			args:   []interface{}{utc("2024-11-03T05:30:00Z"), "hour", "America/New_York"},
+			result: "2024-11-03T01:00:00-04:00",
+		},
+		{
+			name:   "second repeated hour of fall back",
+			args:   []interface{}{utc("2024-11-03T06:30:00Z"), "hour", "America/New_York"},
+			result: "2024-11-03T01:00:00-05:00",
+		},
+		{
+			name:   "week across DST",
+			args:   []interface{}{utc("2024-03-13T12:00:00Z"), "week", "America/New_York"},
+			result: "2024-03-11T00:00:00-04:00",
+		},
+		{
+			name:   "week of sunday",
+			args:   []interface{}{utc("2024-03-10T12:00:00Z"), "WEEK", "America/New_York"},
+			result: "2024-03-04T00:00:00-05:00",
+		},
+		{
+			name:   "month with fixed offset",
+			args:   []interface{}{utc("2024-03-31T23:00:00Z"), "month", "+08:00"},
+			result: "2024-04-01T00:00:00+08:00",
+		},
+		{
+			name:   "minute with half hour offset",
+			args:   []interface{}{utc("2024-01-01T10:45:30Z"), "minute", "+0530"},
+			result: "2024-01-01T16:15:00+05:30",
+		},
+		{
+			name:   "hour with half hour offset",
+			args:   []interface{}{utc("2024-01-01T10:45:30Z"), "hour", "Asia/Kolkata"},
+			result: "2024-01-01T16:00:00+05:30",
+		},
+		{
+			name:   "year in rule time zone",
+			args:   []interface{}{utc("2023-12-31T17:00:00Z"), "year"},
+			result: "2024-01-01T00:00:00+08:00",
+		},
+		{
+			name:   "unix milli",
+			args:   []interface{}{utc("2024-05-20T10:00:00Z").UnixMilli(), "day", "UTC"},
+			result: "2024-05-20T00:00:00Z",
+		},
+		{
+			name: "invalid unit",
+			args: []interface{}{utc("2024-05-20T10:00:00Z"), "decade", "UTC"},
+			err:  "unsupported time unit decade, expect one of minute, hour, day, week, month, year",
+		},
+		{
+			name: "invalid offset",
+			args: []interface{}{utc("2024-05-20T10:00:00Z"), "day", "+8h"},
+			err:  "invalid time zone offset +8h, expect the format such as +08:00",
+		},
+		{
+			name: "invalid time zone",
+			args: []interface{}{utc("2024-05-20T10:00:00Z"), "day", "Mars/Base"},
+			err:  "unknown time zone Mars/Base",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, tt.args)
+			if tt.err != "" {
+				require.False(t, ok)
+				require.EqualError(t, r.(error), tt.err)
+			} else {
+				require.True(t, ok)
+				require.Equal(t, tt.result, r.(time.Time).Format(time.RFC3339))
+			}
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "day"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "month"}, &ast.FieldRef{Name: "tz"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 or 3 arguments but found 1.")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "day"}}), "Expect datetime type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "unit"}}), "Expect string literal type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "decade"}}), "unsupported time unit decade, expect one of minute, hour, day, week, month, year")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "day"}, &ast.IntegerLiteral{Val: 8}}), "Expect string type for parameter 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "day"}, &ast.StringLiteral{Val: "+8h"}}), "invalid time zone offset +8h, expect the format such as +08:00")
+}
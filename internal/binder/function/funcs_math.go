@@ -20,6 +20,7 @@ import (
 	"math"
 	"math/cmplx"
 	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -39,6 +40,7 @@ const (
 func registerMathFunc() {
 	builtins["abs"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			switch v := args[0].(type) {
 			case int:
@@ -62,6 +64,7 @@ func registerMathFunc() {
 	}
 	builtins["acos"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Acos(v)
@@ -79,6 +82,7 @@ func registerMathFunc() {
 	}
 	builtins["asin"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Asin(v)
@@ -96,6 +100,7 @@ func registerMathFunc() {
 	}
 	builtins["atan"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Atan(v)
@@ -113,6 +118,7 @@ func registerMathFunc() {
 	}
 	builtins["atan2"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v1, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				if v2, e1 := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND); e1 == nil {
@@ -134,6 +140,7 @@ func registerMathFunc() {
 	}
 	builtins["bitand"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			v1, err := cast.ToInt(args[0], cast.STRICT)
 			if err != nil {
@@ -150,6 +157,7 @@ func registerMathFunc() {
 	}
 	builtins["bitor"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			v1, err := cast.ToInt(args[0], cast.STRICT)
 			if err != nil {
@@ -166,6 +174,7 @@ func registerMathFunc() {
 	}
 	builtins["bitxor"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			v1, err := cast.ToInt(args[0], cast.STRICT)
 			if err != nil {
@@ -182,6 +191,7 @@ func registerMathFunc() {
 	}
 	builtins["bitnot"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			v1, err := cast.ToInt(args[0], cast.STRICT)
 			if err != nil {
@@ -202,6 +212,7 @@ func registerMathFunc() {
 	}
 	builtins["ceiling"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				return math.Ceil(v), true
@@ -215,6 +226,7 @@ func registerMathFunc() {
 	builtins["ceil"] = builtins["ceiling"] // Synonym for CEILING.
 	builtins["cos"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Cos(v)
@@ -232,6 +244,7 @@ func registerMathFunc() {
 	}
 	builtins["cosh"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Cosh(v)
@@ -249,6 +262,7 @@ func registerMathFunc() {
 	}
 	builtins["exp"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Exp(v)
@@ -266,6 +280,7 @@ func registerMathFunc() {
 	}
 	builtins["floor"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				return math.Floor(v), true
@@ -278,6 +293,7 @@ func registerMathFunc() {
 	}
 	builtins["ln"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Log(v)
@@ -295,6 +311,7 @@ func registerMathFunc() {
 	}
 	builtins["log"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
 			if e != nil {
@@ -328,24 +345,153 @@ func registerMathFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["log10"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec:  execPositiveLog("log10", math.Log10),
+		val:   ValidateOneNumberArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["log2"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec:  execPositiveLog("log2", math.Log2),
+		val:   ValidateOneNumberArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["safe_div"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			var b float64
+			if args[1] != nil {
+				var err error
+				b, err = cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND)
+				if err != nil {
+					return err, false
+				}
+			}
+			if b == 0 {
+				if args[2] == nil {
+					return nil, true
+				}
+				dft, err := cast.ToFloat64(args[2], cast.CONVERT_SAMEKIND)
+				if err != nil {
+					return err, false
+				}
+				return dft, true
+			}
+			a, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			return a / b, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(3, len(args)); err != nil {
+				return err
+			}
+			for i, arg := range args {
+				if ast.IsStringArg(arg) || ast.IsTimeArg(arg) || ast.IsBooleanArg(arg) {
+					return ProduceErrInfo(i, "number - float or int")
+				}
+			}
+			return nil
+		},
+		// Only a nil dividend returns nil, a nil divisor returns the default value
+		check: func(args []interface{}) (interface{}, bool) {
+			if args[0] == nil {
+				return nil, true
+			}
+			return nil, false
+		},
+	}
 	builtins["mod"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
-				if v1, e1 := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND); e == nil {
-					return math.Mod(v, v1), true
-				} else {
-					return e1, false
+			floored, err := isFlooredDivision(args)
+			if err != nil {
+				return err, false
+			}
+			v, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			v1, err := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			if v1 == 0 {
+				return fmt.Errorf("division by zero"), false
+			}
+			r := math.Mod(v, v1)
+			// The floored remainder has the same sign as the divisor
+			if floored && r != 0 && (r < 0) != (v1 < 0) {
+				r += v1
+			}
+			return r, true
+		},
+		val:   validateDivisionArgs,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["int_div"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			floored, err := isFlooredDivision(args)
+			if err != nil {
+				return err, false
+			}
+			if isIntegerValue(args[0]) && isIntegerValue(args[1]) {
+				a, err := cast.ToInt64(args[0], cast.STRICT)
+				if err != nil {
+					return err, false
+				}
+				b, err := cast.ToInt64(args[1], cast.STRICT)
+				if err != nil {
+					return err, false
+				}
+				if b == 0 {
+					return fmt.Errorf("division by zero"), false
 				}
+				if a == math.MinInt64 && b == -1 {
+					return fmt.Errorf("the quotient of %d / %d overflows bigint", a, b), false
+				}
+				q := a / b
+				if floored && a%b != 0 && (a < 0) != (b < 0) {
+					q--
+				}
+				return q, true
+			}
+			a, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			b, err := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			if b == 0 {
+				return fmt.Errorf("division by zero"), false
+			}
+			q := a / b
+			if floored {
+				q = math.Floor(q)
 			} else {
-				return e, false
+				q = math.Trunc(q)
 			}
+			if math.IsNaN(q) || q < math.MinInt64 || q >= math.MaxInt64 {
+				return fmt.Errorf("the quotient of %v / %v overflows bigint", a, b), false
+			}
+			return int64(q), true
 		},
-		val:   ValidateTwoNumberArg,
+		val:   validateDivisionArgs,
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["pi"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(_ api.FunctionContext, _ []interface{}) (interface{}, bool) {
 			return math.Pi, true
 		},
@@ -354,6 +500,7 @@ func registerMathFunc() {
 	}
 	builtins["power"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v1, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				if v2, e2 := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND); e2 == nil {
@@ -378,6 +525,7 @@ func registerMathFunc() {
 	}
 	builtins["round"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				return math.Round(v), true
@@ -390,6 +538,7 @@ func registerMathFunc() {
 	}
 	builtins["sign"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				if v > 0 {
@@ -408,6 +557,7 @@ func registerMathFunc() {
 	}
 	builtins["sin"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Sin(v)
@@ -425,6 +575,7 @@ func registerMathFunc() {
 	}
 	builtins["sinh"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Sinh(v)
@@ -442,6 +593,7 @@ func registerMathFunc() {
 	}
 	builtins["sqrt"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Sqrt(v)
@@ -459,6 +611,7 @@ func registerMathFunc() {
 	}
 	builtins["tan"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Tan(v)
@@ -476,6 +629,7 @@ func registerMathFunc() {
 	}
 	builtins["tanh"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := math.Tanh(v)
@@ -493,6 +647,7 @@ func registerMathFunc() {
 	}
 	builtins["cot"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := real(cmplx.Cot(complex(v, 0)))
@@ -512,6 +667,7 @@ func registerMathFunc() {
 	}
 	builtins["radians"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := radians(v)
@@ -529,6 +685,7 @@ func registerMathFunc() {
 	}
 	builtins["degrees"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, e := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND); e == nil {
 				r := degrees(v)
@@ -546,6 +703,7 @@ func registerMathFunc() {
 	}
 	builtins["conv"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			arg1, _ := cast.ToInt64(args[1], cast.CONVERT_SAMEKIND)
@@ -575,6 +733,110 @@ func registerMathFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["histogram_bucket"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			v, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			bounds, err := cast.ToFloat64Slice(args[1], cast.CONVERT_SAMEKIND, cast.FORCE_CONVERT)
+			if err != nil {
+				return err, false
+			}
+			r, err := histogramBucket(v, bounds)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "number - float or int")
+			}
+			if ast.IsNumericArg(args[1]) || ast.IsStringArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+				return ProduceErrInfo(1, "array")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+}
+
+// execPositiveLog returns the exec function of a logarithm which reports an error for a non-positive argument
+func execPositiveLog(name string, f func(float64) float64) funcExe {
+	return func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+		v, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return err, false
+		}
+		if v <= 0 {
+			return fmt.Errorf("%s requires a positive argument but got %v", name, v), false
+		}
+		return f(v), true
+	}
+}
+
+// isFlooredDivision returns whether the optional mode argument of mod and int_div is floored.
+// The truncated mode like C is the default, the floored mode is like Python.
+func isFlooredDivision(args []interface{}) (bool, error) {
+	if len(args) < 3 {
+		return false, nil
+	}
+	switch mode := cast.ToStringAlways(args[2]); strings.ToLower(mode) {
+	case "truncated":
+		return false, nil
+	case "floored":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported division mode %s, expect truncated or floored", mode)
+	}
+}
+
+func validateDivisionArgs(_ api.FunctionContext, args []ast.Expr) error {
+	if len(args) != 2 && len(args) != 3 {
+		return fmt.Errorf("Expect 2 or 3 arguments but found %d.", len(args))
+	}
+	for i := 0; i < 2; i++ {
+		if ast.IsStringArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+			return ProduceErrInfo(i, "number - float or int")
+		}
+	}
+	if len(args) == 3 {
+		m, ok := args[2].(*ast.StringLiteral)
+		if !ok {
+			return ProduceErrInfo(2, "string literal")
+		}
+		if _, err := isFlooredDivision([]interface{}{nil, nil, m.Val}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isIntegerValue(v interface{}) bool {
+	switch v.(type) {
+	case int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8:
+		return true
+	default:
+		return false
+	}
+}
+
+// histogramBucket returns the zero-based index of the bucket which the value falls in.
+// The bucket i covers (bounds[i-1], bounds[i]], so a value below or equal to the first bound returns 0
+// and a value above the last bound returns len(bounds).
+func histogramBucket(v float64, bounds []float64) (int, error) {
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] < bounds[i-1] {
+			return 0, fmt.Errorf("histogram bounds must be in ascending order but got %v", bounds)
+		}
+	}
+	return sort.SearchFloat64s(bounds, v), nil
 }
 
 func radians(degrees float64) float64 {
@@ -27,6 +27,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
 
 func TestFuncMath(t *testing.T) {
@@ -568,3 +569,156 @@ func TestConvFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestHistogramBucket(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["histogram_bucket"]
+	require.True(t, ok)
+	bounds := []interface{}{10, 20.5, 50}
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{args: []interface{}{5, bounds}, result: 0},
+		{args: []interface{}{10, bounds}, result: 0},
+		{args: []interface{}{15.2, bounds}, result: 1},
+		{args: []interface{}{20.5, bounds}, result: 1},
+		{args: []interface{}{49, bounds}, result: 2},
+		{args: []interface{}{51, bounds}, result: 3},
+		{args: []interface{}{1, []interface{}{}}, result: 0},
+		{args: []interface{}{1, []interface{}{10, 5}}, result: fmt.Errorf("histogram bounds must be in ascending order but got [10 5]")},
+		{args: []interface{}{"a", bounds}, result: fmt.Errorf("cannot convert string(a) to float64")},
+		{args: []interface{}{1, "a"}, result: fmt.Errorf("cannot convert string(a) to float slice)")},
+	}
+	for i, tt := range tests {
+		result, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, result, "case %d", i)
+	}
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect 2 arguments but found 1.")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.FieldRef{Name: "b"}}), "Expect number - float or int type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 1}}), "Expect array type for parameter 2")
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}))
+}
+
+func TestLogFunctions(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{"log10", []interface{}{1000}, 3.0},
+		{"log10", []interface{}{0.01}, -2.0},
+		{"log10", []interface{}{0}, fmt.Errorf("log10 requires a positive argument but got 0")},
+		{"log10", []interface{}{-10.5}, fmt.Errorf("log10 requires a positive argument but got -10.5")},
+		{"log10", []interface{}{"a"}, fmt.Errorf("cannot convert string(a) to float64")},
+		{"log2", []interface{}{8}, 3.0},
+		{"log2", []interface{}{int64(1)}, 0.0},
+		{"log2", []interface{}{-1}, fmt.Errorf("log2 requires a positive argument but got -1")},
+		// ln and log keep returning nil for a negative argument
+		{"ln", []interface{}{math.E}, 1.0},
+		{"ln", []interface{}{-1}, nil},
+		{"log", []interface{}{100}, 2.0},
+		{"log", []interface{}{-1}, nil},
+		{"log", []interface{}{2, 8}, 3.0},
+		{"log", []interface{}{-2, 8}, nil},
+	}
+	for i, tt := range tests {
+		f, ok := builtins[tt.name]
+		require.True(t, ok)
+		result, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, result, "case %d", i)
+	}
+	for _, name := range []string{"log10", "log2"} {
+		f := builtins[name]
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "a"}}), "Expect number - float or int type for parameter 1")
+		r, _ := f.check([]interface{}{nil})
+		require.Nil(t, r)
+	}
+}
+
+func TestSafeDiv(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f, ok := builtins["safe_div"]
+	require.True(t, ok)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{10, 4, 0}, 2.5},
+		{[]interface{}{1.5, int64(-3), 0}, -0.5},
+		{[]interface{}{10, 0, -1}, -1.0},
+		{[]interface{}{10, 0.0, 0.5}, 0.5},
+		{[]interface{}{10, nil, 0}, 0.0},
+		{[]interface{}{10, 0, nil}, nil},
+		{[]interface{}{"a", 2, 0}, fmt.Errorf("cannot convert string(a) to float64")},
+		{[]interface{}{10, 0, "a"}, fmt.Errorf("cannot convert string(a) to float64")},
+	}
+	for i, tt := range tests {
+		result, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, result, "case %d", i)
+	}
+	r, skip := f.check([]interface{}{nil, 2, 0})
+	require.True(t, skip)
+	require.Nil(t, r)
+	_, skip = f.check([]interface{}{1, nil, 0})
+	require.False(t, skip)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}, &ast.IntegerLiteral{Val: 0}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}), "Expect 3 arguments but found 2.")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}, &ast.StringLiteral{Val: "0"}}), "Expect number - float or int type for parameter 3")
+}
+
+func TestDivisionMode(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	fMod, ok := builtins["mod"]
+	require.True(t, ok)
+	fDiv, ok := builtins["int_div"]
+	require.True(t, ok)
+	tests := []struct {
+		args []interface{}
+		mod  interface{}
+		div  interface{}
+	}{
+		{[]interface{}{7, 2}, 1.0, int64(3)},
+		{[]interface{}{-7, 2}, -1.0, int64(-3)},
+		{[]interface{}{-7, 2, "truncated"}, -1.0, int64(-3)},
+		{[]interface{}{-7, 2, "floored"}, 1.0, int64(-4)},
+		{[]interface{}{7, int64(-2), "FLOORED"}, -1.0, int64(-4)},
+		{[]interface{}{-7, -2, "floored"}, -1.0, int64(3)},
+		{[]interface{}{-6, 2, "floored"}, 0.0, int64(-3)},
+		{[]interface{}{-7.5, 2, "truncated"}, -1.5, int64(-3)},
+		{[]interface{}{-7.5, 2, "floored"}, 0.5, int64(-4)},
+		{[]interface{}{7, 0}, fmt.Errorf("division by zero"), fmt.Errorf("division by zero")},
+		{[]interface{}{7.5, 0.0, "floored"}, fmt.Errorf("division by zero"), fmt.Errorf("division by zero")},
+		{[]interface{}{7, 2, "round"}, fmt.Errorf("unsupported division mode round, expect truncated or floored"), fmt.Errorf("unsupported division mode round, expect truncated or floored")},
+		{[]interface{}{"a", 2}, fmt.Errorf("cannot convert string(a) to float64"), fmt.Errorf("cannot convert string(a) to float64")},
+		{[]interface{}{int64(math.MinInt64), -1}, 0.0, fmt.Errorf("the quotient of -9223372036854775808 / -1 overflows bigint")},
+		{[]interface{}{1e300, 1e-10}, math.Mod(1e300, 1e-10), fmt.Errorf("the quotient of 1e+300 / 1e-10 overflows bigint")},
+	}
+	for i, tt := range tests {
+		r, _ := fMod.exec(fctx, tt.args)
+		require.Equal(t, tt.mod, r, "mod case %d", i)
+		r, _ = fDiv.exec(fctx, tt.args)
+		require.Equal(t, tt.div, r, "int_div case %d", i)
+	}
+	for _, f := range []builtinFunc{fMod, fDiv} {
+		require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 2}}))
+		require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}, &ast.StringLiteral{Val: "floored"}}))
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 or 3 arguments but found 1.")
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.IntegerLiteral{Val: 2}}), "Expect number - float or int type for parameter 1")
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}, &ast.FieldRef{Name: "mode"}}), "Expect string literal type for parameter 3")
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}, &ast.StringLiteral{Val: "round"}}), "unsupported division mode round, expect truncated or floored")
+	}
+}
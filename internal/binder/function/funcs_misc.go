@@ -15,21 +15,32 @@
 package function
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
 	b64 "encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
 	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"hash/crc32"
 	"io"
 	"math"
+	"net/netip"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/lf-edge/ekuiper/contract/v2/api"
@@ -45,8 +56,14 @@ import (
 
 func registerMiscFunc() {
 	gob.Register(&ringqueue{})
+	gob.Register(&timedqueue{})
+	gob.Register(timedItem{})
+	gob.Register(&throttleState{})
+	gob.Register(&accStatus{})
+	gob.Register(&emaState{})
 	builtins["bypass"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			return args[0], true
 		},
@@ -62,48 +79,137 @@ func registerMiscFunc() {
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			key, ok := args[0].(string)
 			if !ok {
-				return fmt.Errorf("invalid input %v: must be property name of string type", args[0]), false
+				return fmt.Errorf("invalid input %v: must be property name of string type", errArg(args[0])), false
 			}
-			return props.SC.Get(key)
+			return redactResult(props.SC.Get(key))
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
 	}
+	// props_all returns all the properties. The values of the properties not in basic.propsDumpAllowList are redacted
+	// because they may be secrets.
+	builtins["props_all"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			all := props.SC.GetAll()
+			result := make(map[string]interface{}, len(all))
+			for k, v := range all {
+				if isPropDumpAllowed(k) {
+					result[k] = v
+				} else {
+					result[k] = redactedValue
+				}
+			}
+			return result, true
+		},
+		val:   ValidateNoArg,
+		check: returnNilIfHasAnyNil,
+	}
+	// env only reads the environment variables in basic.envAllowList to avoid leaking secrets
+	builtins["env"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			var dft interface{}
+			if len(args) > 1 {
+				dft = args[1]
+			}
+			name, ok := args[0].(string)
+			if !ok {
+				return fmt.Errorf("invalid input %v: must be environment variable name of string type", errArg(args[0])), false
+			}
+			if !isEnvAllowed(name) {
+				return dft, true
+			}
+			if v, ok := os.LookupEnv(name); ok {
+				return v, true
+			}
+			return dft, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("Expect 1 or 2 arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["cast"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			value := args[0]
 			newType := args[1]
-			return cast.ToType(value, newType)
+			if len(args) == 3 {
+				if args[2] == "strict" && (newType == "bigint" || newType == "float") {
+					return castStrict(value, newType.(string))
+				}
+				if newType != "datetime" && newType != "bytea" {
+					return fmt.Errorf("the format parameter is only supported for datetime and bytea type"), false
+				}
+				format, ok := args[2].(string)
+				if !ok {
+					return fmt.Errorf("the format must be a string but got %v", errArg(args[2])), false
+				}
+				if newType == "datetime" {
+					return redactResult(cast.ToDatetime(value, format, ruleTimeZone(ctx)))
+				}
+				return redactResult(cast.ToType(value, newType, format))
+			}
+			if newType == "datetime" {
+				return redactResult(cast.ToDatetime(value, "", ruleTimeZone(ctx)))
+			}
+			return redactResult(cast.ToType(value, newType))
 		},
 		val: func(_ api.FunctionContext, args []ast.Expr) error {
-			if err := ValidateLen(2, len(args)); err != nil {
-				return err
+			if len(args) != 2 && len(args) != 3 {
+				return fmt.Errorf("Expect 2 or 3 arguments but found %d.", len(args))
 			}
 			a := args[1]
 			if ast.IsNumericArg(a) || ast.IsTimeArg(a) || ast.IsBooleanArg(a) {
 				return ProduceErrInfo(0, "string")
 			}
 			if av, ok := a.(*ast.StringLiteral); ok {
-				if !(av.Val == "bigint" || av.Val == "float" || av.Val == "string" || av.Val == "boolean" || av.Val == "datetime" || av.Val == "bytea") {
+				if !isCastType(av.Val) {
 					return fmt.Errorf("Expect one of following value for the 2nd parameter: bigint, float, string, boolean, datetime, bytea.")
 				}
 			}
+			if len(args) == 3 {
+				av, ok := a.(*ast.StringLiteral)
+				if sv, isStr := args[2].(*ast.StringLiteral); isStr && sv.Val == "strict" {
+					if !ok || (av.Val != "bigint" && av.Val != "float") {
+						return fmt.Errorf("the strict mode is only allowed when the target type is bigint or float")
+					}
+					return nil
+				}
+				if !ok || (av.Val != "datetime" && av.Val != "bytea") {
+					return fmt.Errorf("the 3rd parameter is only allowed when the target type is datetime or bytea")
+				}
+				if ast.IsNumericArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsBooleanArg(args[2]) {
+					return ProduceErrInfo(2, "string")
+				}
+				if ev, ok := args[2].(*ast.StringLiteral); ok && av.Val == "bytea" && ev.Val != "hex" && ev.Val != "base64" {
+					return fmt.Errorf("expect hex or base64 for the encoding of bytea but got %s", ev.Val)
+				}
+			}
 			return nil
 		},
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["convert_tz"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0, err := cast.InterfaceToTime(args[0], "")
+			arg0, err := interfaceToTime(ctx, args[0])
 			if err != nil {
-				return err, false
+				return redactErr(err), false
 			}
 			arg1 := cast.ToStringAlways(args[1])
-			loc, err := time.LoadLocation(arg1)
+			loc, err := cast.LoadLocation(arg1)
 			if err != nil {
-				return err, false
+				return redactErr(err), false
 			}
 			return arg0.In(loc), true
 		},
@@ -123,40 +229,109 @@ func registerMiscFunc() {
 	}
 	builtins["to_seconds"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			t, err := cast.InterfaceToTime(args[0], "")
+			loc := ruleTimeZone(ctx)
+			if len(args) > 1 {
+				var err error
+				loc, err = cast.LoadLocation(cast.ToStringAlways(args[1]))
+				if err != nil {
+					return redactErr(err), false
+				}
+			}
+			t, err := cast.InterfaceToTimeInLocation(args[0], "", loc)
 			if err != nil {
-				return err, false
+				return redactErr(err), false
 			}
 			return t.Unix(), true
 		},
-		val:   ValidateOneArg,
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			l := len(args)
+			if l != 1 && l != 2 {
+				return fmt.Errorf("Expect one or two arguments but found %d.", l)
+			}
+			if l == 2 && (ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1])) {
+				return ProduceErrInfo(1, "string")
+			}
+			return nil
+		},
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["to_json"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			rr, err := json.Marshal(args[0])
+			v := args[0]
+			numberAsString := false
+			precisionArg := args[1:]
+			if len(args) > 1 {
+				// the last bool argument is the flag to encode numbers as strings
+				if b, ok := args[len(args)-1].(bool); ok {
+					numberAsString = b
+					precisionArg = args[1 : len(args)-1]
+				} else if len(args) > 2 {
+					return fmt.Errorf("the numberAsString must be a bool but got %v", errArg(args[2])), false
+				}
+			}
+			if len(precisionArg) > 0 {
+				precision, err := cast.ToInt(precisionArg[0], cast.STRICT)
+				if err != nil {
+					return fmt.Errorf("the precision must be an int but got %v", errArg(precisionArg[0])), false
+				}
+				if precision < 0 {
+					return fmt.Errorf("the precision must not be negative but got %d", precision), false
+				}
+				v = roundFloats(v, precision)
+			}
+			v = normalizeJsonNumbers(v, numberAsString)
+			rr, err := json.Marshal(v)
 			if err != nil {
-				return fmt.Errorf("fail to convert %v to json", args[0]), false
+				return fmt.Errorf("fail to convert %v to json", errArg(args[0])), false
 			}
 			return string(rr), true
 		},
-		val:   ValidateOneArg,
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			l := len(args)
+			if l < 1 || l > 3 {
+				return fmt.Errorf("the arguments for to_json should be 1 to 3")
+			}
+			if l == 3 {
+				if ast.IsNumericArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsStringArg(args[2]) {
+					return ProduceErrInfo(2, "bool")
+				}
+				if ast.IsBooleanArg(args[1]) {
+					return ProduceErrInfo(1, "int")
+				}
+			}
+			if l >= 2 {
+				if ast.IsFloatArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsStringArg(args[1]) {
+					return ProduceErrInfo(1, "int")
+				}
+				if p, ok := args[1].(*ast.IntegerLiteral); ok && p.Val < 0 {
+					return fmt.Errorf("the precision must not be negative")
+				}
+			}
+			return nil
+		},
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["parse_json"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil || args[0] == "null" {
 				return nil, true
 			}
 			text, err := cast.ToString(args[0], cast.CONVERT_SAMEKIND)
 			if err != nil {
-				return fmt.Errorf("fail to convert %v to string", args[0]), false
+				return fmt.Errorf("fail to convert %v to string", errArg(args[0])), false
+			}
+			b := cast.StringToBytes(text)
+			if err := checkJsonLimits(b); err != nil {
+				return fmt.Errorf("fail to parse json: %v", err), false
 			}
 			var data interface{}
-			err = json.Unmarshal(cast.StringToBytes(text), &data)
+			err = json.Unmarshal(b, &data)
 			if err != nil {
 				return fmt.Errorf("fail to parse json: %v", err), false
 			}
@@ -164,8 +339,47 @@ func registerMiscFunc() {
 		},
 		val: ValidateOneStrArg,
 	}
+	builtins["json_canonical"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			v := args[0]
+			if text, ok := v.(string); ok {
+				b := cast.StringToBytes(text)
+				if err := checkJsonLimits(b); err != nil {
+					return fmt.Errorf("fail to parse json: %v", err), false
+				}
+				var err error
+				v, err = unmarshalUseNumber(b)
+				if err != nil {
+					return fmt.Errorf("fail to parse json: %v", err), false
+				}
+			}
+			// json.Marshal sorts the keys of map[string]interface{} so that the result is stable
+			rr, err := json.Marshal(canonicalValue(v))
+			if err != nil {
+				return fmt.Errorf("fail to convert %v to json", errArg(args[0])), false
+			}
+			return string(rr), true
+		},
+		val:   ValidateOneArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["json_valid"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			text, ok := args[0].(string)
+			if !ok {
+				return false, true
+			}
+			return json.Valid(cast.StringToBytes(text)), true
+		},
+		val: ValidateOneArg,
+	}
 	builtins["chr"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			switch v := args[0].(type) {
 			case int:
@@ -197,6 +411,7 @@ func registerMiscFunc() {
 	}
 	builtins["encode"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, ok := args[1].(string); ok {
 				if strings.EqualFold(v, "base64") {
@@ -235,6 +450,7 @@ func registerMiscFunc() {
 	}
 	builtins["decode"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if v, ok := args[1].(string); ok {
 				if strings.EqualFold(v, "base64") {
@@ -275,13 +491,96 @@ func registerMiscFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["auto_decode"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			v, ok := args[0].(string)
+			if !ok {
+				return fmt.Errorf("Only string type can be decoded."), false
+			}
+			r, err := autoDecode(v)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(1, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["ip_in_cidr"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			addr, err := netip.ParseAddr(cast.ToStringAlways(args[0]))
+			if err != nil {
+				return fmt.Errorf("invalid ip address %v: %v", errArg(args[0]), errCause(err)), false
+			}
+			prefix, err := netip.ParsePrefix(cast.ToStringAlways(args[1]))
+			if err != nil {
+				return fmt.Errorf("invalid cidr %v: %v", errArg(args[1]), errCause(err)), false
+			}
+			// IPv4-mapped IPv6 address like ::ffff:192.168.0.1 can match IPv4 cidr
+			if prefix.Addr().Is4() {
+				addr = addr.Unmap()
+			}
+			return prefix.Contains(addr), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			for i := 0; i < 2; i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "string")
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["ip_to_int"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			addr, err := netip.ParseAddr(cast.ToStringAlways(args[0]))
+			if err != nil {
+				return fmt.Errorf("invalid ip address %v: %v", errArg(args[0]), errCause(err)), false
+			}
+			addr = addr.Unmap()
+			if !addr.Is4() {
+				return fmt.Errorf("only IPv4 address can be converted to int but got %s", errArg(addr)), false
+			}
+			b := addr.As4()
+			return int64(binary.BigEndian.Uint32(b[:])), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(1, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["trunc"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			var v0 float64
 			v0, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
 			if err != nil {
-				return err, false
+				return redactErr(err), false
 			}
 			switch v2 := args[1].(type) {
 			case int:
@@ -310,79 +609,198 @@ func registerMiscFunc() {
 	}
 	builtins["md5"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0 := cast.ToStringAlways(args[0])
-			h := md5.New()
-			_, err := io.WriteString(h, arg0)
-			if err != nil {
-				return err, false
-			}
-			return fmt.Sprintf("%x", h.Sum(nil)), true
+			return hashHex(md5.New(), args[0])
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["sha1"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0 := cast.ToStringAlways(args[0])
-			h := sha1.New()
-			_, err := io.WriteString(h, arg0)
-			if err != nil {
-				return err, false
-			}
-			return fmt.Sprintf("%x", h.Sum(nil)), true
+			return hashHex(sha1.New(), args[0])
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["sha256"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			return hashHex(sha256.New(), args[0])
+		},
+		val:   ValidateOneStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["sha384"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0 := cast.ToStringAlways(args[0])
-			h := sha256.New()
-			_, err := io.WriteString(h, arg0)
+			return hashHex(sha512.New384(), args[0])
+		},
+		val:   ValidateOneStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["sha512"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			return hashHex(sha512.New(), args[0])
+		},
+		val:   ValidateOneStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["crc32"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			return fmt.Sprintf("%x", crc32.ChecksumIEEE(hashInput(args[0]))), true
+		},
+		val:   ValidateOneStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["crc32c"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			return fmt.Sprintf("%x", crc32.Checksum(hashInput(args[0]), castagnoliTable)), true
+		},
+		val:   ValidateOneStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["crc16"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			poly := "ccitt"
+			if len(args) > 1 {
+				poly = cast.ToStringAlways(args[1])
+			}
+			r, err := crc16(hashInput(args[0]), poly)
 			if err != nil {
 				return err, false
 			}
-			return fmt.Sprintf("%x", h.Sum(nil)), true
+			return fmt.Sprintf("%x", r), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateAtLeast(1, len(args)); err != nil {
+				return err
+			}
+			if len(args) > 2 {
+				return fmt.Errorf("Expect at most 2 arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			if len(args) == 2 {
+				if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+					return ProduceErrInfo(1, "string")
+				}
+				if p, ok := args[1].(*ast.StringLiteral); ok {
+					if _, err := crc16(nil, p.Val); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
 		},
-		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
 	}
-	builtins["sha384"] = builtinFunc{
+	builtins["content_hash"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0 := cast.ToStringAlways(args[0])
-			h := sha512.New384()
-			_, err := io.WriteString(h, arg0)
+			h, err := newHash(cast.ToStringAlways(args[1]))
 			if err != nil {
 				return err, false
 			}
-			return fmt.Sprintf("%x", h.Sum(nil)), true
+			b, err := json.Marshal(canonicalValue(args[0]))
+			if err != nil {
+				return fmt.Errorf("fail to canonicalize %v: %v", errArg(args[0]), errCause(err)), false
+			}
+			return hashHex(h, b)
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+				return ProduceErrInfo(1, "string")
+			}
+			if a, ok := args[1].(*ast.StringLiteral); ok {
+				if _, err := newHash(a.Val); err != nil {
+					return err
+				}
+			}
+			return nil
 		},
-		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
 	}
-	builtins["sha512"] = builtinFunc{
+	builtins["row_checksum"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			h, err := newChecksumHash(cast.ToStringAlways(args[0]))
+			if err != nil {
+				return err, false
+			}
+			// Hash all values as one json array so that the boundaries and types of the values are kept
+			values := make([]interface{}, len(args)-1)
+			for i, arg := range args[1:] {
+				values[i] = canonicalValue(arg)
+			}
+			b, err := json.Marshal(values)
+			if err != nil {
+				return fmt.Errorf("fail to canonicalize the values: %v", errCause(err)), false
+			}
+			return hashHex(h, b)
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateAtLeast(2, len(args)); err != nil {
+				return err
+			}
+			a, ok := args[0].(*ast.StringLiteral)
+			if !ok {
+				return ProduceErrInfo(0, "string literal")
+			}
+			_, err := newChecksumHash(a.Val)
+			return err
+		},
+		check: func(args []interface{}) (interface{}, bool) {
+			// the nil values are part of the checksum
+			return nil, args[0] == nil
+		},
+	}
+	builtins["luhn_valid"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0 := cast.ToStringAlways(args[0])
-			h := sha512.New()
-			_, err := io.WriteString(h, arg0)
+			digits, err := luhnDigits(args[0])
 			if err != nil {
 				return err, false
 			}
-			return fmt.Sprintf("%x", h.Sum(nil)), true
+			if len(digits) < 2 {
+				return fmt.Errorf("the number should have at least 2 digits but got %d", len(digits)), false
+			}
+			return luhnSum(digits, false)%10 == 0, true
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
 	}
-	builtins["crc32"] = builtinFunc{
+	builtins["luhn_checkdigit"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			arg0 := cast.ToStringAlways(args[0])
-			return fmt.Sprintf("%x", crc32.ChecksumIEEE([]byte(arg0))), true
+			digits, err := luhnDigits(args[0])
+			if err != nil {
+				return err, false
+			}
+			if len(digits) == 0 {
+				return fmt.Errorf("the number should have at least 1 digit"), false
+			}
+			return (10 - luhnSum(digits, true)%10) % 10, true
 		},
 		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
@@ -395,8 +813,13 @@ func registerMiscFunc() {
 		conf.Log.Infof("initializing decompress function")
 		return &decompressFunc{}
 	}
+	builtinStatfulFuncs["throttle"] = func() api.Function {
+		conf.Log.Infof("initializing throttle function")
+		return &throttleFunc{}
+	}
 	builtins["isnull"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return true, true
@@ -412,8 +835,17 @@ func registerMiscFunc() {
 		},
 		val: ValidateOneArg,
 	}
+	builtins["is_empty"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			return isEmptyValue(args[0]), true
+		},
+		val: ValidateOneArg,
+	}
 	builtins["coalesce"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			for _, arg := range args {
 				if arg != nil {
@@ -429,17 +861,118 @@ func registerMiscFunc() {
 			return nil
 		},
 	}
-	builtins["newuuid"] = builtinFunc{
+	// coalesce_empty is like coalesce but also skips the empty values same as is_empty
+	builtins["coalesce_empty"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			if newUUID, err := uuid.NewUUID(); err != nil {
-				return err, false
-			} else {
-				return newUUID.String(), true
+			for _, arg := range args {
+				if !isEmptyValue(arg) {
+					return arg, true
+				}
 			}
+			return nil, true
 		},
-		val: ValidateNoArg,
-	}
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			return ValidateAtLeast(1, len(args))
+		},
+	}
+	// first_of_type returns the first value whose runtime type is the given cast type
+	builtins["first_of_type"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			typ, ok := args[0].(string)
+			if !ok || !isCastType(typ) {
+				return fmt.Errorf("the type should be one of bigint, float, string, boolean, datetime, bytea but got %v", errArg(args[0])), false
+			}
+			for _, arg := range args[1:] {
+				if isOfCastType(arg, typ) {
+					return arg, true
+				}
+			}
+			return nil, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateAtLeast(2, len(args)); err != nil {
+				return err
+			}
+			av, ok := args[0].(*ast.StringLiteral)
+			if !ok {
+				return fmt.Errorf("Expect string literal type for parameter 1")
+			}
+			if !isCastType(av.Val) {
+				return fmt.Errorf("Expect one of following value for the 1st parameter: bigint, float, string, boolean, datetime, bytea.")
+			}
+			return nil
+		},
+	}
+	// assert fails the record with the message if the condition does not hold. A null condition does not hold.
+	builtins["assert"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if args[0] != nil {
+				cond, ok := args[0].(bool)
+				if !ok {
+					return fmt.Errorf("the condition should be a bool but got %v", errArg(args[0])), false
+				}
+				if cond {
+					return true, true
+				}
+			}
+			return fmt.Errorf("assertion failed: %s", cast.ToStringAlways(args[1])), false
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsStringArg(args[0]) {
+				return ProduceErrInfo(0, "bool")
+			}
+			if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+				return ProduceErrInfo(1, "string")
+			}
+			return nil
+		},
+	}
+	builtins["newuuid"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			newUUID, err := uuid.NewUUID()
+			if err != nil {
+				return err, false
+			}
+			format := "canonical"
+			if len(args) > 0 {
+				f, ok := args[0].(string)
+				if !ok {
+					return fmt.Errorf("the uuid format must be a string but got %v", errArg(args[0])), false
+				}
+				format = f
+			}
+			r, err := formatUUID(newUUID, format)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) > 1 {
+				return fmt.Errorf("Expect at most 1 argument but found %d.", len(args))
+			}
+			if len(args) == 1 {
+				if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+					return ProduceErrInfo(0, "string")
+				}
+				if f, ok := args[0].(*ast.StringLiteral); ok {
+					if _, err := formatUUID(uuid.Nil, f.Val); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	}
 	builtins["tstamp"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
@@ -474,6 +1007,7 @@ func registerMiscFunc() {
 	}
 	builtins["rule_id"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			return ctx.GetRuleId(), true
 		},
@@ -481,11 +1015,23 @@ func registerMiscFunc() {
 	}
 	builtins["rule_start"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			return ctx.Value(context.RuleStartKey), true
 		},
 		val: ValidateNoArg,
 	}
+	builtins["rule_runtime"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			start, err := cast.ToInt64(ctx.Value(context.RuleStartKey), cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return int64(0), true
+			}
+			return timex.GetNowInMilli() - start, true
+		},
+		val: ValidateNoArg,
+	}
 	builtins["meta"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
@@ -512,8 +1058,27 @@ func registerMiscFunc() {
 			return ProduceErrInfo(0, "meta reference")
 		},
 	}
+	builtins["metakeys"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec:  nil, // directly return in the valuer
+		val:   ValidateNoArg,
+	}
+	builtins["set_meta"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec:  nil, // directly set in the valuer
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			return nil
+		},
+	}
 	builtins["cardinality"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			val := reflect.ValueOf(args[0])
 			if val.Kind() == reflect.Slice {
@@ -526,10 +1091,11 @@ func registerMiscFunc() {
 	}
 	builtins["json_path_query"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			result, err := jsonCall(ctx, args)
 			if err != nil {
-				return err, false
+				return redactErr(err), false
 			}
 			return result, true
 		},
@@ -537,21 +1103,23 @@ func registerMiscFunc() {
 	}
 	builtins["json_path_query_first"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			result, err := jsonCall(ctx, args)
 			if err != nil {
-				return err, false
+				return redactErr(err), false
 			}
 			if arr, ok := result.([]interface{}); ok {
 				return arr[0], true
 			} else {
-				return fmt.Errorf("query result (%v) is not an array", result), false
+				return fmt.Errorf("query result (%v) is not an array", errArg(result)), false
 			}
 		},
 		val: ValidateJsonFunc,
 	}
 	builtins["json_path_exists"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			result, err := jsonCall(ctx, args)
 			if err != nil {
@@ -586,6 +1154,11 @@ func registerMiscFunc() {
 		exec:  nil, // directly return in the valuer
 		val:   ValidateNoArg,
 	}
+	builtins["window_index"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec:  nil, // directly return in the valuer
+		val:   ValidateNoArg,
+	}
 	builtins["event_time"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
 		exec:  nil, // directly return in the valuer
@@ -597,7 +1170,7 @@ func registerMiscFunc() {
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			d, err := cast.ToInt(args[0], cast.CONVERT_SAMEKIND)
 			if err != nil {
-				return err, false
+				return redactErr(err), false
 			}
 			time.Sleep(time.Duration(d) * time.Millisecond)
 			return args[1], true
@@ -621,15 +1194,20 @@ func registerMiscFunc() {
 			}
 			key, ok := args[0].(string)
 			if !ok {
-				return fmt.Errorf("key %v is not a string", args[0]), false
+				return fmt.Errorf("key %v is not a string", errArg(args[0])), false
 			}
 
-			value, err := keyedstate.GetKeyedState(key)
+			value, err := keyedstate.GetKeyedState(keyedStateKey(ctx, key))
 			if err != nil {
 				return args[2], true
 			}
 
-			return cast.ToType(value, args[1])
+			r, ok := cast.ToType(value, args[1])
+			if !ok {
+				ctx.GetLogger().Warnf("get_keyed_state cannot convert value of key %s: %v, return the default value", key, r)
+				return args[2], true
+			}
+			return r, true
 		},
 		val: func(_ api.FunctionContext, args []ast.Expr) error {
 			if err := ValidateLen(3, len(args)); err != nil {
@@ -647,61 +1225,928 @@ func registerMiscFunc() {
 			return nil
 		},
 	}
+	builtins["get_keyed_states"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			if args[0] == nil {
+				return nil, true
+			}
+			arr, ok := args[0].([]interface{})
+			if !ok {
+				return fmt.Errorf("keys %v is not an array", errArg(args[0])), false
+			}
+			keys := make([]string, len(arr))
+			for i, k := range arr {
+				key, ok := k.(string)
+				if !ok {
+					return fmt.Errorf("key %v is not a string", errArg(k)), false
+				}
+				keys[i] = key
+			}
+			storeKeys := make([]string, len(keys))
+			for i, key := range keys {
+				storeKeys[i] = keyedStateKey(ctx, key)
+			}
+			values, err := keyedstate.GetKeyedStates(storeKeys)
+			if err != nil {
+				ctx.GetLogger().Warnf("get_keyed_states failed: %v, return the default values", err)
+				values = make([]interface{}, len(keys))
+			}
+			result := make([]interface{}, len(keys))
+			for i, value := range values {
+				if value == nil {
+					result[i] = args[2]
+					continue
+				}
+				r, ok := cast.ToType(value, args[1])
+				if !ok {
+					ctx.GetLogger().Warnf("get_keyed_states cannot convert value of key %s: %v, return the default value", keys[i], r)
+					result[i] = args[2]
+					continue
+				}
+				result[i] = r
+			}
+			return result, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(3, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) || ast.IsStringArg(args[0]) {
+				return ProduceErrInfo(0, "array")
+			}
+			return builtins["get_keyed_state"].val(ctx, args)
+		},
+	}
+	builtins["set_keyed_state"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			key, ok := args[0].(string)
+			if !ok {
+				return fmt.Errorf("key %v is not a string", errArg(args[0])), false
+			}
+			var ttl int
+			if len(args) > 2 {
+				var err error
+				ttl, err = cast.ToInt(args[2], cast.CONVERT_SAMEKIND)
+				if err != nil {
+					return redactErr(err), false
+				}
+				if ttl < 0 {
+					return fmt.Errorf("ttl must not be negative but got %d", ttl), false
+				}
+			}
+			if err := keyedstate.SetKeyedStateWithTTL(keyedStateKey(ctx, key), args[1], time.Duration(ttl)*time.Millisecond); err != nil {
+				return err, false
+			}
+			return args[1], true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateAtLeast(2, len(args)); err != nil {
+				return err
+			}
+			if len(args) > 3 {
+				return fmt.Errorf("Expect at most 3 arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			if len(args) > 2 && (ast.IsStringArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsBooleanArg(args[2]) || ast.IsFloatArg(args[2])) {
+				return ProduceErrInfo(2, "int")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["set_keyed_states"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			keys, ok := args[0].([]interface{})
+			if !ok {
+				return fmt.Errorf("keys %v is not an array", errArg(args[0])), false
+			}
+			values, ok := args[1].([]interface{})
+			if !ok {
+				return fmt.Errorf("values %v is not an array", errArg(args[1])), false
+			}
+			if len(keys) != len(values) {
+				return fmt.Errorf("the length of keys %d and values %d must be the same", len(keys), len(values)), false
+			}
+			sets := make(map[string]interface{}, len(keys))
+			seen := make(map[string]struct{}, len(keys))
+			var deletes []string
+			for i, k := range keys {
+				key, ok := k.(string)
+				if !ok {
+					return fmt.Errorf("key %v is not a string", errArg(k)), false
+				}
+				if _, ok := seen[key]; ok {
+					return fmt.Errorf("duplicate key %v", errArg(key)), false
+				}
+				seen[key] = struct{}{}
+				storeKey := keyedStateKey(ctx, key)
+				if values[i] == nil {
+					deletes = append(deletes, storeKey)
+				} else {
+					sets[storeKey] = values[i]
+				}
+			}
+			if err := keyedstate.CommitKeyedStates(sets, deletes); err != nil {
+				return err, false
+			}
+			return values, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			for i, a := range args {
+				if ast.IsNumericArg(a) || ast.IsTimeArg(a) || ast.IsBooleanArg(a) || ast.IsStringArg(a) {
+					return ProduceErrInfo(i, "array")
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["hex2dec"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			hex, ok := args[0].(string)
 			if !ok {
-				return fmt.Errorf("invalid input type: %v please input hex string", args[0]), false
+				return fmt.Errorf("invalid input type: %v please input hex string", errArg(args[0])), false
+			}
+			unsigned := false
+			if len(args) > 1 {
+				unsigned, ok = args[1].(bool)
+				if !ok {
+					return fmt.Errorf("the unsigned arg should be a bool but got %v", errArg(args[1])), false
+				}
+			}
+			digits, neg := strings.CutPrefix(hex, "-")
+			if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+				digits = digits[2:]
+			}
+			var (
+				dec     interface{}
+				err     error
+				typName string
+			)
+			if unsigned {
+				typName = "uint64"
+				if neg {
+					return fmt.Errorf("invalid hexadecimal value: %s, negative value is not allowed in unsigned mode", errArg(hex)), false
+				}
+				dec, err = strconv.ParseUint(digits, 16, 64)
+			} else {
+				typName = "int64"
+				if neg {
+					digits = "-" + digits
+				}
+				dec, err = strconv.ParseInt(digits, 16, 64)
 			}
-			hex = strings.TrimPrefix(hex, "0x")
-			dec, err := strconv.ParseInt(hex, 16, 64)
 			if err != nil {
-				return fmt.Errorf("invalid hexadecimal value: %v", hex), false
+				if errors.Is(err, strconv.ErrRange) {
+					return fmt.Errorf("hexadecimal value %s is out of %s range", errArg(hex), typName), false
+				}
+				return fmt.Errorf("invalid hexadecimal value: %s", errArg(hex)), false
 			}
 			return dec, true
 		},
-		val:   ValidateOneStrArg,
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("Expect 1 or 2 arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			if len(args) == 2 && (ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsStringArg(args[1])) {
+				return ProduceErrInfo(1, "bool")
+			}
+			return nil
+		},
 		check: returnNilIfHasAnyNil,
 	}
 	builtins["dec2hex"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			width := 0
+			if len(args) > 1 {
+				w, err := cast.ToInt(args[1], cast.STRICT)
+				if err != nil || w < 0 || w > maxHexWidth {
+					return fmt.Errorf("the width should be an integer between 0 and %d but got %v", maxHexWidth, errArg(args[1])), false
+				}
+				width = w
+			}
+			var (
+				digits string
+				neg    bool
+			)
+			if u, ok := args[0].(uint64); ok {
+				digits = strconv.FormatUint(u, 16)
+			} else {
+				dec, err := cast.ToInt64(args[0], cast.STRICT)
+				if err != nil {
+					return redactErr(err), false
+				}
+				// Format the absolute value by uint64 to handle math.MinInt64
+				neg = dec < 0
+				u := uint64(dec)
+				if neg {
+					u = -u
+				}
+				digits = strconv.FormatUint(u, 16)
+			}
+			if len(digits) < width {
+				digits = strings.Repeat("0", width-len(digits)) + digits
+			}
+			if neg {
+				return "-0x" + digits, true
+			}
+			return "0x" + digits, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("Expect 1 or 2 arguments but found %d.", len(args))
+			}
+			if ast.IsStringArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) || ast.IsFloatArg(args[0]) {
+				return ProduceErrInfo(0, "int")
+			}
+			if len(args) == 2 {
+				if ast.IsStringArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) || ast.IsFloatArg(args[1]) {
+					return ProduceErrInfo(1, "int")
+				}
+				if w, ok := args[1].(*ast.IntegerLiteral); ok && (w.Val < 0 || w.Val > maxHexWidth) {
+					return fmt.Errorf("the width should be an integer between 0 and %d but got %d", maxHexWidth, w.Val)
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["to_number"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
-			dec, err := cast.ToInt(args[0], cast.STRICT)
+			s, ok := args[0].(string)
+			if !ok {
+				return fmt.Errorf("the first argument of to_number must be a string but got %v", errArg(args[0])), false
+			}
+			r, err := parseLocalNumber(s, cast.ToStringAlways(args[1]))
 			if err != nil {
 				return err, false
 			}
-			hex := "0x" + strconv.FormatInt(int64(dec), 16)
-			return hex, true
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+				return ProduceErrInfo(1, "string")
+			}
+			if f, ok := args[1].(*ast.StringLiteral); ok {
+				if _, err := parseLocalNumber("0", f.Val); err != nil {
+					return err
+				}
+			}
+			return nil
 		},
-		val:   ValidateOneStrArg,
 		check: returnNilIfHasAnyNil,
 	}
-}
-
-func round(num float64) int {
-	return int(num + math.Copysign(0.5, num))
-}
-
-func toFixed(num float64, precision int) float64 {
-	output := math.Pow(10, float64(precision))
-	return float64(round(num*output)) / output
-}
-
-func jsonCall(ctx api.StreamContext, args []interface{}) (interface{}, error) {
-	jp, ok := args[1].(string)
-	if !ok {
-		return nil, fmt.Errorf("invalid jsonPath, must be a string but got %v", args[1])
+	builtins["parse_csv"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			line, err := cast.ToString(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("fail to convert %v to string", errArg(args[0])), false
+			}
+			opt := &csvOptions{delimiter: ',', quote: '"'}
+			if len(args) > 1 {
+				opt, err = parseCsvOptions(args[1])
+				if err != nil {
+					return err, false
+				}
+			}
+			r, err := parseCsvLine(line, opt)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("Expect one or two arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			if len(args) == 2 {
+				if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+					return ProduceErrInfo(1, "string or object")
+				}
+				if d, ok := args[1].(*ast.StringLiteral); ok {
+					if _, err := parseCsvOptions(d.Val); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
 	}
-	return ctx.ParseJsonPath(jp, args[0])
-}
-
-// page Rotate storage for in memory cache
-// Not thread safe!
-type ringqueue struct {
-	Data []any
-	H    int
-	T    int
+	builtins["parse_kv"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			seps := make([]string, 3)
+			for i := 0; i < 3; i++ {
+				s, err := cast.ToString(args[i], cast.CONVERT_SAMEKIND)
+				if err != nil {
+					return fmt.Errorf("fail to convert %v to string", errArg(args[i])), false
+				}
+				seps[i] = s
+			}
+			var coerce, strict bool
+			if len(args) > 3 {
+				b, err := cast.ToBool(args[3], cast.STRICT)
+				if err != nil {
+					return fmt.Errorf("the coerce flag must be a bool but got %v", errArg(args[3])), false
+				}
+				coerce = b
+			}
+			if len(args) > 4 {
+				b, err := cast.ToBool(args[4], cast.STRICT)
+				if err != nil {
+					return fmt.Errorf("the strict flag must be a bool but got %v", errArg(args[4])), false
+				}
+				strict = b
+			}
+			r, err := parseKv(seps[0], seps[1], seps[2], coerce, strict)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) < 3 || len(args) > 5 {
+				return fmt.Errorf("the arguments for parse_kv should be 3 to 5")
+			}
+			for i := 0; i < 3; i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "string")
+				}
+			}
+			for i := 1; i < 3; i++ {
+				if s, ok := args[i].(*ast.StringLiteral); ok && s.Val == "" {
+					return fmt.Errorf("the separators of parse_kv must not be empty")
+				}
+			}
+			for i := 3; i < len(args); i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsStringArg(args[i]) {
+					return ProduceErrInfo(i, "bool")
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["geo_distance"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			var coords [4]float64
+			for i := 0; i < 4; i++ {
+				v, err := cast.ToFloat64(args[i], cast.CONVERT_SAMEKIND)
+				if err != nil {
+					return fmt.Errorf("the %d argument of geo_distance must be a number but got %v", i+1, errArg(args[i])), false
+				}
+				coords[i] = v
+			}
+			for i := 0; i < 4; i += 2 {
+				if coords[i] < -90 || coords[i] > 90 {
+					return fmt.Errorf("latitude must be in range [-90, 90] but got %v", errArg(coords[i])), false
+				}
+				if coords[i+1] < -180 || coords[i+1] > 180 {
+					return fmt.Errorf("longitude must be in range [-180, 180] but got %v", errArg(coords[i+1])), false
+				}
+			}
+			d := haversine(coords[0], coords[1], coords[2], coords[3])
+			if len(args) == 5 {
+				unit, ok := args[4].(string)
+				if !ok {
+					return fmt.Errorf("the unit of geo_distance must be a string but got %v", errArg(args[4])), false
+				}
+				switch strings.ToLower(unit) {
+				case "m":
+				case "km":
+					d = d / 1000
+				case "mi":
+					d = d / 1609.344
+				default:
+					return fmt.Errorf("unsupported unit %s for geo_distance, expect one of m, km, mi", errArg(unit)), false
+				}
+			}
+			return d, true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 4 && len(args) != 5 {
+				return fmt.Errorf("the arguments for geo_distance should be 4 or 5")
+			}
+			for i := 0; i < 4; i++ {
+				if ast.IsStringArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "number - float or int")
+				}
+			}
+			if len(args) == 5 {
+				if ast.IsNumericArg(args[4]) || ast.IsTimeArg(args[4]) || ast.IsBooleanArg(args[4]) {
+					return ProduceErrInfo(4, "string")
+				}
+				if u, ok := args[4].(*ast.StringLiteral); ok {
+					switch strings.ToLower(u.Val) {
+					case "m", "km", "mi":
+					default:
+						return fmt.Errorf("expect one of following value for the 5th parameter: m, km, mi")
+					}
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["point_in_polygon"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			lat, err := cast.ToFloat64(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("the latitude of point_in_polygon must be a number but got %v", errArg(args[0])), false
+			}
+			lon, err := cast.ToFloat64(args[1], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return fmt.Errorf("the longitude of point_in_polygon must be a number but got %v", errArg(args[1])), false
+			}
+			ring, err := toPolygonRing(args[2])
+			if err != nil {
+				return err, false
+			}
+			if len(ring) < 3 {
+				return false, true
+			}
+			return pointInRing(lon, lat, ring), true
+		},
+		val: func(ctx api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(3, len(args)); err != nil {
+				return err
+			}
+			for i := 0; i < 2; i++ {
+				if ast.IsStringArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "number - float or int")
+				}
+			}
+			if ast.IsNumericArg(args[2]) || ast.IsStringArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsBooleanArg(args[2]) {
+				return ProduceErrInfo(2, "array")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+}
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// haversine returns the great-circle distance in meters between two coordinates in degrees
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// toPolygonRing converts the polygon argument to a ring of [lon, lat] points. The polygon can be
+// an array of two-element arrays or a GeoJSON Polygon object whose exterior ring is used.
+func toPolygonRing(v interface{}) ([][2]float64, error) {
+	if m, ok := v.(map[string]interface{}); ok {
+		coords, ok := m["coordinates"].([]interface{})
+		if !ok || len(coords) == 0 {
+			return nil, fmt.Errorf("the polygon of point_in_polygon must have coordinates but got %v", errArg(v))
+		}
+		v = coords[0]
+	}
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the polygon of point_in_polygon must be an array but got %v", errArg(v))
+	}
+	ring := make([][2]float64, 0, len(arr))
+	for _, p := range arr {
+		pair, ok := p.([]interface{})
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("the polygon point must be an array of [lon, lat] but got %v", errArg(p))
+		}
+		lon, err := cast.ToFloat64(pair[0], cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("the polygon point must be an array of [lon, lat] but got %v", errArg(p))
+		}
+		lat, err := cast.ToFloat64(pair[1], cast.CONVERT_SAMEKIND)
+		if err != nil {
+			return nil, fmt.Errorf("the polygon point must be an array of [lon, lat] but got %v", errArg(p))
+		}
+		ring = append(ring, [2]float64{lon, lat})
+	}
+	return ring, nil
+}
+
+// pointInRing tests whether the point is inside the ring by ray casting
+func pointInRing(x, y float64, ring [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// formatUUID returns the representation of the uuid in the format of canonical, simple, urn or braced
+func formatUUID(u uuid.UUID, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "canonical":
+		return u.String(), nil
+	case "simple":
+		return strings.ReplaceAll(u.String(), "-", ""), nil
+	case "urn":
+		return u.URN(), nil
+	case "braced":
+		return "{" + u.String() + "}", nil
+	default:
+		return "", fmt.Errorf("unsupported uuid format %s, expect one of canonical, simple, urn, braced", errArg(format))
+	}
+}
+
+func round(num float64) int {
+	return int(num + math.Copysign(0.5, num))
+}
+
+func toFixed(num float64, precision int) float64 {
+	output := math.Pow(10, float64(precision))
+	return float64(round(num*output)) / output
+}
+
+// roundFloats rounds all float values inside v to the precision recursively so that
+// the json output is stable. The float is formatted in decimal then parsed back to get the
+// nearest representation of the rounded value.
+func roundFloats(v interface{}, precision int) interface{} {
+	switch vt := v.(type) {
+	case float64:
+		r, _ := strconv.ParseFloat(strconv.FormatFloat(vt, 'f', precision, 64), 64)
+		return r
+	case float32:
+		r, _ := strconv.ParseFloat(strconv.FormatFloat(float64(vt), 'f', precision, 32), 64)
+		return r
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(vt))
+		for k, e := range vt {
+			r[k] = roundFloats(e, precision)
+		}
+		return r
+	case []map[string]interface{}:
+		r := make([]interface{}, len(vt))
+		for i, e := range vt {
+			r[i] = roundFloats(e, precision)
+		}
+		return r
+	case []interface{}:
+		r := make([]interface{}, len(vt))
+		for i, e := range vt {
+			r[i] = roundFloats(e, precision)
+		}
+		return r
+	case []float64:
+		r := make([]float64, len(vt))
+		for i, e := range vt {
+			r[i] = roundFloats(e, precision).(float64)
+		}
+		return r
+	default:
+		return v
+	}
+}
+
+// normalizeJsonNumbers prepares the numbers inside the value for json marshaling. The whole float numbers are encoded
+// in the integer form instead of the exponent form. If asString is true, all numbers are encoded as strings.
+func normalizeJsonNumbers(v interface{}, asString bool) interface{} {
+	switch vt := v.(type) {
+	case float64:
+		if asString {
+			return strconv.FormatFloat(vt, 'f', -1, 64)
+		}
+		if vt == math.Trunc(vt) && !math.IsInf(vt, 0) {
+			return json.Number(strconv.FormatFloat(vt, 'f', -1, 64))
+		}
+		return vt
+	case float32:
+		if asString {
+			return strconv.FormatFloat(float64(vt), 'f', -1, 32)
+		}
+		if f := float64(vt); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return json.Number(strconv.FormatFloat(f, 'f', -1, 32))
+		}
+		return vt
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		if asString {
+			return fmt.Sprintf("%d", vt)
+		}
+		return vt
+	case json.Number:
+		if asString {
+			return string(vt)
+		}
+		return vt
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(vt))
+		for k, e := range vt {
+			r[k] = normalizeJsonNumbers(e, asString)
+		}
+		return r
+	case []map[string]interface{}:
+		r := make([]interface{}, len(vt))
+		for i, e := range vt {
+			r[i] = normalizeJsonNumbers(e, asString)
+		}
+		return r
+	case []interface{}:
+		r := make([]interface{}, len(vt))
+		for i, e := range vt {
+			r[i] = normalizeJsonNumbers(e, asString)
+		}
+		return r
+	case []float64:
+		r := make([]interface{}, len(vt))
+		for i, e := range vt {
+			r[i] = normalizeJsonNumbers(e, asString)
+		}
+		return r
+	default:
+		return v
+	}
+}
+
+func jsonCall(_ api.StreamContext, args []interface{}) (interface{}, error) {
+	jp, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid jsonPath, must be a string but got %v", errArg(args[1]))
+	}
+	je, err := conf.GetCachedJsonPathEval(jp)
+	if err != nil {
+		return nil, err
+	}
+	return je.Eval(args[0])
+}
+
+const redactedValue = "******"
+
+// redactErrorArgs indicates whether to hide the argument values in the error messages of the builtin functions
+var redactErrorArgs atomic.Bool
+
+// SetRedactErrorArgs sets whether the builtin functions replace the argument values in their error messages with a
+// descriptor of the value type and length such as <string(len=11)>. It prevents leaking sensitive data such as PII
+// into the logs. It is disabled by default.
+func SetRedactErrorArgs(redact bool) {
+	redactErrorArgs.Store(redact)
+}
+
+// errArg returns the argument value to print in an error message, or its descriptor if the redaction is enabled
+func errArg(v any) any {
+	if !redactErrorArgs.Load() {
+		return v
+	}
+	if v == nil {
+		return "<nil>"
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("<string(len=%d)>", rv.Len())
+	case reflect.Slice, reflect.Array:
+		if _, ok := v.([]byte); ok {
+			return fmt.Sprintf("<bytea(len=%d)>", rv.Len())
+		}
+		return fmt.Sprintf("<array(len=%d)>", rv.Len())
+	case reflect.Map:
+		return fmt.Sprintf("<object(len=%d)>", rv.Len())
+	default:
+		return fmt.Sprintf("<%T>", v)
+	}
+}
+
+// redactErr returns the error from the other packages such as cast, or hides it if the redaction is enabled because
+// it may contain the argument value
+func redactErr(err error) error {
+	if err == nil || !redactErrorArgs.Load() {
+		return err
+	}
+	return fmt.Errorf("invalid argument: %s", redactedValue)
+}
+
+// redactResult applies redactErr to the error result of the functions from the other packages
+func redactResult(r interface{}, ok bool) (interface{}, bool) {
+	if err, isErr := r.(error); isErr && !ok {
+		return redactErr(err), false
+	}
+	return r, ok
+}
+
+// errCause returns the underlying error to print in an error message. The error is hidden if the redaction is
+// enabled because it may also contain the argument value.
+func errCause(err error) any {
+	if !redactErrorArgs.Load() {
+		return err
+	}
+	return redactedValue
+}
+
+// maxHexWidth is the max zero-padded width of dec2hex
+const maxHexWidth = 64
+
+func isPropDumpAllowed(name string) bool {
+	if conf.Config == nil {
+		return false
+	}
+	for _, n := range conf.Config.Basic.PropsDumpAllowList {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isEnvAllowed(name string) bool {
+	if conf.Config == nil {
+		return false
+	}
+	for _, n := range conf.Config.Basic.EnvAllowList {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isCastType reports whether the type name is supported by the cast function
+func isCastType(t string) bool {
+	switch t {
+	case "bigint", "float", "string", "boolean", "datetime", "bytea":
+		return true
+	default:
+		return false
+	}
+}
+
+// castStrict casts the value to bigint or float and returns an error if the conversion loses information
+func castStrict(value interface{}, newType string) (interface{}, bool) {
+	switch newType {
+	case "bigint":
+		switch v := value.(type) {
+		case uint:
+			if uint64(v) > math.MaxInt64 {
+				return fmt.Errorf("cannot cast %v to bigint: overflow", errArg(v)), false
+			}
+		case uint64:
+			if v > math.MaxInt64 {
+				return fmt.Errorf("cannot cast %v to bigint: overflow", errArg(v)), false
+			}
+		case float32, float64:
+			f, _ := cast.ToFloat64(v, cast.STRICT)
+			if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return fmt.Errorf("cannot cast %v to bigint: overflow", errArg(v)), false
+			}
+			if _, err := cast.ToInt(v, cast.STRICT); err != nil {
+				return fmt.Errorf("cannot cast %v to bigint: fractional part dropped", errArg(v)), false
+			}
+		}
+	case "float":
+		switch v := value.(type) {
+		case int:
+			if f := float64(v); f >= math.MaxInt64 || int64(f) != int64(v) {
+				return fmt.Errorf("cannot cast %v to float: precision lost", errArg(v)), false
+			}
+		case int64:
+			if f := float64(v); f >= math.MaxInt64 || int64(f) != v {
+				return fmt.Errorf("cannot cast %v to float: precision lost", errArg(v)), false
+			}
+		case uint:
+			if f := float64(v); f >= math.MaxUint64 || uint64(f) != uint64(v) {
+				return fmt.Errorf("cannot cast %v to float: precision lost", errArg(v)), false
+			}
+		case uint64:
+			if f := float64(v); f >= math.MaxUint64 || uint64(f) != v {
+				return fmt.Errorf("cannot cast %v to float: precision lost", errArg(v)), false
+			}
+		}
+	}
+	return cast.ToType(value, newType)
+}
+
+// isOfCastType reports whether the runtime type of the value is the cast type
+func isOfCastType(v interface{}, t string) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return t == "bigint"
+	case float32, float64:
+		return t == "float"
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case time.Time:
+		return t == "datetime"
+	case []byte:
+		return t == "bytea"
+	default:
+		return false
+	}
+}
+
+// isEmptyValue reports whether the value is null, an empty string, an empty array or an empty map
+func isEmptyValue(arg interface{}) bool {
+	if arg == nil {
+		return true
+	}
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+const (
+	defaultParseJsonMaxSize  = 16 * 1024 * 1024
+	defaultParseJsonMaxDepth = 1000
+)
+
+// checkJsonLimits checks the size and the nesting depth of the json input before unmarshaling to protect from the
+// malicious payloads. The limits are set by basic.parseJsonMaxSize and basic.parseJsonMaxDepth.
+func checkJsonLimits(b []byte) error {
+	maxSize, maxDepth := defaultParseJsonMaxSize, defaultParseJsonMaxDepth
+	if conf.Config != nil {
+		if conf.Config.Basic.ParseJsonMaxSize > 0 {
+			maxSize = conf.Config.Basic.ParseJsonMaxSize
+		}
+		if conf.Config.Basic.ParseJsonMaxDepth > 0 {
+			maxDepth = conf.Config.Basic.ParseJsonMaxDepth
+		}
+	}
+	if len(b) > maxSize {
+		return fmt.Errorf("the input size %d exceeds the limit %d", len(b), maxSize)
+	}
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range b {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return fmt.Errorf("the nesting depth exceeds the limit %d", maxDepth)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// page Rotate storage for in memory cache
+// Not thread safe!
+type ringqueue struct {
+	Data []any
+	H    int
+	T    int
 	L    int
 	Size int
 }
@@ -764,3 +2209,490 @@ func (p *ringqueue) peek() (interface{}, bool) {
 func (p *ringqueue) isFull() bool {
 	return p.L == p.Size
 }
+
+type timedItem struct {
+	Ts  int64
+	Val any
+}
+
+// timedqueue is a time indexed ringqueue which grows when full.
+// The items must be added in time order so that the expired items are always at the head.
+// Not thread safe!
+type timedqueue struct {
+	Q *ringqueue
+}
+
+func newTimedqueue() *timedqueue {
+	return &timedqueue{Q: newRingqueue(8)}
+}
+
+// add appends the item with its timestamp, double the capacity if the queue is full
+func (p *timedqueue) add(ts int64, item interface{}) {
+	if p.Q.isFull() {
+		nq := newRingqueue(p.Q.Size * 2)
+		for {
+			v, ok := p.Q.fetch()
+			if !ok {
+				break
+			}
+			nq.append(v)
+		}
+		p.Q = nq
+	}
+	p.Q.append(timedItem{Ts: ts, Val: item})
+}
+
+// evict removes all the items whose timestamp is not after the given time
+func (p *timedqueue) evict(before int64) {
+	for {
+		v, ok := p.Q.peek()
+		if !ok || v.(timedItem).Ts > before {
+			return
+		}
+		p.Q.fetch()
+	}
+}
+
+// values returns the item values from the oldest to the newest
+func (p *timedqueue) values() []interface{} {
+	result := make([]interface{}, 0, p.Q.L)
+	for i := 0; i < p.Q.L; i++ {
+		result = append(result, p.Q.Data[(p.Q.H+i)%p.Q.Size].(timedItem).Val)
+	}
+	return result
+}
+
+// hashHex writes the input into the hash and returns the hex encoded digest.
+// The bytea input is written directly to avoid copying the whole payload into a string.
+func hashHex(h hash.Hash, input interface{}) (interface{}, bool) {
+	var err error
+	if b, ok := input.([]byte); ok {
+		_, err = h.Write(b)
+	} else {
+		_, err = io.WriteString(h, cast.ToStringAlways(input))
+	}
+	if err != nil {
+		return err, false
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), true
+}
+
+// hashInput returns the bytes to calculate the checksum. The bytea input is used directly.
+func hashInput(input interface{}) []byte {
+	if b, ok := input.([]byte); ok {
+		return b
+	}
+	return []byte(cast.ToStringAlways(input))
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+func newHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha384":
+		return sha512.New384(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %s, expect one of md5, sha1, sha256, sha384, sha512", errArg(algo))
+	}
+}
+
+// newChecksumHash returns the hash of the algorithm for row_checksum which supports crc32 and crc32c besides the
+// algorithms of newHash
+func newChecksumHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "crc32":
+		return crc32.NewIEEE(), nil
+	case "crc32c":
+		return crc32.New(castagnoliTable), nil
+	}
+	if h, err := newHash(algo); err == nil {
+		return h, nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %s, expect one of crc32, crc32c, md5, sha1, sha256, sha384, sha512", errArg(algo))
+}
+
+// unmarshalUseNumber parses the json text and keeps the numbers as json.Number so that
+// the large integers such as ids are not rounded to float64.
+func unmarshalUseNumber(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return v, nil
+}
+
+// CanonicalJSON marshals the value to json with the map keys sorted in all the levels so that the equal values have
+// the same result regardless of their go map types.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	return json.Marshal(canonicalValue(v))
+}
+
+// canonicalValue converts all the nested maps to map[string]interface{} whose keys
+// are sorted when marshalling to json. The array order is kept. The json.Number is
+// normalized to int64 or float64, and the integer out of the int64 range is kept as is.
+func canonicalValue(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case json.Number:
+		if i, err := vt.Int64(); err == nil {
+			return i
+		}
+		if !strings.ContainsAny(vt.String(), ".eE") {
+			return vt
+		}
+		if f, err := vt.Float64(); err == nil {
+			return f
+		}
+		return vt
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(vt))
+		for k, e := range vt {
+			r[k] = canonicalValue(e)
+		}
+		return r
+	case map[interface{}]interface{}:
+		r := make(map[string]interface{}, len(vt))
+		for k, e := range vt {
+			r[cast.ToStringAlways(k)] = canonicalValue(e)
+		}
+		return r
+	case []interface{}:
+		r := make([]interface{}, len(vt))
+		for i, e := range vt {
+			r[i] = canonicalValue(e)
+		}
+		return r
+	case []map[string]interface{}:
+		r := make([]interface{}, len(vt))
+		for i, e := range vt {
+			r[i] = canonicalValue(e)
+		}
+		return r
+	default:
+		return v
+	}
+}
+
+// crc16 calculates the checksum with the named polynomial.
+// ccitt: CRC-16/CCITT-FALSE, polynomial 0x1021, initial value 0xFFFF, not reflected.
+// ibm: CRC-16/ARC, polynomial 0x8005 (reflected 0xA001), initial value 0x0000, reflected.
+func crc16(data []byte, poly string) (uint16, error) {
+	switch poly {
+	case "ccitt":
+		crc := uint16(0xFFFF)
+		for _, b := range data {
+			crc ^= uint16(b) << 8
+			for i := 0; i < 8; i++ {
+				if crc&0x8000 != 0 {
+					crc = crc<<1 ^ 0x1021
+				} else {
+					crc <<= 1
+				}
+			}
+		}
+		return crc, nil
+	case "ibm":
+		crc := uint16(0)
+		for _, b := range data {
+			crc ^= uint16(b)
+			for i := 0; i < 8; i++ {
+				if crc&1 != 0 {
+					crc = crc>>1 ^ 0xA001
+				} else {
+					crc >>= 1
+				}
+			}
+		}
+		return crc, nil
+	default:
+		return 0, fmt.Errorf("unsupported crc16 polynomial %s, expect one of ccitt, ibm", errArg(poly))
+	}
+}
+
+// parseLocalNumber parses the localized numeric string. The format decides the decimal mark and the grouping separator.
+// en: 1,234.56; de: 1.234,56. Whitespaces and currency symbols are ignored. The grouping separator is only allowed
+// between groups of 3 digits in the integer part, so that a string in the other format is rejected instead of being
+// parsed to a wrong number.
+func parseLocalNumber(s string, format string) (float64, error) {
+	var decimal, group rune
+	switch format {
+	case "en":
+		decimal, group = '.', ','
+	case "de":
+		decimal, group = ',', '.'
+	default:
+		return 0, fmt.Errorf("unsupported number format %s, expect one of en, de", errArg(format))
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r) || unicode.Is(unicode.Sc, r):
+			continue
+		case r == decimal:
+			b.WriteRune('.')
+		case r == group:
+			b.WriteRune(',')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	intPart, fracPart, _ := strings.Cut(b.String(), ".")
+	if !validDigitGroups(intPart) || strings.ContainsRune(fracPart, ',') {
+		return 0, fmt.Errorf("cannot parse %s as number with format %s", errArg(s), errArg(format))
+	}
+	r, err := strconv.ParseFloat(strings.ReplaceAll(b.String(), ",", ""), 64)
+	if err != nil || math.IsInf(r, 0) || math.IsNaN(r) {
+		return 0, fmt.Errorf("cannot parse %s as number with format %s", errArg(s), errArg(format))
+	}
+	return r, nil
+}
+
+// validDigitGroups checks the grouping separators (normalized to comma) of the integer part
+// are only used between groups of 3 digits like 1,234,567.
+func validDigitGroups(s string) bool {
+	if !strings.ContainsRune(s, ',') {
+		return true
+	}
+	groups := strings.Split(strings.TrimLeft(s, "+-"), ",")
+	for i, g := range groups {
+		if len(g) == 0 || len(g) > 3 || i > 0 && len(g) != 3 {
+			return false
+		}
+		for _, c := range g {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// autoDecode detects the encoding of the string and decodes it. The string is
+// decoded as hex if it only contains hex digits and has an even length, which takes
+// precedence over base64 because such strings are also valid base64 in most cases.
+// Otherwise, it is decoded as standard or url safe base64 with padding.
+func autoDecode(s string) ([]byte, error) {
+	if len(s)%2 == 0 && isHexString(s) {
+		return hex.DecodeString(s)
+	}
+	if len(s)%4 == 0 {
+		if r, err := b64.StdEncoding.DecodeString(s); err == nil {
+			return r, nil
+		}
+		if r, err := b64.URLEncoding.DecodeString(s); err == nil {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("fail to decode %s: it is neither hex nor base64 encoded", errArg(s))
+}
+
+func isHexString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+type csvOptions struct {
+	delimiter rune
+	quote     rune
+	trim      bool
+}
+
+// parseCsvOptions parses the options of parse_csv. The options can be a string as the delimiter
+// or an object with the optional keys delimiter, quote and trim.
+func parseCsvOptions(v interface{}) (*csvOptions, error) {
+	opt := &csvOptions{delimiter: ',', quote: '"'}
+	switch ov := v.(type) {
+	case string:
+		d, err := csvChar("delimiter", ov)
+		if err != nil {
+			return nil, err
+		}
+		opt.delimiter = d
+	case map[string]interface{}:
+		for k, val := range ov {
+			var err error
+			switch k {
+			case "delimiter":
+				opt.delimiter, err = csvChar(k, cast.ToStringAlways(val))
+			case "quote":
+				opt.quote, err = csvChar(k, cast.ToStringAlways(val))
+			case "trim":
+				opt.trim, err = cast.ToBool(val, cast.CONVERT_SAMEKIND)
+				err = redactErr(err)
+			default:
+				err = fmt.Errorf("unknown csv option %s, expect delimiter, quote or trim", errArg(k))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("the csv options must be a string or an object but got %v", errArg(v))
+	}
+	if opt.delimiter == opt.quote {
+		return nil, fmt.Errorf("the csv delimiter and quote must be different")
+	}
+	return opt, nil
+}
+
+func csvChar(name, s string) (rune, error) {
+	r := []rune(s)
+	if len(r) != 1 || r[0] == '\r' || r[0] == '\n' || r[0] == utf8.RuneError {
+		return 0, fmt.Errorf("the csv %s must be a single character but got %s", name, errArg(s))
+	}
+	return r[0], nil
+}
+
+// parseCsvLine parses one csv record with the encoding/csv semantics. As encoding/csv only supports
+// the double quote, a custom quote is swapped with the double quote before parsing and swapped back after.
+func parseCsvLine(line string, opt *csvOptions) ([]interface{}, error) {
+	swap := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			switch r {
+			case opt.quote:
+				return '"'
+			case '"':
+				return opt.quote
+			default:
+				return r
+			}
+		}, s)
+	}
+	if opt.quote != '"' {
+		line = swap(line)
+	}
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.Comma = opt.delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = opt.trim
+	record, err := reader.Read()
+	if err == io.EOF {
+		return []interface{}{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse csv: %v", err)
+	}
+	if _, err := reader.Read(); err != io.EOF {
+		return nil, fmt.Errorf("fail to parse csv: only one record is allowed")
+	}
+	result := make([]interface{}, len(record))
+	for i, field := range record {
+		if opt.quote != '"' {
+			field = swap(field)
+		}
+		if opt.trim {
+			field = strings.TrimSpace(field)
+		}
+		result[i] = field
+	}
+	return result, nil
+}
+
+// luhnDigits returns the digits of the number string. The spaces and dashes are ignored as separators.
+func luhnDigits(v interface{}) ([]int, error) {
+	str, err := cast.ToString(v, cast.CONVERT_SAMEKIND)
+	if err != nil {
+		return nil, fmt.Errorf("fail to convert %v to string", errArg(v))
+	}
+	digits := make([]int, 0, len(str))
+	for _, c := range str {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, int(c-'0'))
+		case c == ' ' || c == '-':
+		default:
+			return nil, fmt.Errorf("invalid character %q in %v, expect digits, spaces or dashes", c, errArg(v))
+		}
+	}
+	return digits, nil
+}
+
+// luhnSum sums the digits from the rightmost one and doubles every second digit. The rightmost digit is doubled
+// if it is followed by the check digit to compute.
+func luhnSum(digits []int, doubleFirst bool) int {
+	sum := 0
+	double := doubleFirst
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum
+}
+
+// keyedStateKey namespaces the key by the rule id unless the rule shares the keyed state
+func keyedStateKey(ctx api.FunctionContext, key string) string {
+	if shared, _ := ctx.Value(context.KeyedStateSharedKey).(bool); shared {
+		return key
+	}
+	return keyedstate.NamespacedKey(ctx.GetRuleId(), key)
+}
+
+// parseKv splits the text into pairs by pairSep and then each pair into key and value by the first kvSep.
+// Blank pairs are ignored. Pairs without kvSep are skipped unless strict is set.
+func parseKv(text, pairSep, kvSep string, coerce, strict bool) (map[string]interface{}, error) {
+	if pairSep == "" || kvSep == "" {
+		return nil, fmt.Errorf("the separators of parse_kv must not be empty")
+	}
+	result := make(map[string]interface{})
+	for _, pair := range strings.Split(text, pairSep) {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, found := strings.Cut(pair, kvSep)
+		k = strings.TrimSpace(k)
+		if !found || k == "" {
+			if strict {
+				return nil, fmt.Errorf("invalid key value pair %v", errArg(pair))
+			}
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if coerce {
+			result[k] = coerceKvValue(v)
+		} else {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// coerceKvValue converts the obvious integers, floats and booleans. Others are kept as string.
+func coerceKvValue(v string) interface{} {
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	switch strings.ToLower(v) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return v
+}
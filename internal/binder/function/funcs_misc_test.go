@@ -15,13 +15,18 @@
 package function
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/lf-edge/ekuiper/contract/v2/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -32,6 +37,8 @@ import (
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
+	"github.com/lf-edge/ekuiper/v2/pkg/props"
 	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
@@ -101,6 +108,72 @@ func TestCoalesceExec(t *testing.T) {
 	}
 }
 
+func TestCoalesceEmptyExec(t *testing.T) {
+	f, ok := builtins["coalesce_empty"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"foo", "bar"}, "foo"},
+		{[]interface{}{nil, "", "bar"}, "bar"},
+		{[]interface{}{"", []interface{}{}, map[string]interface{}{}, 0}, 0},
+		{[]interface{}{"", false}, false},
+		{[]interface{}{[]interface{}{}, []interface{}{nil}}, []interface{}{nil}},
+		{[]interface{}{"", nil, []byte{}}, nil},
+	}
+	for _, tt := range tests {
+		result, ok := f.exec(fctx, tt.args)
+		require.True(t, ok)
+		require.Equal(t, tt.result, result, fmt.Sprintf("%v", tt.args))
+	}
+	// coalesce keeps treating only nil as missing
+	result, _ := builtins["coalesce"].exec(fctx, []interface{}{"", "bar"})
+	require.Equal(t, "", result)
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{}), "At least has 1 argument but found 0.")
+}
+
+func TestFirstOfType(t *testing.T) {
+	f, ok := builtins["first_of_type"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	now := time.Now()
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"string", 1, "a", "b"}, "a"},
+		{[]interface{}{"bigint", "a", 1.5, int64(3), 4}, int64(3)},
+		{[]interface{}{"bigint", uint8(2)}, uint8(2)},
+		{[]interface{}{"float", 1, float32(2.5)}, float32(2.5)},
+		{[]interface{}{"boolean", nil, "true", false}, false},
+		{[]interface{}{"datetime", 1, now}, now},
+		{[]interface{}{"bytea", "a", []byte("b")}, []byte("b")},
+		{[]interface{}{"string", 1, nil, true}, nil},
+	}
+	for _, tt := range tests {
+		result, ok := f.exec(fctx, tt.args)
+		require.True(t, ok)
+		require.Equal(t, tt.result, result, fmt.Sprintf("%v", tt.args))
+	}
+	result, ok := f.exec(fctx, []interface{}{"int", 1})
+	require.False(t, ok)
+	require.EqualError(t, result.(error), "the type should be one of bigint, float, string, boolean, datetime, bytea but got int")
+
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "bigint"}, &ast.FieldRef{Name: "a"}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "bigint"}}), "At least has 2 argument but found 1.")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}), "Expect string literal type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "int"}, &ast.FieldRef{Name: "b"}}), "Expect one of following value for the 1st parameter: bigint, float, string, boolean, datetime, bytea.")
+}
+
 func TestToSeconds(t *testing.T) {
 	f, ok := builtins["to_seconds"]
 	if !ok {
@@ -132,6 +205,76 @@ func TestToSeconds(t *testing.T) {
 	}
 }
 
+func TestToSecondsTimeZone(t *testing.T) {
+	old := cast.GetConfiguredTimeZone()
+	require.NoError(t, cast.SetTimeZone("UTC"))
+	defer func() {
+		_ = cast.SetTimeZone(old.String())
+	}()
+	f, ok := builtins["to_seconds"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	loc, err := cast.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	rctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	rctx = kctx.WithValue(rctx, kctx.TimeZoneKey, loc)
+	rfctx := kctx.NewDefaultFuncContext(rctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		ctx    api.FunctionContext
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "global timezone",
+			ctx:    fctx,
+			args:   []interface{}{"2024-01-01 00:00:00"},
+			result: int64(1704067200),
+		},
+		{
+			name:   "rule timezone",
+			ctx:    rfctx,
+			args:   []interface{}{"2024-01-01 00:00:00"},
+			result: int64(1704067200 - 8*3600),
+		},
+		{
+			name:   "explicit timezone",
+			ctx:    rfctx,
+			args:   []interface{}{"2024-01-01 00:00:00", "America/New_York"},
+			result: int64(1704067200 + 5*3600),
+		},
+		{
+			name:   "zone in input",
+			ctx:    rfctx,
+			args:   []interface{}{"2024-01-01T00:00:00Z", "America/New_York"},
+			result: int64(1704067200),
+		},
+		{
+			name:   "invalid timezone",
+			ctx:    fctx,
+			args:   []interface{}{"2024-01-01 00:00:00", "Nowhere/Invalid"},
+			result: errors.New("unknown time zone Nowhere/Invalid"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(tt.ctx, tt.args)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+	// cast to datetime follows the rule timezone too
+	fc, ok := builtins["cast"]
+	require.True(t, ok)
+	result, ok := fc.exec(rfctx, []interface{}{"2024-01-01 00:00:00", "datetime"})
+	require.True(t, ok)
+	require.Equal(t, int64(1704067200-8*3600), result.(time.Time).Unix())
+	err = f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}})
+	require.EqualError(t, err, "Expect string type for parameter 2")
+}
+
 func TestToJson(t *testing.T) {
 	f, ok := builtins["to_json"]
 	if !ok {
@@ -173,6 +316,149 @@ func TestToJson(t *testing.T) {
 	}
 }
 
+func TestToJsonNumbers(t *testing.T) {
+	f, ok := builtins["to_json"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "int64 beyond float precision",
+			args:   []interface{}{int64(1<<53 + 1)},
+			result: "9007199254740993",
+		},
+		{
+			name:   "json number verbatim",
+			args:   []interface{}{[]interface{}{json.Number("9007199254740993"), json.Number("1.50")}},
+			result: "[9007199254740993,1.50]",
+		},
+		{
+			name:   "whole float",
+			args:   []interface{}{map[string]interface{}{"a": 1e21, "b": float32(1e22), "c": 1.5, "d": -3.0}},
+			result: `{"a":1000000000000000000000,"b":10000000000000000000000,"c":1.5,"d":-3}`,
+		},
+		{
+			name:   "number as string",
+			args:   []interface{}{map[string]interface{}{"id": int64(1<<53 + 1), "n": json.Number("9007199254740993"), "v": 1.5, "w": 1e21, "s": "x"}, true},
+			result: `{"id":"9007199254740993","n":"9007199254740993","s":"x","v":"1.5","w":"1000000000000000000000"}`,
+		},
+		{
+			name:   "number as string with precision",
+			args:   []interface{}{[]interface{}{3.14159, uint8(2)}, 2, true},
+			result: `["3.14","2"]`,
+		},
+		{
+			name:   "number as string disabled",
+			args:   []interface{}{[]float64{1, 2.5}, false},
+			result: `[1,2.5]`,
+		},
+		{
+			name:   "invalid flag",
+			args:   []interface{}{1, 2, "true"},
+			result: fmt.Errorf("the numberAsString must be a bool but got true"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.BooleanLiteral{Val: true}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 2}, &ast.BooleanLiteral{Val: true}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 2}, &ast.StringLiteral{Val: "true"}}), "Expect bool type for parameter 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.BooleanLiteral{Val: true}, &ast.BooleanLiteral{Val: true}}), "Expect int type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "the arguments for to_json should be 1 to 3")
+}
+
+func TestToJsonPrecision(t *testing.T) {
+	f, ok := builtins["to_json"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	// use variables so that the sum is not calculated as an exact constant
+	a, b := 0.1, 0.2
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "default",
+			args:   []interface{}{a + b},
+			result: "0.30000000000000004",
+		},
+		{
+			name:   "fixed",
+			args:   []interface{}{a + b, 2},
+			result: "0.3",
+		},
+		{
+			name:   "round up",
+			args:   []interface{}{3.14159, 3},
+			result: "3.142",
+		},
+		{
+			name:   "zero precision",
+			args:   []interface{}{2.6, 0},
+			result: "3",
+		},
+		{
+			name: "nested",
+			args: []interface{}{map[string]interface{}{
+				"a": 1.23456,
+				"b": []interface{}{2.34567, "foo", int64(3)},
+				"c": map[string]interface{}{"d": float32(4.56789)},
+			}, 2},
+			result: `{"a":1.23,"b":[2.35,"foo",3],"c":{"d":4.57}}`,
+		},
+		{
+			name:   "negative precision",
+			args:   []interface{}{1.23, -1},
+			result: fmt.Errorf("the precision must not be negative but got -1"),
+		},
+		{
+			name:   "invalid precision",
+			args:   []interface{}{1.23, "a"},
+			result: fmt.Errorf("the precision must be an int but got a"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	vtests := []struct {
+		args    []ast.Expr
+		wantErr bool
+	}{
+		{[]ast.Expr{&ast.FieldRef{Name: "foo"}}, false},
+		{[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.IntegerLiteral{Val: 2}}, false},
+		{[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.NumberLiteral{Val: 2.1}}, true},
+		{[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.IntegerLiteral{Val: -1}}, true},
+		{[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.IntegerLiteral{Val: 2}, &ast.IntegerLiteral{Val: 2}}, true},
+	}
+	for _, vtt := range vtests {
+		err := f.val(fctx, vtt.args)
+		if vtt.wantErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
 func TestFromJson(t *testing.T) {
 	f, ok := builtins["parse_json"]
 	if !ok {
@@ -447,18 +733,200 @@ func TestKeyedStateExec(t *testing.T) {
 				20.0,
 			},
 			result: 20.0,
+		}, { // 3
+			args: []interface{}{
+				"str",
+				"bigint",
+				int64(10),
+			},
+			result: int64(10),
+		}, { // 4
+			args: []interface{}{
+				"num",
+				"bigint",
+				int64(10),
+			},
+			result: 5,
 		},
 	}
 
+	require.NoError(t, keyedstate.SetKeyedState(keyedstate.NamespacedKey("mockRule0", "str"), "not a number"))
+	require.NoError(t, keyedstate.SetKeyedState(keyedstate.NamespacedKey("mockRule0", "num"), "5"))
 	for i, tt := range tests {
 		result, _ := f.exec(fctx, tt.args)
 		if !reflect.DeepEqual(result, tt.result) {
 			t.Errorf("%d result mismatch,\ngot:\t%v \nwant:\t%v", i, result, tt.result)
 		}
 	}
+	t.Run("batch", func(t *testing.T) {
+		testKeyedStatesExec(t, fctx)
+	})
+	t.Run("set", func(t *testing.T) {
+		testSetKeyedStateExec(t, fctx)
+	})
+	t.Run("setBatch", func(t *testing.T) {
+		testSetKeyedStatesExec(t, fctx)
+	})
+	t.Run("namespace", func(t *testing.T) {
+		testKeyedStateNamespace(t, ctx)
+	})
 	_ = keyedstate.ClearKeyedState()
 }
 
+func testKeyedStateNamespace(t *testing.T, ctx *kctx.DefaultContext) {
+	set := builtins["set_keyed_state"]
+	get := builtins["get_keyed_state"]
+	newFuncCtx := func(c *kctx.DefaultContext, ruleId string) api.FunctionContext {
+		tempStore, _ := state.CreateStore(ruleId, def.AtMostOnce)
+		return kctx.NewDefaultFuncContext(c.WithMeta(ruleId, "test", tempStore), 1)
+	}
+	rule1 := newFuncCtx(ctx, "rule1")
+	rule2 := newFuncCtx(ctx, "rule2")
+	sharedCtx := kctx.WithValue(ctx, kctx.KeyedStateSharedKey, true)
+	shared1 := newFuncCtx(sharedCtx, "rule1")
+	shared2 := newFuncCtx(sharedCtx, "rule2")
+
+	// the same key is isolated by rule
+	_, ok := set.exec(rule1, []interface{}{"counter", int64(1)})
+	require.True(t, ok)
+	_, ok = set.exec(rule2, []interface{}{"counter", int64(2)})
+	require.True(t, ok)
+	r, _ := get.exec(rule1, []interface{}{"counter", "bigint", int64(0)})
+	require.Equal(t, 1, r)
+	r, _ = get.exec(rule2, []interface{}{"counter", "bigint", int64(0)})
+	require.Equal(t, 2, r)
+	r, _ = get.exec(shared1, []interface{}{"counter", "bigint", int64(0)})
+	require.Equal(t, int64(0), r)
+	r, _ = builtins["get_keyed_states"].exec(rule2, []interface{}{[]interface{}{"counter"}, "bigint", int64(0)})
+	require.Equal(t, []interface{}{2}, r)
+
+	// the shared keys are not namespaced so that they can be written by other rules or external writers
+	_, ok = set.exec(shared1, []interface{}{"global", "v"})
+	require.True(t, ok)
+	r, _ = get.exec(shared2, []interface{}{"global", "string", "default"})
+	require.Equal(t, "v", r)
+	r, _ = get.exec(rule2, []interface{}{"global", "string", "default"})
+	require.Equal(t, "default", r)
+	v, err := keyedstate.GetKeyedState("global")
+	require.NoError(t, err)
+	require.Equal(t, "v", v)
+}
+
+func testSetKeyedStateExec(t *testing.T, fctx api.FunctionContext) {
+	f, ok := builtins["set_keyed_state"]
+	require.True(t, ok)
+	get := builtins["get_keyed_state"]
+
+	r, ok := f.exec(fctx, []interface{}{"k1", "v1"})
+	require.True(t, ok)
+	require.Equal(t, "v1", r)
+	r, ok = f.exec(fctx, []interface{}{"k2", "v2", 1000})
+	require.True(t, ok)
+	require.Equal(t, "v2", r)
+	r, _ = get.exec(fctx, []interface{}{"k2", "string", "default"})
+	require.Equal(t, "v2", r)
+	timex.Add(2 * time.Second)
+	r, _ = get.exec(fctx, []interface{}{"k2", "string", "default"})
+	require.Equal(t, "default", r)
+	r, _ = get.exec(fctx, []interface{}{"k1", "string", "default"})
+	require.Equal(t, "v1", r)
+
+	r, ok = f.exec(fctx, []interface{}{"k3", "v3", -1})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "ttl must not be negative but got -1")
+	r, ok = f.exec(fctx, []interface{}{1, "v3"})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "key 1 is not a string")
+
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "foo"}}), "At least has 2 argument but found 1.")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "foo"}, &ast.StringLiteral{Val: "bar"}, &ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 1}}), "Expect at most 3 arguments but found 4.")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "bar"}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "foo"}, &ast.StringLiteral{Val: "bar"}, &ast.StringLiteral{Val: "1s"}}), "Expect int type for parameter 3")
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "key"}, &ast.FieldRef{Name: "value"}, &ast.IntegerLiteral{Val: 1000}}))
+}
+
+func testSetKeyedStatesExec(t *testing.T, fctx api.FunctionContext) {
+	f, ok := builtins["set_keyed_states"]
+	require.True(t, ok)
+	get := builtins["get_keyed_states"]
+
+	require.NoError(t, keyedstate.SetKeyedState(keyedstate.NamespacedKey("mockRule0", "stale"), "old"))
+	r, ok := f.exec(fctx, []interface{}{[]interface{}{"a", "b", "stale"}, []interface{}{"1", "2", nil}})
+	require.True(t, ok, r)
+	require.Equal(t, []interface{}{"1", "2", nil}, r)
+	r, _ = get.exec(fctx, []interface{}{[]interface{}{"a", "b", "stale"}, "string", "default"})
+	require.Equal(t, []interface{}{"1", "2", "default"}, r)
+
+	errTests := []struct {
+		args []interface{}
+		err  string
+	}{
+		{[]interface{}{"a", []interface{}{"1"}}, "keys a is not an array"},
+		{[]interface{}{[]interface{}{"a"}, "1"}, "values 1 is not an array"},
+		{[]interface{}{[]interface{}{"a", "b"}, []interface{}{"1"}}, "the length of keys 2 and values 1 must be the same"},
+		{[]interface{}{[]interface{}{"a", 1}, []interface{}{"1", "2"}}, "key 1 is not a string"},
+		{[]interface{}{[]interface{}{"a", "a"}, []interface{}{"1", nil}}, "duplicate key a"},
+	}
+	for _, tt := range errTests {
+		r, ok := f.exec(fctx, tt.args)
+		require.False(t, ok)
+		require.EqualError(t, r.(error), tt.err)
+	}
+	// nothing is written if the arguments are invalid
+	r, _ = get.exec(fctx, []interface{}{[]interface{}{"a"}, "string", "default"})
+	require.Equal(t, []interface{}{"1"}, r)
+
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "keys"}}), "Expect 2 arguments but found 1.")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.FieldRef{Name: "values"}}), "Expect array type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "keys"}, &ast.IntegerLiteral{Val: 1}}), "Expect array type for parameter 2")
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "keys"}, &ast.FieldRef{Name: "values"}}))
+}
+
+func testKeyedStatesExec(t *testing.T, fctx api.FunctionContext) {
+	f, ok := builtins["get_keyed_states"]
+	require.True(t, ok)
+	require.NoError(t, keyedstate.SetKeyedStates(map[string]interface{}{
+		keyedstate.NamespacedKey("mockRule0", "str"): "not a number",
+		keyedstate.NamespacedKey("mockRule0", "num"): "5",
+		keyedstate.NamespacedKey("mockRule0", "neg"): "-3",
+	}))
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "mixed",
+			args:   []interface{}{[]interface{}{"num", "foo", "str", "neg"}, "bigint", int64(10)},
+			result: []interface{}{5, int64(10), int64(10), -3},
+		},
+		{
+			name:   "empty",
+			args:   []interface{}{[]interface{}{}, "bigint", int64(10)},
+			result: []interface{}{},
+		},
+		{
+			name:   "not array",
+			args:   []interface{}{"num", "bigint", int64(10)},
+			result: fmt.Errorf("keys num is not an array"),
+		},
+		{
+			name:   "not string key",
+			args:   []interface{}{[]interface{}{"num", 1}, "bigint", int64(10)},
+			result: fmt.Errorf("key 1 is not a string"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "foo"}, &ast.StringLiteral{Val: "bigint"}, &ast.IntegerLiteral{Val: 1}}), "Expect array type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "keys"}, &ast.StringLiteral{Val: "bar"}, &ast.IntegerLiteral{Val: 1}}), "expect one of following value for the 2nd parameter: bigint, float, string, boolean, datetime")
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "keys"}, &ast.StringLiteral{Val: "bigint"}, &ast.IntegerLiteral{Val: 1}}))
+}
+
 func TestHexIntFunctions(t *testing.T) {
 	contextLogger := conf.Log.WithField("rule", "testExec")
 	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
@@ -476,6 +944,66 @@ func TestHexIntFunctions(t *testing.T) {
 			},
 			result: int64(16),
 		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0XFF"},
+			result: int64(255),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"-0xff"},
+			result: int64(-255),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0x7fffffffffffffff"},
+			result: int64(math.MaxInt64),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"-0x8000000000000000"},
+			result: int64(math.MinInt64),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0x8000000000000000"},
+			result: fmt.Errorf("hexadecimal value 0x8000000000000000 is out of int64 range"),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0x8000000000000000", true},
+			result: uint64(math.MaxInt64 + 1),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0XFFFFFFFFFFFFFFFF", true},
+			result: uint64(math.MaxUint64),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0x10000000000000000", true},
+			result: fmt.Errorf("hexadecimal value 0x10000000000000000 is out of uint64 range"),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"10", false},
+			result: int64(16),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"-0x1", true},
+			result: fmt.Errorf("invalid hexadecimal value: -0x1, negative value is not allowed in unsigned mode"),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0xzz"},
+			result: fmt.Errorf("invalid hexadecimal value: 0xzz"),
+		},
+		{
+			name:   "hex2dec",
+			args:   []interface{}{"0x10", "true"},
+			result: fmt.Errorf("the unsigned arg should be a bool but got true"),
+		},
 		{
 			name: "dec2hex",
 			args: []interface{}{
@@ -483,6 +1011,46 @@ func TestHexIntFunctions(t *testing.T) {
 			},
 			result: "0x10",
 		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{-255},
+			result: "-0xff",
+		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{255, 4},
+			result: "0x00ff",
+		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{int64(-255), 4},
+			result: "-0x00ff",
+		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{int64(0x1234), 2},
+			result: "0x1234",
+		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{int64(math.MinInt64)},
+			result: "-0x8000000000000000",
+		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{uint64(math.MaxUint64)},
+			result: "0xffffffffffffffff",
+		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{1, -1},
+			result: fmt.Errorf("the width should be an integer between 0 and 64 but got -1"),
+		},
+		{
+			name:   "dec2hex",
+			args:   []interface{}{1.5},
+			result: fmt.Errorf("cannot convert float64(1.5) to int64"),
+		},
 	}
 	for i, tt := range tests {
 		f, ok := builtins[tt.name]
@@ -496,32 +1064,1348 @@ func TestHexIntFunctions(t *testing.T) {
 	}
 }
 
-func TestMiscFuncNil(t *testing.T) {
+func TestHex2DecValidation(t *testing.T) {
+	f := builtins["hex2dec"]
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "0x10"}}))
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.BooleanLiteral{Val: true}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "true"}}), "Expect bool type for parameter 2")
+	require.EqualError(t, f.val(nil, []ast.Expr{}), "Expect 1 or 2 arguments but found 0.")
+}
+
+func TestDec2HexValidation(t *testing.T) {
+	f := builtins["dec2hex"]
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.IntegerLiteral{Val: 1}}))
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 4}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "a"}}), "Expect int type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "4"}}), "Expect int type for parameter 2")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 65}}), "the width should be an integer between 0 and 64 but got 65")
+	require.EqualError(t, f.val(nil, []ast.Expr{}), "Expect 1 or 2 arguments but found 0.")
+}
+
+func TestToNumber(t *testing.T) {
+	f, ok := builtins["to_number"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
 	contextLogger := conf.Log.WithField("rule", "testExec")
 	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
 	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
 	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
-	oldBuiltins := builtins
-	defer func() {
-		builtins = oldBuiltins
-	}()
-	builtins = map[string]builtinFunc{}
-	registerMiscFunc()
-	for name, function := range builtins {
-		switch name {
-		case "compress", "decompress", "newuuid", "tstamp", "rule_id", "rule_start", "window_start", "window_end", "window_trigger", "event_time",
-			"json_path_query", "json_path_query_first", "coalesce", "meta", "json_path_exists", "bypass", "get_keyed_state":
-			continue
-		case "isnull":
-			v, b := function.exec(fctx, []interface{}{nil})
-			require.True(t, b)
-			require.Equal(t, v, true)
-		case "cardinality":
-			v, b := function.check([]interface{}{nil})
-			require.True(t, b)
-			require.Equal(t, v, 0)
-		case "to_json":
-			v, b := function.exec(fctx, []interface{}{nil})
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{args: []interface{}{"1,234.56", "en"}, result: 1234.56},
+		{args: []interface{}{"$1,234.56", "en"}, result: 1234.56},
+		{args: []interface{}{"-1,234,567", "en"}, result: float64(-1234567)},
+		{args: []interface{}{"1.234,56", "de"}, result: 1234.56},
+		{args: []interface{}{"1.234,56 €", "de"}, result: 1234.56},
+		{args: []interface{}{"0,5", "de"}, result: 0.5},
+		{args: []interface{}{"1.234,56", "en"}, result: errors.New("cannot parse 1.234,56 as number with format en")},
+		{args: []interface{}{"1,2,3", "en"}, result: errors.New("cannot parse 1,2,3 as number with format en")},
+		{args: []interface{}{"1234,567.8", "en"}, result: errors.New("cannot parse 1234,567.8 as number with format en")},
+		{args: []interface{}{"1,234.5,6", "en"}, result: errors.New("cannot parse 1,234.5,6 as number with format en")},
+		{args: []interface{}{"1.234.567", "de"}, result: float64(1234567)},
+		{args: []interface{}{"1.234.56", "en"}, result: errors.New("cannot parse 1.234.56 as number with format en")},
+		{args: []interface{}{"abc", "en"}, result: errors.New("cannot parse abc as number with format en")},
+		{args: []interface{}{"Inf", "en"}, result: errors.New("cannot parse Inf as number with format en")},
+		{args: []interface{}{"1", "fr"}, result: errors.New("unsupported number format fr, expect one of en, de")},
+		{args: []interface{}{1, "en"}, result: errors.New("the first argument of to_number must be a string but got 1")},
+	}
+	for i, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, "case %d", i)
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "de"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "fr"}}), "unsupported number format fr, expect one of en, de")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "en"}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 arguments but found 1.")
+}
+
+func TestGeoDistance(t *testing.T) {
+	f, ok := builtins["geo_distance"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result float64
+	}{
+		{
+			name:   "same point",
+			args:   []interface{}{31.2304, 121.4737, 31.2304, 121.4737},
+			result: 0,
+		},
+		{
+			name:   "meters",
+			args:   []interface{}{48.8566, 2.3522, 51.5074, -0.1278},
+			result: 343560,
+		},
+		{
+			name:   "int args",
+			args:   []interface{}{0, 0, 0, 1},
+			result: 111195,
+		},
+		{
+			name:   "km",
+			args:   []interface{}{48.8566, 2.3522, 51.5074, -0.1278, "km"},
+			result: 343.56,
+		},
+		{
+			name:   "mi",
+			args:   []interface{}{48.8566, 2.3522, 51.5074, -0.1278, "MI"},
+			result: 213.48,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, tt.args)
+			require.True(t, ok)
+			require.InDelta(t, tt.result, r, tt.result*0.001+0.01)
+		})
+	}
+	errTests := []struct {
+		name string
+		args []interface{}
+		err  string
+	}{
+		{
+			name: "invalid latitude",
+			args: []interface{}{91, 0, 0, 0},
+			err:  "latitude must be in range [-90, 90] but got 91",
+		},
+		{
+			name: "invalid longitude",
+			args: []interface{}{0, 0, 0, -180.5},
+			err:  "longitude must be in range [-180, 180] but got -180.5",
+		},
+		{
+			name: "invalid type",
+			args: []interface{}{"a", 0, 0, 0},
+			err:  "the 1 argument of geo_distance must be a number but got a",
+		},
+		{
+			name: "invalid unit",
+			args: []interface{}{0, 0, 0, 0, "ft"},
+			err:  "unsupported unit ft for geo_distance, expect one of m, km, mi",
+		},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, tt.args)
+			require.False(t, ok)
+			require.EqualError(t, r.(error), tt.err)
+		})
+	}
+	valTests := []struct {
+		args []ast.Expr
+		err  string
+	}{
+		{
+			args: []ast.Expr{&ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}},
+			err:  "the arguments for geo_distance should be 4 or 5",
+		},
+		{
+			args: []ast.Expr{&ast.NumberLiteral{Val: 1}, &ast.StringLiteral{Val: "1"}, &ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}},
+			err:  "Expect number - float or int type for parameter 2",
+		},
+		{
+			args: []ast.Expr{&ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}, &ast.StringLiteral{Val: "ft"}},
+			err:  "expect one of following value for the 5th parameter: m, km, mi",
+		},
+		{
+			args: []ast.Expr{&ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}, &ast.NumberLiteral{Val: 1}, &ast.StringLiteral{Val: "km"}},
+		},
+	}
+	for _, tt := range valTests {
+		err := f.val(fctx, tt.args)
+		if tt.err == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestPointInPolygon(t *testing.T) {
+	f, ok := builtins["point_in_polygon"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	square := []interface{}{
+		[]interface{}{0.0, 0.0},
+		[]interface{}{10.0, 0.0},
+		[]interface{}{10.0, 10.0},
+		[]interface{}{0.0, 10.0},
+		[]interface{}{0.0, 0.0},
+	}
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "inside",
+			args:   []interface{}{5, 5, square},
+			result: true,
+		},
+		{
+			name:   "outside",
+			args:   []interface{}{5, 11.5, square},
+			result: false,
+		},
+		{
+			name: "concave",
+			args: []interface{}{5.0, 8.0, []interface{}{
+				[]interface{}{0, 0},
+				[]interface{}{10, 0},
+				[]interface{}{5, 4},
+				[]interface{}{10, 10},
+				[]interface{}{0, 10},
+			}},
+			result: false,
+		},
+		{
+			name: "geojson",
+			args: []interface{}{5, 5, map[string]interface{}{
+				"type":        "Polygon",
+				"coordinates": []interface{}{square},
+			}},
+			result: true,
+		},
+		{
+			name:   "degenerate",
+			args:   []interface{}{0, 0, []interface{}{[]interface{}{0, 0}, []interface{}{1, 1}}},
+			result: false,
+		},
+		{
+			name:   "invalid point",
+			args:   []interface{}{0, 0, []interface{}{[]interface{}{0}}},
+			result: errors.New("the polygon point must be an array of [lon, lat] but got [0]"),
+		},
+		{
+			name:   "invalid polygon",
+			args:   []interface{}{0, 0, "foo"},
+			result: errors.New("the polygon of point_in_polygon must be an array but got foo"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, r)
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "lat"}, &ast.FieldRef{Name: "lon"}, &ast.FieldRef{Name: "area"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "lat"}, &ast.StringLiteral{Val: "lon"}, &ast.FieldRef{Name: "area"}}), "Expect number - float or int type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "lat"}, &ast.FieldRef{Name: "lon"}, &ast.StringLiteral{Val: "area"}}), "Expect array type for parameter 3")
+}
+
+func TestNewUUIDFormat(t *testing.T) {
+	f, ok := builtins["newuuid"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name    string
+		args    []interface{}
+		pattern string
+	}{
+		{
+			name:    "default",
+			args:    []interface{}{},
+			pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`,
+		},
+		{
+			name:    "canonical",
+			args:    []interface{}{"canonical"},
+			pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`,
+		},
+		{
+			name:    "simple",
+			args:    []interface{}{"simple"},
+			pattern: `^[0-9a-f]{32}$`,
+		},
+		{
+			name:    "urn",
+			args:    []interface{}{"urn"},
+			pattern: `^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`,
+		},
+		{
+			name:    "braced",
+			args:    []interface{}{"BRACED"},
+			pattern: `^\{[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\}$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, tt.args)
+			require.True(t, ok)
+			require.Regexp(t, tt.pattern, r)
+		})
+	}
+	r, ok := f.exec(fctx, []interface{}{"hex"})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "unsupported uuid format hex, expect one of canonical, simple, urn, braced")
+
+	require.NoError(t, f.val(fctx, []ast.Expr{}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "urn"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "hex"}}), "unsupported uuid format hex, expect one of canonical, simple, urn, braced")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "urn"}, &ast.StringLiteral{Val: "urn"}}), "Expect at most 1 argument but found 2.")
+}
+
+func TestIsEmpty(t *testing.T) {
+	f, ok := builtins["is_empty"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		arg    interface{}
+		result bool
+	}{
+		{arg: nil, result: true},
+		{arg: "", result: true},
+		{arg: []interface{}{}, result: true},
+		{arg: map[string]interface{}{}, result: true},
+		{arg: []interface{}(nil), result: true},
+		{arg: "a", result: false},
+		{arg: []interface{}{1}, result: false},
+		{arg: map[string]interface{}{"a": 1}, result: false},
+		{arg: 0, result: false},
+		{arg: false, result: false},
+	}
+	for i, tt := range tests {
+		r, ok := f.exec(fctx, []interface{}{tt.arg})
+		require.True(t, ok)
+		require.Equal(t, tt.result, r, "case %d", i)
+	}
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "Expect 1 arguments but found 0.")
+}
+
+func TestLuhn(t *testing.T) {
+	valid, ok := builtins["luhn_valid"]
+	require.True(t, ok)
+	checkdigit, ok := builtins["luhn_checkdigit"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	validTests := []struct {
+		arg    interface{}
+		result interface{}
+		err    string
+	}{
+		{arg: "79927398713", result: true},
+		{arg: "79927398710", result: false},
+		{arg: "4539 1488 0343 6467", result: true},
+		{arg: "4539-1488-0343-6468", result: false},
+		{arg: "00", result: true},
+		{arg: "0", err: "the number should have at least 2 digits but got 1"},
+		{arg: "4539a", err: "invalid character 'a' in 4539a, expect digits, spaces or dashes"},
+		{arg: 123, err: "fail to convert 123 to string"},
+	}
+	for _, tt := range validTests {
+		r, ok := valid.exec(fctx, []interface{}{tt.arg})
+		if tt.err != "" {
+			require.False(t, ok, tt.arg)
+			require.EqualError(t, r.(error), tt.err)
+		} else {
+			require.True(t, ok, tt.arg)
+			require.Equal(t, tt.result, r, tt.arg)
+		}
+	}
+	checkTests := []struct {
+		arg    interface{}
+		result interface{}
+		err    string
+	}{
+		{arg: "7992739871", result: 3},
+		{arg: "4539 1488 0343 646", result: 7},
+		{arg: "0", result: 0},
+		{arg: " - ", err: "the number should have at least 1 digit"},
+		{arg: "12.3", err: "invalid character '.' in 12.3, expect digits, spaces or dashes"},
+	}
+	for _, tt := range checkTests {
+		r, ok := checkdigit.exec(fctx, []interface{}{tt.arg})
+		if tt.err != "" {
+			require.False(t, ok, tt.arg)
+			require.EqualError(t, r.(error), tt.err)
+		} else {
+			require.True(t, ok, tt.arg)
+			require.Equal(t, tt.result, r, tt.arg)
+		}
+	}
+	for _, f := range []builtinFunc{valid, checkdigit} {
+		require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	}
+}
+
+func TestJsonCanonical(t *testing.T) {
+	f, ok := builtins["json_canonical"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		arg    interface{}
+		result interface{}
+		err    string
+	}{
+		{
+			name:   "string",
+			arg:    ` { "b": [3, {"y": 1, "x": 2.50}], "a": "s" } `,
+			result: `{"a":"s","b":[3,{"x":2.5,"y":1}]}`,
+		},
+		{
+			name:   "map",
+			arg:    map[string]interface{}{"b": 1, "a": map[interface{}]interface{}{"d": true, "c": nil}},
+			result: `{"a":{"c":null,"d":true},"b":1}`,
+		},
+		{
+			name:   "array",
+			arg:    []map[string]interface{}{{"b": 1, "a": 2}},
+			result: `[{"a":2,"b":1}]`,
+		},
+		{
+			name:   "scalar",
+			arg:    int64(10),
+			result: `10`,
+		},
+		{
+			name:   "large integer",
+			arg:    `{"id": 12345678901234567890, "n": -9007199254740993, "f": 1e3}`,
+			result: `{"f":1000,"id":12345678901234567890,"n":-9007199254740993}`,
+		},
+		{
+			name: "invalid json",
+			arg:  `{"a":`,
+			err:  "fail to parse json: unexpected EOF",
+		},
+		{
+			name: "trailing data",
+			arg:  `{"a":1} 2`,
+			err:  "fail to parse json: invalid character after top-level value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, []interface{}{tt.arg})
+			if tt.err != "" {
+				require.False(t, ok)
+				require.EqualError(t, r.(error), tt.err)
+			} else {
+				require.True(t, ok)
+				require.Equal(t, tt.result, r)
+			}
+		})
+	}
+	// the same content in different key order results in the same string
+	r1, _ := f.exec(fctx, []interface{}{`{"a":1,"b":2}`})
+	r2, _ := f.exec(fctx, []interface{}{`{"b":2,"a":1}`})
+	require.Equal(t, r1, r2)
+	r, ok := f.check([]interface{}{nil})
+	require.True(t, ok)
+	require.Nil(t, r)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "Expect 1 arguments but found 0.")
+}
+
+func TestJsonValid(t *testing.T) {
+	f, ok := builtins["json_valid"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		arg    interface{}
+		result bool
+	}{
+		{arg: `{"a":1,"b":[1,2]}`, result: true},
+		{arg: `[1,2,3]`, result: true},
+		{arg: `"str"`, result: true},
+		{arg: `12.5`, result: true},
+		{arg: `null`, result: true},
+		{arg: `{"a":1`, result: false},
+		{arg: `{a:1}`, result: false},
+		{arg: ``, result: false},
+		{arg: 12, result: false},
+		{arg: map[string]interface{}{"a": 1}, result: false},
+	}
+	for i, tt := range tests {
+		r, ok := f.exec(fctx, []interface{}{tt.arg})
+		require.True(t, ok)
+		require.Equal(t, tt.result, r, "case %d", i)
+	}
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "Expect 1 arguments but found 0.")
+}
+
+func TestRuleRuntime(t *testing.T) {
+	f, ok := builtins["rule_runtime"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	// no rule start
+	r, ok := f.exec(fctx, []interface{}{})
+	require.True(t, ok)
+	require.Equal(t, int64(0), r)
+
+	start := timex.GetNowInMilli()
+	ctx = kctx.WithValue(ctx, kctx.RuleStartKey, start)
+	fctx = kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	timex.Add(1500 * time.Millisecond)
+	r, ok = f.exec(fctx, []interface{}{})
+	require.True(t, ok)
+	require.Equal(t, int64(1500), r)
+
+	require.NoError(t, f.val(fctx, []ast.Expr{}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect 0 arguments but found 1.")
+}
+
+func TestAutoDecode(t *testing.T) {
+	f, ok := builtins["auto_decode"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		arg    interface{}
+		result interface{}
+		err    string
+	}{
+		{
+			name:   "hex",
+			arg:    "48656c6C6f",
+			result: []byte("Hello"),
+		},
+		{
+			name:   "base64",
+			arg:    "SGVsbG8=",
+			result: []byte("Hello"),
+		},
+		{
+			name:   "url safe base64",
+			arg:    "-_8=",
+			result: []byte{0xfb, 0xff},
+		},
+		{
+			name:   "ambiguous prefers hex",
+			arg:    "abcd",
+			result: []byte{0xab, 0xcd},
+		},
+		{
+			name:   "odd length hex falls back to base64",
+			arg:    "abc=",
+			result: []byte{0x69, 0xb7},
+		},
+		{
+			name:   "empty",
+			arg:    "",
+			result: []byte{},
+		},
+		{
+			name: "invalid",
+			arg:  "SGVsbG8",
+			err:  "fail to decode SGVsbG8: it is neither hex nor base64 encoded",
+		},
+		{
+			name: "non string",
+			arg:  12,
+			err:  "Only string type can be decoded.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, []interface{}{tt.arg})
+			if tt.err != "" {
+				require.False(t, ok)
+				require.EqualError(t, r.(error), tt.err)
+			} else {
+				require.True(t, ok)
+				require.Equal(t, tt.result, r)
+			}
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "Expect 1 arguments but found 0.")
+}
+
+func TestIpFunctions(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+		err    string
+	}{
+		{
+			name:   "ip_in_cidr",
+			args:   []interface{}{"192.168.1.10", "192.168.0.0/16"},
+			result: true,
+		},
+		{
+			name:   "ip_in_cidr",
+			args:   []interface{}{"10.0.0.1", "192.168.0.0/16"},
+			result: false,
+		},
+		{
+			name:   "ip_in_cidr",
+			args:   []interface{}{"2001:db8::1", "2001:db8::/32"},
+			result: true,
+		},
+		{
+			name:   "ip_in_cidr",
+			args:   []interface{}{"::ffff:192.168.1.10", "192.168.0.0/16"},
+			result: true,
+		},
+		{
+			name:   "ip_in_cidr",
+			args:   []interface{}{"192.168.1.10", "2001:db8::/32"},
+			result: false,
+		},
+		{
+			name: "ip_in_cidr",
+			args: []interface{}{"192.168.1", "192.168.0.0/16"},
+			err:  `invalid ip address 192.168.1: ParseAddr("192.168.1"): IPv4 address too short`,
+		},
+		{
+			name: "ip_in_cidr",
+			args: []interface{}{"192.168.1.10", "192.168.0.0/33"},
+			err:  `invalid cidr 192.168.0.0/33: netip.ParsePrefix("192.168.0.0/33"): prefix length out of range`,
+		},
+		{
+			name:   "ip_to_int",
+			args:   []interface{}{"192.168.1.10"},
+			result: int64(3232235786),
+		},
+		{
+			name:   "ip_to_int",
+			args:   []interface{}{"255.255.255.255"},
+			result: int64(4294967295),
+		},
+		{
+			name:   "ip_to_int",
+			args:   []interface{}{"::ffff:0.0.0.1"},
+			result: int64(1),
+		},
+		{
+			name: "ip_to_int",
+			args: []interface{}{"2001:db8::1"},
+			err:  "only IPv4 address can be converted to int but got 2001:db8::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := builtins[tt.name]
+			require.True(t, ok)
+			r, ok := f.exec(fctx, tt.args)
+			if tt.err != "" {
+				require.False(t, ok)
+				require.EqualError(t, r.(error), tt.err)
+			} else {
+				require.True(t, ok)
+				require.Equal(t, tt.result, r)
+			}
+		})
+	}
+	f := builtins["ip_in_cidr"]
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ip"}, &ast.StringLiteral{Val: "10.0.0.0/8"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ip"}, &ast.IntegerLiteral{Val: 8}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ip"}}), "Expect 2 arguments but found 1.")
+	f = builtins["ip_to_int"]
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "ip"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.BooleanLiteral{Val: true}}), "Expect string type for parameter 1")
+}
+
+func TestParseCsv(t *testing.T) {
+	f, ok := builtins["parse_csv"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+		err    string
+	}{
+		{
+			name:   "default",
+			args:   []interface{}{`a,"b,c",,"say ""hi"""`},
+			result: []interface{}{"a", "b,c", "", `say "hi"`},
+		},
+		{
+			name:   "string delimiter",
+			args:   []interface{}{"a;b;c", ";"},
+			result: []interface{}{"a", "b", "c"},
+		},
+		{
+			name:   "tab delimiter",
+			args:   []interface{}{"a\tb", map[string]interface{}{"delimiter": "\t"}},
+			result: []interface{}{"a", "b"},
+		},
+		{
+			name:   "custom quote",
+			args:   []interface{}{`'a|b'|"c"`, map[string]interface{}{"delimiter": "|", "quote": "'"}},
+			result: []interface{}{"a|b", `"c"`},
+		},
+		{
+			name:   "trim",
+			args:   []interface{}{` a ,  "b,c",c `, map[string]interface{}{"trim": true}},
+			result: []interface{}{"a", "b,c", "c"},
+		},
+		{
+			name:   "no trim",
+			args:   []interface{}{` a ,b`},
+			result: []interface{}{" a ", "b"},
+		},
+		{
+			name:   "multiline quoted field",
+			args:   []interface{}{"\"a\nb\",c\n"},
+			result: []interface{}{"a\nb", "c"},
+		},
+		{
+			name:   "empty",
+			args:   []interface{}{""},
+			result: []interface{}{},
+		},
+		{
+			name: "bad quote",
+			args: []interface{}{`a,"b`},
+			err:  `fail to parse csv: parse error on line 1, column 5: extraneous or missing " in quoted-field`,
+		},
+		{
+			name: "multiple records",
+			args: []interface{}{"a,b\nc,d"},
+			err:  "fail to parse csv: only one record is allowed",
+		},
+		{
+			name: "unknown option",
+			args: []interface{}{"a", map[string]interface{}{"sep": ","}},
+			err:  "unknown csv option sep, expect delimiter, quote or trim",
+		},
+		{
+			name: "invalid delimiter",
+			args: []interface{}{"a", "ab"},
+			err:  "the csv delimiter must be a single character but got ab",
+		},
+		{
+			name: "same delimiter and quote",
+			args: []interface{}{"a", map[string]interface{}{"quote": ","}},
+			err:  "the csv delimiter and quote must be different",
+		},
+		{
+			name: "invalid options",
+			args: []interface{}{"a", 1},
+			err:  "the csv options must be a string or an object but got 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, tt.args)
+			if tt.err != "" {
+				require.False(t, ok)
+				require.EqualError(t, r.(error), tt.err)
+			} else {
+				require.True(t, ok)
+				require.Equal(t, tt.result, r)
+			}
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: ";"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "opts"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect string or object type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: ";;"}}), "the csv delimiter must be a single character but got ;;")
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "Expect one or two arguments but found 0.")
+}
+
+func TestParseKv(t *testing.T) {
+	f, ok := builtins["parse_kv"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+		err    string
+	}{
+		{
+			name:   "default",
+			args:   []interface{}{" k1 = v1; k2=v2 ;", ";", "="},
+			result: map[string]interface{}{"k1": "v1", "k2": "v2"},
+		},
+		{
+			name:   "value with kv separator",
+			args:   []interface{}{"url=a=b&c=", "&", "="},
+			result: map[string]interface{}{"url": "a=b", "c": ""},
+		},
+		{
+			name:   "multi-char separators",
+			args:   []interface{}{"a:>1||b:>2", "||", ":>"},
+			result: map[string]interface{}{"a": "1", "b": "2"},
+		},
+		{
+			name:   "no coerce",
+			args:   []interface{}{"a=1;b=true", ";", "=", false},
+			result: map[string]interface{}{"a": "1", "b": "true"},
+		},
+		{
+			name:   "coerce",
+			args:   []interface{}{"a=1;b=-2.5;c=TRUE;d=false;e=abc;f=inf;g=", ";", "=", true},
+			result: map[string]interface{}{"a": int64(1), "b": -2.5, "c": true, "d": false, "e": "abc", "f": "inf", "g": ""},
+		},
+		{
+			name:   "skip malformed",
+			args:   []interface{}{"a=1;bad;=2;c=3", ";", "=", false, false},
+			result: map[string]interface{}{"a": "1", "c": "3"},
+		},
+		{
+			name: "strict malformed",
+			args: []interface{}{"a=1;bad", ";", "=", false, true},
+			err:  "invalid key value pair bad",
+		},
+		{
+			name:   "empty",
+			args:   []interface{}{"", ";", "="},
+			result: map[string]interface{}{},
+		},
+		{
+			name: "empty separator",
+			args: []interface{}{"a=1", "", "="},
+			err:  "the separators of parse_kv must not be empty",
+		},
+		{
+			name: "invalid flag",
+			args: []interface{}{"a=1", ";", "=", "yes"},
+			err:  "the coerce flag must be a bool but got yes",
+		},
+		{
+			name: "invalid text",
+			args: []interface{}{1, ";", "="},
+			err:  "fail to convert 1 to string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := f.exec(fctx, tt.args)
+			if tt.err != "" {
+				require.False(t, ok)
+				require.EqualError(t, r.(error), tt.err)
+			} else {
+				require.True(t, ok)
+				require.Equal(t, tt.result, r)
+			}
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: ";"}, &ast.StringLiteral{Val: "="}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: ";"}, &ast.StringLiteral{Val: "="}, &ast.BooleanLiteral{Val: true}, &ast.FieldRef{Name: "b"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: ";"}}), "the arguments for parse_kv should be 3 to 5")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "="}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: ";"}, &ast.StringLiteral{Val: ""}}), "the separators of parse_kv must not be empty")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: ";"}, &ast.StringLiteral{Val: "="}, &ast.StringLiteral{Val: "true"}}), "Expect bool type for parameter 4")
+}
+
+func TestContentHash(t *testing.T) {
+	f, ok := builtins["content_hash"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "map md5",
+			args:   []interface{}{map[string]interface{}{"b": map[string]interface{}{"d": []interface{}{2, 1}, "c": "x"}, "a": 1}, "md5"},
+			result: "505815cb7d3fd72a6d699fad7e5604a3",
+		},
+		{
+			name:   "interface key map md5",
+			args:   []interface{}{map[interface{}]interface{}{"a": 1.0, "b": map[interface{}]interface{}{"c": "x", "d": []interface{}{2, 1}}}, "md5"},
+			result: "505815cb7d3fd72a6d699fad7e5604a3",
+		},
+		{
+			name:   "map sha256",
+			args:   []interface{}{map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": "x", "d": []interface{}{2, 1}}}, "sha256"},
+			result: "a75f1418dd9650e75f650ac3d05c284499f3fb8f32ff18e2757ddb7a22d3e522",
+		},
+		{
+			name:   "string",
+			args:   []interface{}{"abc", "md5"},
+			result: "ebd9f4c7b06cb0aaf5d13d80e49d8b90",
+		},
+		{
+			name:   "unknown algo",
+			args:   []interface{}{"abc", "crc"},
+			result: errors.New("unsupported hash algorithm crc, expect one of md5, sha1, sha256, sha384, sha512"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, r)
+		})
+	}
+	// array order is kept
+	r1, _ := f.exec(fctx, []interface{}{[]interface{}{1, 2}, "sha1"})
+	r2, _ := f.exec(fctx, []interface{}{[]interface{}{2, 1}, "sha1"})
+	require.NotEqual(t, r1, r2)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "sha512"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "crc"}}), "unsupported hash algorithm crc, expect one of md5, sha1, sha256, sha384, sha512")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 arguments but found 1.")
+}
+
+func TestRowChecksum(t *testing.T) {
+	f, ok := builtins["row_checksum"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "md5",
+			args:   []interface{}{"md5", "a", 1, map[string]interface{}{"y": 2, "x": 1}, nil},
+			result: "57e8d7d728863af7abb3d1466905c3f3",
+		},
+		{
+			name:   "interface key map",
+			args:   []interface{}{"md5", "a", 1.0, map[interface{}]interface{}{"x": 1, "y": int64(2)}, nil},
+			result: "57e8d7d728863af7abb3d1466905c3f3",
+		},
+		{
+			name:   "crc32",
+			args:   []interface{}{"crc32", "a", 1, map[string]interface{}{"y": 2, "x": 1}, nil},
+			result: "673de5df",
+		},
+		{
+			name:   "no separator ambiguity",
+			args:   []interface{}{"md5", "ab", "c"},
+			result: "933d216840a75af8e568e7972fb2697f",
+		},
+		{
+			name:   "no separator ambiguity 2",
+			args:   []interface{}{"md5", "a", "bc"},
+			result: "c4af8e002b21aabadd114ae4fac47125",
+		},
+		{
+			name:   "type is kept",
+			args:   []interface{}{"sha256", "1"},
+			result: "43de3a417d75f4818c5a553268b80ce3a5805109a3bbc6b605e9fb0b8f50b485",
+		},
+		{
+			name:   "number",
+			args:   []interface{}{"sha256", 1},
+			result: "080a9ed428559ef602668b4c00f114f1a11c3f6b02a435f0bdc154578e4d7f22",
+		},
+		{
+			name:   "unknown algo",
+			args:   []interface{}{"crc", 1},
+			result: errors.New("unsupported checksum algorithm crc, expect one of crc32, crc32c, md5, sha1, sha256, sha384, sha512"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, r)
+		})
+	}
+	r, skip := f.check([]interface{}{nil, 1})
+	require.True(t, skip)
+	require.Nil(t, r)
+	_, skip = f.check([]interface{}{"md5", nil})
+	require.False(t, skip)
+
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "sha1"}, &ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "sha1"}}), "At least has 2 argument but found 1.")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "algo"}, &ast.FieldRef{Name: "a"}}), "Expect string literal type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "crc"}, &ast.FieldRef{Name: "a"}}), "unsupported checksum algorithm crc, expect one of crc32, crc32c, md5, sha1, sha256, sha384, sha512")
+}
+
+func TestHashBytes(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		result string
+	}{
+		{name: "md5", result: "5d41402abc4b2a76b9719d911017c592"},
+		{name: "sha1", result: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{name: "sha256", result: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{name: "crc32", result: "3610a686"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := builtins[tt.name]
+			require.True(t, ok)
+			r, ok := f.exec(fctx, []interface{}{"hello"})
+			require.True(t, ok)
+			require.Equal(t, tt.result, r)
+			r, ok = f.exec(fctx, []interface{}{[]byte("hello")})
+			require.True(t, ok)
+			require.Equal(t, tt.result, r)
+		})
+	}
+}
+
+func TestCrcVariants(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{name: "crc16", args: []interface{}{"123456789"}, result: "29b1"},
+		{name: "crc16", args: []interface{}{"123456789", "ccitt"}, result: "29b1"},
+		{name: "crc16", args: []interface{}{"123456789", "ibm"}, result: "bb3d"},
+		{name: "crc16", args: []interface{}{[]byte("hello"), "ibm"}, result: "34d2"},
+		{name: "crc16", args: []interface{}{"hello", "modbus"}, result: errors.New("unsupported crc16 polynomial modbus, expect one of ccitt, ibm")},
+		{name: "crc32c", args: []interface{}{"123456789"}, result: "e3069283"},
+		{name: "crc32c", args: []interface{}{[]byte("hello")}, result: "9a71bb4c"},
+	}
+	for i, tt := range tests {
+		f, ok := builtins[tt.name]
+		require.True(t, ok)
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, "case %d", i)
+	}
+
+	f := builtins["crc16"]
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "ibm"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "x25"}}), "unsupported crc16 polynomial x25, expect one of ccitt, ibm")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{}), "At least has 1 argument but found 0.")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "ibm"}, &ast.StringLiteral{Val: "ibm"}}), "Expect at most 2 arguments but found 3.")
+	f = builtins["crc32c"]
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+}
+
+func BenchmarkHashBytes(b *testing.B) {
+	payload := make([]byte, 1024*1024)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = hashHex(sha256.New(), payload)
+		}
+	})
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = hashHex(sha256.New(), cast.ToStringAlways(payload))
+		}
+	})
+}
+
+func TestJsonPathCache(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f := builtins["json_path_query"]
+	data := map[string]any{"a": map[string]any{"b": 1}}
+	r, ok := f.exec(fctx, []any{data, "$.a.b"})
+	require.True(t, ok)
+	require.Equal(t, 1, r)
+	je, err := conf.GetCachedJsonPathEval("$.a.b")
+	require.NoError(t, err)
+	cached, err := conf.GetCachedJsonPathEval("$.a.b")
+	require.NoError(t, err)
+	require.Same(t, je, cached)
+	r, ok = f.exec(fctx, []any{map[string]any{"a": map[string]any{"b": 2}}, "$.a.b"})
+	require.True(t, ok)
+	require.Equal(t, 2, r)
+	// the context shares the same cache
+	r, err = fctx.ParseJsonPath("$.a.b", data)
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+	// invalid path is not cached
+	_, ok = f.exec(fctx, []any{data, "$.a["})
+	require.False(t, ok)
+	_, err = conf.GetCachedJsonPathEval("$.a[")
+	require.Error(t, err)
+}
+
+func TestRedactErrorArgs(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name     string
+		args     []any
+		err      string
+		redacted string
+	}{
+		{
+			name:     "ip_to_int",
+			args:     []any{"john@doe"},
+			err:      `invalid ip address john@doe: ParseAddr("john@doe"): unable to parse IP`,
+			redacted: "invalid ip address <string(len=8)>: ******",
+		},
+		{
+			name:     "hex2dec",
+			args:     []any{"0xzz"},
+			err:      "invalid hexadecimal value: 0xzz",
+			redacted: "invalid hexadecimal value: <string(len=4)>",
+		},
+		{
+			name:     "hex2dec",
+			args:     []any{[]byte{1, 2}},
+			err:      "invalid input type: [1 2] please input hex string",
+			redacted: "invalid input type: <bytea(len=2)> please input hex string",
+		},
+		{
+			name:     "cast",
+			args:     []any{1.5, "bigint", "strict"},
+			err:      "cannot cast 1.5 to bigint: fractional part dropped",
+			redacted: "cannot cast <float64> to bigint: fractional part dropped",
+		},
+		{
+			name:     "point_in_polygon",
+			args:     []any{1.0, 2.0, map[string]any{"type": "Polygon"}},
+			err:      "the polygon of point_in_polygon must have coordinates but got map[type:Polygon]",
+			redacted: "the polygon of point_in_polygon must have coordinates but got <object(len=1)>",
+		},
+		{
+			name:     "set_keyed_states",
+			args:     []any{[]any{"secret", "secret"}, []any{1, 2}},
+			err:      "duplicate key secret",
+			redacted: "duplicate key <string(len=6)>",
+		},
+		{
+			name:     "geo_distance",
+			args:     []any{1.0, 2.0, 3.0, 4.0, 5},
+			err:      "the unit of geo_distance must be a string but got 5",
+			redacted: "the unit of geo_distance must be a string but got <int>",
+		},
+		{
+			name:     "geo_distance",
+			args:     []any{1.0, 2.0, 3.0, 4.0, "secret"},
+			err:      "unsupported unit secret for geo_distance, expect one of m, km, mi",
+			redacted: "unsupported unit <string(len=6)> for geo_distance, expect one of m, km, mi",
+		},
+		{
+			name:     "cast",
+			args:     []any{"secret", "bigint"},
+			err:      "not supported type conversion, got error cannot convert string(secret) to int",
+			redacted: "invalid argument: ******",
+		},
+	}
+	defer SetRedactErrorArgs(false)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := builtins[tt.name]
+			require.True(t, ok)
+			SetRedactErrorArgs(false)
+			r, ok := f.exec(fctx, tt.args)
+			require.False(t, ok)
+			require.EqualError(t, r.(error), tt.err)
+			SetRedactErrorArgs(true)
+			r, ok = f.exec(fctx, tt.args)
+			require.False(t, ok)
+			require.EqualError(t, r.(error), tt.redacted)
+		})
+	}
+	require.Equal(t, "<nil>", errArg(nil))
+	require.Equal(t, "<array(len=3)>", errArg([]any{1, 2, 3}))
+	require.Equal(t, "<bool>", errArg(true))
+
+	// Every error path of the misc functions must not leak the argument values. Put a secret at each position of the
+	// args and check the errors of all the functions.
+	oldBuiltins := builtins
+	defer func() {
+		builtins = oldBuiltins
+	}()
+	builtins = map[string]builtinFunc{}
+	registerMiscFunc()
+	SetRedactErrorArgs(true)
+	const secret = "s3cr3t"
+	secrets := []any{secret, map[string]any{secret: secret}, []any{secret}}
+	for name, f := range builtins {
+		// assert prints the message argument by design
+		if f.exec == nil || name == "assert" {
+			continue
+		}
+		for l := 1; l <= 5; l++ {
+			for i := 0; i < l; i++ {
+				for _, sv := range secrets {
+					args := make([]any, l)
+					for j := range args {
+						args[j] = 1.0
+					}
+					args[i] = sv
+					r, ok := func() (r any, ok bool) {
+						// the args are not validated, ignore the panics of the invalid args
+						defer func() {
+							if recover() != nil {
+								r, ok = nil, true
+							}
+						}()
+						return f.exec(fctx, args)
+					}()
+					if err, isErr := r.(error); isErr && !ok {
+						require.NotContains(t, err.Error(), secret, "%s%v", name, args)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestParseJsonLimits(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f := builtins["parse_json"]
+	oldSize, oldDepth := conf.Config.Basic.ParseJsonMaxSize, conf.Config.Basic.ParseJsonMaxDepth
+	defer func() {
+		conf.Config.Basic.ParseJsonMaxSize, conf.Config.Basic.ParseJsonMaxDepth = oldSize, oldDepth
+	}()
+	conf.Config.Basic.ParseJsonMaxSize, conf.Config.Basic.ParseJsonMaxDepth = 64, 3
+	tests := []struct {
+		input  string
+		result any
+	}{
+		{`{"a":[{"b":1}]}`, map[string]any{"a": []any{map[string]any{"b": float64(1)}}}},
+		{`{"a":"[[[[{{{{","b":"\\\"[[["}`, map[string]any{"a": "[[[[{{{{", "b": `\"[[[`}},
+		{`{"a":[{"b":[1]}]}`, errors.New("fail to parse json: the nesting depth exceeds the limit 3")},
+		{`[[[[[[[[[[`, errors.New("fail to parse json: the nesting depth exceeds the limit 3")},
+		{`"` + strings.Repeat("a", 64) + `"`, errors.New("fail to parse json: the input size 66 exceeds the limit 64")},
+	}
+	for i, tt := range tests {
+		r, _ := f.exec(fctx, []any{tt.input})
+		require.Equal(t, tt.result, r, "case %d", i)
+	}
+	// non-positive values use the defaults
+	conf.Config.Basic.ParseJsonMaxSize, conf.Config.Basic.ParseJsonMaxDepth = 0, 0
+	r, ok := f.exec(fctx, []any{strings.Repeat("[", 100) + strings.Repeat("]", 100)})
+	require.True(t, ok, r)
+	r, ok = f.exec(fctx, []any{strings.Repeat("[", defaultParseJsonMaxDepth+1)})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "fail to parse json: the nesting depth exceeds the limit 1000")
+}
+
+func BenchmarkJsonPathQuery(b *testing.B) {
+	contextLogger := conf.Log.WithField("rule", "benchJsonPath")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	data := map[string]any{"a": map[string]any{"b": []any{1, 2, 3}}}
+	jp := "$.a.b[?(@ > 1)]"
+	b.Run("parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			je, _ := conf.GetJsonPathEval(jp)
+			_, _ = je.Eval(data)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = jsonCall(fctx, []any{data, jp})
+		}
+	})
+	b.Run("cached parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = jsonCall(fctx, []any{data, jp})
+			}
+		})
+	})
+}
+
+func TestMiscFuncNil(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	oldBuiltins := builtins
+	defer func() {
+		builtins = oldBuiltins
+	}()
+	builtins = map[string]builtinFunc{}
+	registerMiscFunc()
+	for name, function := range builtins {
+		switch name {
+		case "compress", "decompress", "newuuid", "tstamp", "rule_id", "rule_start", "rule_runtime", "window_start", "window_end", "window_trigger", "window_index", "event_time", "metakeys", "set_meta",
+			"json_path_query", "json_path_query_first", "coalesce", "coalesce_empty", "first_of_type", "meta", "json_path_exists", "bypass", "get_keyed_state", "assert", "row_checksum":
+			continue
+		case "isnull", "is_empty":
+			v, b := function.exec(fctx, []interface{}{nil})
+			require.True(t, b)
+			require.Equal(t, v, true)
+		case "json_valid":
+			v, b := function.exec(fctx, []interface{}{nil})
+			require.True(t, b)
+			require.Equal(t, v, false)
+		case "get_keyed_states":
+			v, b := function.exec(fctx, []interface{}{nil, "bigint", 0})
+			require.True(t, b)
+			require.Nil(t, v)
+		case "cardinality":
+			v, b := function.check([]interface{}{nil})
+			require.True(t, b)
+			require.Equal(t, v, 0)
+		case "to_json":
+			v, b := function.exec(fctx, []interface{}{nil})
 			require.True(t, b)
 			require.Equal(t, v, "null")
 		case "parse_json":
@@ -539,6 +2423,106 @@ func TestMiscFuncNil(t *testing.T) {
 	}
 }
 
+func TestCastDatetimeWithFormat(t *testing.T) {
+	f, ok := builtins["cast"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	r, ok := f.exec(fctx, []interface{}{"20060102150405", "datetime", "yyyyMMddHHmmss"})
+	require.True(t, ok)
+	require.Equal(t, time.Date(2006, 1, 2, 15, 4, 5, 0, cast.GetConfiguredTimeZone()), r)
+
+	r, ok = f.exec(fctx, []interface{}{int64(1136214245000), "datetime", "yyyyMMddHHmmss"})
+	require.True(t, ok)
+	require.Equal(t, cast.TimeFromUnixMilli(1136214245000), r)
+
+	r, ok = f.exec(fctx, []interface{}{"not a time", "datetime", "yyyyMMddHHmmss"})
+	require.False(t, ok)
+	require.Contains(t, r.(error).Error(), "cannot parse not a time to datetime with layout yyyyMMddHHmmss")
+
+	r, ok = f.exec(fctx, []interface{}{"20060102150405", "bigint", "yyyyMMddHHmmss"})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "the format parameter is only supported for datetime and bytea type")
+}
+
+func TestCastByteaWithEncoding(t *testing.T) {
+	f, ok := builtins["cast"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	r, ok := f.exec(fctx, []interface{}{"0x6279746561", "bytea"})
+	require.True(t, ok)
+	require.Equal(t, []byte("bytea"), r)
+
+	r, ok = f.exec(fctx, []interface{}{"6279746561", "bytea", "hex"})
+	require.True(t, ok)
+	require.Equal(t, []byte("bytea"), r)
+
+	r, ok = f.exec(fctx, []interface{}{"Ynl0ZWE=", "bytea", "base64"})
+	require.True(t, ok)
+	require.Equal(t, []byte("bytea"), r)
+
+	r, ok = f.exec(fctx, []interface{}{"Ynl0ZWE=", "bytea", "hex"})
+	require.False(t, ok)
+	require.EqualError(t, r.(error), "illegal string Ynl0ZWE=, must be hex encoded string")
+
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "bytea"}, &ast.StringLiteral{Val: "hex"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "bytea"}, &ast.StringLiteral{Val: "utf8"}}), "expect hex or base64 for the encoding of bytea but got utf8")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "bigint"}, &ast.StringLiteral{Val: "hex"}}), "the 3rd parameter is only allowed when the target type is datetime or bytea")
+}
+
+func TestCastStrict(t *testing.T) {
+	f, ok := builtins["cast"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+		err    string
+	}{
+		{args: []interface{}{3.0, "bigint", "strict"}, result: 3},
+		{args: []interface{}{float32(-2), "bigint", "strict"}, result: -2},
+		{args: []interface{}{int64(5), "bigint", "strict"}, result: 5},
+		{args: []interface{}{"12", "bigint", "strict"}, result: 12},
+		{args: []interface{}{3.5, "bigint", "strict"}, err: "cannot cast 3.5 to bigint: fractional part dropped"},
+		{args: []interface{}{1e20, "bigint", "strict"}, err: "cannot cast 1e+20 to bigint: overflow"},
+		{args: []interface{}{math.NaN(), "bigint", "strict"}, err: "cannot cast NaN to bigint: overflow"},
+		{args: []interface{}{uint64(math.MaxUint64), "bigint", "strict"}, err: "cannot cast 18446744073709551615 to bigint: overflow"},
+		{args: []interface{}{int64(1 << 53), "float", "strict"}, result: float64(1 << 53)},
+		{args: []interface{}{int64(1<<53 + 1), "float", "strict"}, err: "cannot cast 9007199254740993 to float: precision lost"},
+		{args: []interface{}{int64(math.MaxInt64), "float", "strict"}, err: "cannot cast 9223372036854775807 to float: precision lost"},
+		{args: []interface{}{uint64(math.MaxUint64), "float", "strict"}, err: "cannot cast 18446744073709551615 to float: precision lost"},
+		{args: []interface{}{"1.5", "float", "strict"}, result: 1.5},
+	}
+	for _, tt := range tests {
+		r, ok := f.exec(fctx, tt.args)
+		if tt.err != "" {
+			require.False(t, ok, fmt.Sprintf("%v", tt.args))
+			require.EqualError(t, r.(error), tt.err)
+		} else {
+			require.True(t, ok, fmt.Sprintf("%v", tt.args))
+			require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.args))
+		}
+	}
+	// default mode still truncates
+	r, ok := f.exec(fctx, []interface{}{3.5, "bigint"})
+	require.True(t, ok)
+	require.Equal(t, 3, r)
+
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "bigint"}, &ast.StringLiteral{Val: "strict"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "float"}, &ast.StringLiteral{Val: "strict"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "string"}, &ast.StringLiteral{Val: "strict"}}), "the strict mode is only allowed when the target type is bigint or float")
+}
+
 func TestCast(t *testing.T) {
 	f, ok := builtins["cast"]
 	if !ok {
@@ -640,6 +2624,18 @@ func TestCast(t *testing.T) {
 			[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.StringLiteral{Val: "test"}},
 			true,
 		},
+		{
+			[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.StringLiteral{Val: "datetime"}, &ast.StringLiteral{Val: "yyyyMMddHHmmss"}},
+			false,
+		},
+		{
+			[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.StringLiteral{Val: "bigint"}, &ast.StringLiteral{Val: "yyyyMMddHHmmss"}},
+			true,
+		},
+		{
+			[]ast.Expr{&ast.FieldRef{Name: "foo"}, &ast.StringLiteral{Val: "datetime"}, &ast.IntegerLiteral{Val: 1}},
+			true,
+		},
 	}
 	for _, vtt := range vtests {
 		err := f.val(fctx, vtt.args)
@@ -670,3 +2666,96 @@ func TestProps(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, strconv.FormatInt(tt, 10), et)
 }
+
+func TestEnv(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f := builtins["env"]
+	old := conf.Config.Basic.EnvAllowList
+	defer func() {
+		conf.Config.Basic.EnvAllowList = old
+	}()
+	conf.Config.Basic.EnvAllowList = []string{"KUIPER_TEST_REGION", "KUIPER_TEST_UNSET"}
+	t.Setenv("KUIPER_TEST_REGION", "eu")
+	t.Setenv("KUIPER_TEST_SECRET", "secret")
+	tests := []struct {
+		args   []any
+		result any
+	}{
+		{[]any{"KUIPER_TEST_REGION"}, "eu"},
+		{[]any{"KUIPER_TEST_REGION", "us"}, "eu"},
+		{[]any{"KUIPER_TEST_UNSET", "us"}, "us"},
+		{[]any{"KUIPER_TEST_UNSET"}, nil},
+		// not in the allow list
+		{[]any{"KUIPER_TEST_SECRET", "none"}, "none"},
+		{[]any{"KUIPER_TEST_SECRET"}, nil},
+		{[]any{12}, errors.New("invalid input 12: must be environment variable name of string type")},
+	}
+	for i, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, "case %d", i)
+	}
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.IntegerLiteral{Val: 1}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{}), "Expect 1 or 2 arguments but found 0.")
+}
+
+func TestPropsAll(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f := builtins["props_all"]
+	old := conf.Config.Basic.PropsDumpAllowList
+	defer func() {
+		conf.Config.Basic.PropsDumpAllowList = old
+	}()
+	conf.Config.Basic.PropsDumpAllowList = []string{"test_region"}
+	props.SC.Set("test_region", "eu")
+	props.SC.Set("test_password", "secret")
+	r, ok := f.exec(fctx, []any{})
+	require.True(t, ok)
+	m, ok := r.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "eu", m["test_region"])
+	require.Equal(t, "******", m["test_password"])
+	require.NoError(t, f.val(nil, []ast.Expr{}))
+	require.Error(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "a"}}))
+}
+
+func TestAssert(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	f := builtins["assert"]
+	tests := []struct {
+		args   []any
+		result any
+		ok     bool
+	}{
+		{[]any{true, "temperature out of range"}, true, true},
+		{[]any{false, "temperature out of range"}, errors.New("assertion failed: temperature out of range"), false},
+		{[]any{nil, "temperature is null"}, errors.New("assertion failed: temperature is null"), false},
+		{[]any{1, "msg"}, errors.New("the condition should be a bool but got 1"), false},
+	}
+	for i, tt := range tests {
+		r, ok := f.exec(fctx, tt.args)
+		require.Equal(t, tt.ok, ok, "case %d", i)
+		require.Equal(t, tt.result, r, "case %d", i)
+	}
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "msg"}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "a"}, &ast.StringLiteral{Val: "msg"}}), "Expect bool type for parameter 1")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 arguments but found 1.")
+}
+
+func TestSetMetaVal(t *testing.T) {
+	f, ok := builtins["set_meta"]
+	require.True(t, ok)
+	require.NoError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "topic"}, &ast.FieldRef{Name: "a"}}))
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.StringLiteral{Val: "topic"}}), "Expect 2 arguments but found 1.")
+	require.EqualError(t, f.val(nil, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.FieldRef{Name: "a"}}), "Expect string type for parameter 1")
+}
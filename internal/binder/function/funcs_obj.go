@@ -17,6 +17,8 @@ package function
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
@@ -28,6 +30,7 @@ import (
 func registerObjectFunc() {
 	builtins["keys"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg := args[0]
 			if arg, ok := arg.(map[string]interface{}); ok {
@@ -44,6 +47,7 @@ func registerObjectFunc() {
 	}
 	builtins["values"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg := args[0]
 			if arg, ok := arg.(map[string]interface{}); ok {
@@ -60,6 +64,7 @@ func registerObjectFunc() {
 	}
 	builtins["object"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			keys, ok := args[0].([]interface{})
 			if !ok {
@@ -90,8 +95,45 @@ func registerObjectFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["to_map"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			keys, ok := args[0].([]interface{})
+			if !ok {
+				return fmt.Errorf("first argument should be an array but got %v", args[0]), false
+			}
+			values, ok := args[1].([]interface{})
+			if !ok {
+				return fmt.Errorf("second argument should be an array but got %v", args[1]), false
+			}
+			l := len(keys)
+			if len(values) < l {
+				l = len(values)
+			}
+			m := make(map[string]interface{}, l)
+			for i := 0; i < l; i++ {
+				// the later value overrides the former one for duplicate keys
+				m[cast.ToStringAlways(keys[i])] = values[i]
+			}
+			return m, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(2, len(args)); err != nil {
+				return err
+			}
+			for i := 0; i < 2; i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsStringArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "array")
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["zip"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			lists, ok := args[0].([]interface{})
 			if !ok {
@@ -125,6 +167,7 @@ func registerObjectFunc() {
 	}
 	builtins["items"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			m, ok := args[0].(map[string]interface{})
 			if !ok {
@@ -144,6 +187,7 @@ func registerObjectFunc() {
 	}
 	builtins["object_concat"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			res := make(map[string]interface{})
 			for i, arg := range args {
@@ -166,15 +210,13 @@ func registerObjectFunc() {
 	}
 	builtins["object_construct"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			result := make(map[string]interface{})
 			for i := 0; i < len(args); i += 2 {
+				// the later value overrides the former one for duplicate keys
 				if args[i] != nil {
-					s, err := cast.ToString(args[i], cast.CONVERT_SAMEKIND)
-					if err != nil {
-						return fmt.Errorf("key %v is not a string", args[i]), false
-					}
-					result[s] = args[i+1]
+					result[cast.ToStringAlways(args[i])] = args[i+1]
 				}
 			}
 			return result, true
@@ -183,18 +225,12 @@ func registerObjectFunc() {
 			if len(args)%2 != 0 {
 				return fmt.Errorf("the args must be key value pairs")
 			}
-			for i, arg := range args {
-				if i%2 == 0 {
-					if ast.IsNumericArg(arg) || ast.IsTimeArg(arg) || ast.IsBooleanArg(arg) {
-						return ProduceErrInfo(i, "string")
-					}
-				}
-			}
 			return nil
 		},
 	}
 	builtins["erase"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if len(args) != 2 {
 				return fmt.Errorf("the argument number should be 2, got %v", len(args)), false
@@ -239,6 +275,7 @@ func registerObjectFunc() {
 	}
 	builtins["object_size"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return 0, true
@@ -256,6 +293,7 @@ func registerObjectFunc() {
 	// The argument can be {obj}, {string arr} OR {obj}, {string}...
 	builtins["object_pick"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil {
 				return nil, true
@@ -296,6 +334,7 @@ func registerObjectFunc() {
 	}
 	builtins["obj_to_kvpair_array"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			obj, ok := args[0].(map[string]interface{})
 			if !ok {
@@ -314,6 +353,365 @@ func registerObjectFunc() {
 		val:   ValidateOneArg,
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["map_keys"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			mv := reflect.ValueOf(args[0])
+			if mv.Kind() != reflect.Map {
+				return []interface{}{}, true
+			}
+			keys := sortedMapKeys(mv)
+			result := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				result = append(result, k.Interface())
+			}
+			return result, true
+		},
+		val:   ValidateOneArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["map_values"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			mv := reflect.ValueOf(args[0])
+			if mv.Kind() != reflect.Map {
+				return []interface{}{}, true
+			}
+			keys := sortedMapKeys(mv)
+			result := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				result = append(result, mv.MapIndex(k).Interface())
+			}
+			return result, true
+		},
+		val:   ValidateOneArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["map_get"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			var dftVal interface{}
+			if len(args) == 3 {
+				dftVal = args[2]
+			}
+			if m, ok := args[0].(map[string]interface{}); ok {
+				if v, ok := m[cast.ToStringAlways(args[1])]; ok {
+					return v, true
+				}
+				return dftVal, true
+			}
+			mv := reflect.ValueOf(args[0])
+			if mv.Kind() != reflect.Map {
+				return dftVal, true
+			}
+			key := cast.ToStringAlways(args[1])
+			iter := mv.MapRange()
+			for iter.Next() {
+				if cast.ToStringAlways(iter.Key().Interface()) == key {
+					return iter.Value().Interface(), true
+				}
+			}
+			return dftVal, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 2 && len(args) != 3 {
+				return fmt.Errorf("the arguments for map_get should be 2 or 3")
+			}
+			return nil
+		},
+	}
+	builtins["flatten"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			m, ok := args[0].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("the first argument should be a map, got %v", args[0]), false
+			}
+			sep := "."
+			if len(args) > 1 {
+				sep, ok = args[1].(string)
+				if !ok || sep == "" {
+					return fmt.Errorf("the separator should be a non-empty string, got %v", args[1]), false
+				}
+			}
+			mode := flattenArrayIndex
+			if len(args) > 2 {
+				mode, ok = args[2].(string)
+				if !ok || !isFlattenArrayMode(mode) {
+					return fmt.Errorf("the array mode should be one of index, bracket and none, got %v", args[2]), false
+				}
+			}
+			result := make(map[string]interface{}, len(m))
+			flattenValue(result, "", m, sep, mode)
+			return result, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) < 1 || len(args) > 3 {
+				return fmt.Errorf("Expect 1 to 3 arguments but found %d.", len(args))
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) || ast.IsStringArg(args[0]) {
+				return ProduceErrInfo(0, "map")
+			}
+			if len(args) > 1 && (ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1])) {
+				return ProduceErrInfo(1, "string")
+			}
+			if len(args) > 2 {
+				if ast.IsNumericArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsBooleanArg(args[2]) {
+					return ProduceErrInfo(2, "string")
+				}
+				if s, ok := args[2].(*ast.StringLiteral); ok && !isFlattenArrayMode(s.Val) {
+					return fmt.Errorf("the array mode should be one of index, bracket and none, got %s", s.Val)
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["unflatten"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			m, ok := args[0].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("the first argument should be a map, got %v", args[0]), false
+			}
+			sep := "."
+			if len(args) > 1 {
+				sep, ok = args[1].(string)
+				if !ok || sep == "" {
+					return fmt.Errorf("the separator should be a non-empty string, got %v", args[1]), false
+				}
+			}
+			mode := flattenArrayNone
+			if len(args) > 2 {
+				mode, ok = args[2].(string)
+				if !ok || !isFlattenArrayMode(mode) {
+					return fmt.Errorf("the array mode should be one of index, bracket and none, got %v", args[2]), false
+				}
+			}
+			r, err := unflatten(m, sep, mode)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val:   builtins["flatten"].val,
+		check: returnNilIfHasAnyNil,
+	}
+}
+
+// sortedMapKeys returns the keys of the map value sorted by their string representation
+func sortedMapKeys(mv reflect.Value) []reflect.Value {
+	keys := mv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return cast.ToStringAlways(keys[i].Interface()) < cast.ToStringAlways(keys[j].Interface())
+	})
+	return keys
+}
+
+const (
+	flattenArrayIndex   = "index"
+	flattenArrayBracket = "bracket"
+	flattenArrayNone    = "none"
+)
+
+func isFlattenArrayMode(mode string) bool {
+	return mode == flattenArrayIndex || mode == flattenArrayBracket || mode == flattenArrayNone
+}
+
+// flattenValue puts the leaf values of v into result with the keys joined by sep. Empty maps and arrays are kept as
+// values so that no key is lost.
+func flattenValue(result map[string]interface{}, prefix string, v interface{}, sep string, mode string) {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		if len(vt) == 0 && prefix != "" {
+			result[prefix] = vt
+			return
+		}
+		for k, cv := range vt {
+			key := k
+			if prefix != "" {
+				key = prefix + sep + k
+			}
+			flattenValue(result, key, cv, sep, mode)
+		}
+	case []interface{}:
+		if mode == flattenArrayNone || len(vt) == 0 {
+			result[prefix] = vt
+			return
+		}
+		for i, cv := range vt {
+			var key string
+			if mode == flattenArrayBracket {
+				key = prefix + "[" + strconv.Itoa(i) + "]"
+			} else {
+				key = prefix + sep + strconv.Itoa(i)
+			}
+			flattenValue(result, key, cv, sep, mode)
+		}
+	default:
+		result[prefix] = v
+	}
+}
+
+// unflattenSeg is a segment of a flattened key. It is an array index if isIndex is true.
+type unflattenSeg struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+type unflattenNode struct {
+	// key is the flattened key which creates the node, used to report the conflicts
+	key string
+	// path is the flattened prefix of the node
+	path     string
+	leaf     bool
+	value    interface{}
+	children map[unflattenSeg]*unflattenNode
+}
+
+// unflatten expands the flattened keys of m into nested maps. It is the reverse of flattenValue.
+func unflatten(m map[string]interface{}, sep string, mode string) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	// Sort to report the conflicts deterministically
+	sort.Strings(keys)
+	root := &unflattenNode{children: make(map[unflattenSeg]*unflattenNode)}
+	for _, k := range keys {
+		node := root
+		path := ""
+		for _, seg := range splitFlattenKey(k, sep, mode) {
+			if node.leaf {
+				return nil, fmt.Errorf("key %s conflicts with key %s", k, node.key)
+			}
+			switch {
+			case path == "":
+				path = seg.name
+			case seg.isIndex && mode == flattenArrayBracket:
+				path += "[" + seg.name + "]"
+			default:
+				path += sep + seg.name
+			}
+			if node.children == nil {
+				node.children = make(map[unflattenSeg]*unflattenNode)
+			}
+			child, ok := node.children[seg]
+			if !ok {
+				child = &unflattenNode{key: k, path: path}
+				node.children[seg] = child
+			}
+			node = child
+		}
+		if node.leaf || len(node.children) > 0 {
+			return nil, fmt.Errorf("key %s conflicts with key %s", k, node.key)
+		}
+		node.leaf = true
+		node.key = k
+		node.value = m[k]
+	}
+	// The root is always a map even if the keys are numeric
+	result := make(map[string]interface{}, len(root.children))
+	for seg, child := range root.children {
+		v, err := child.build(mode)
+		if err != nil {
+			return nil, err
+		}
+		result[seg.name] = v
+	}
+	return result, nil
+}
+
+func (n *unflattenNode) build(mode string) (interface{}, error) {
+	if n.leaf {
+		return n.value, nil
+	}
+	isArray, isMap := false, false
+	for seg := range n.children {
+		if seg.isIndex {
+			isArray = true
+		} else {
+			isMap = true
+		}
+	}
+	if isArray && !isMap {
+		result := make([]interface{}, len(n.children))
+		for seg, child := range n.children {
+			if seg.index >= len(result) {
+				return nil, fmt.Errorf("the array indexes of %s are not contiguous", n.path)
+			}
+			v, err := child.build(mode)
+			if err != nil {
+				return nil, err
+			}
+			result[seg.index] = v
+		}
+		return result, nil
+	}
+	if isArray && mode == flattenArrayBracket {
+		return nil, fmt.Errorf("%s has both array indexes and keys", n.path)
+	}
+	result := make(map[string]interface{}, len(n.children))
+	for seg, child := range n.children {
+		v, err := child.build(mode)
+		if err != nil {
+			return nil, err
+		}
+		result[seg.name] = v
+	}
+	return result, nil
+}
+
+// splitFlattenKey splits the key into segments. In index mode, the numeric segments are array indexes. In bracket
+// mode, the [i] suffixes of a segment are array indexes.
+func splitFlattenKey(key string, sep string, mode string) []unflattenSeg {
+	parts := strings.Split(key, sep)
+	result := make([]unflattenSeg, 0, len(parts))
+	for _, p := range parts {
+		switch mode {
+		case flattenArrayIndex:
+			if i, ok := parseArrayIndex(p); ok {
+				result = append(result, unflattenSeg{name: p, index: i, isIndex: true})
+				continue
+			}
+		case flattenArrayBracket:
+			if segs, ok := splitBracketSeg(p); ok {
+				result = append(result, segs...)
+				continue
+			}
+		}
+		result = append(result, unflattenSeg{name: p})
+	}
+	return result
+}
+
+// splitBracketSeg splits a segment like a[0][1] into the name and the indexes
+func splitBracketSeg(p string) ([]unflattenSeg, bool) {
+	start := strings.IndexByte(p, '[')
+	if start <= 0 || !strings.HasSuffix(p, "]") {
+		return nil, false
+	}
+	result := []unflattenSeg{{name: p[:start]}}
+	for _, s := range strings.Split(p[start+1:len(p)-1], "][") {
+		i, ok := parseArrayIndex(s)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, unflattenSeg{name: s, index: i, isIndex: true})
+	}
+	return result, true
+}
+
+// parseArrayIndex only accepts the canonical form of a non-negative integer, so that 0 and 00 are not the same index
+func parseArrayIndex(s string) (int, bool) {
+	i, err := strconv.Atoi(s)
+	return i, err == nil && i >= 0 && strconv.Itoa(i) == s
 }
 
 func pick(ctx api.FunctionContext, res map[string]any, argMap map[string]any, k string) {
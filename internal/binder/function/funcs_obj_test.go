@@ -55,8 +55,13 @@ func TestToMap(t *testing.T) {
 			args: []interface{}{
 				true,
 				"bar",
+				1,
+				2.5,
+			},
+			result: map[string]interface{}{
+				"true": "bar",
+				"1":    2.5,
 			},
-			result: fmt.Errorf("key true is not a string"),
 		}, { // 2
 			args: []interface{}{
 				"key1",
@@ -82,6 +87,16 @@ func TestToMap(t *testing.T) {
 				"key2": "foo",
 				"key3": nil,
 			},
+		}, { // 4
+			args: []interface{}{
+				"key1",
+				"bar",
+				"key1",
+				"foo",
+			},
+			result: map[string]interface{}{
+				"key1": "foo",
+			},
 		},
 	}
 	for i, tt := range tests {
@@ -123,7 +138,6 @@ func TestToMapVal(t *testing.T) {
 				&ast.BooleanLiteral{Val: true},
 				&ast.StringLiteral{Val: "baz"},
 			},
-			err: fmt.Errorf("Expect string type for parameter 1"),
 		},
 	}
 	for i, tt := range tests {
@@ -527,6 +541,152 @@ func TestObjectFunctions(t *testing.T) {
 	}
 }
 
+func TestMapFunctions(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	m := map[string]interface{}{
+		"c": 3,
+		"a": map[string]interface{}{"x": 1},
+		"b": "2",
+	}
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "map_keys",
+			args:   []interface{}{m},
+			result: []interface{}{"a", "b", "c"},
+		},
+		{
+			name:   "map_keys",
+			args:   []interface{}{map[int]string{2: "b", 1: "a"}},
+			result: []interface{}{1, 2},
+		},
+		{
+			name:   "map_keys",
+			args:   []interface{}{"foo"},
+			result: []interface{}{},
+		},
+		{
+			name:   "map_values",
+			args:   []interface{}{m},
+			result: []interface{}{map[string]interface{}{"x": 1}, "2", 3},
+		},
+		{
+			name:   "map_values",
+			args:   []interface{}{[]interface{}{1, 2}},
+			result: []interface{}{},
+		},
+		{
+			name:   "map_get",
+			args:   []interface{}{m, "b", "dft"},
+			result: "2",
+		},
+		{
+			name:   "map_get",
+			args:   []interface{}{m, "d", "dft"},
+			result: "dft",
+		},
+		{
+			name:   "map_get",
+			args:   []interface{}{m, "d"},
+			result: nil,
+		},
+		{
+			name:   "map_get",
+			args:   []interface{}{map[int]string{1: "a"}, 1, "dft"},
+			result: "a",
+		},
+		{
+			name:   "map_get",
+			args:   []interface{}{"foo", "a", "dft"},
+			result: "dft",
+		},
+		{
+			name:   "map_get",
+			args:   []interface{}{nil, "a", "dft"},
+			result: "dft",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, tt.name), func(t *testing.T) {
+			f, ok := builtins[tt.name]
+			require.True(t, ok)
+			result, ok := f.exec(fctx, tt.args)
+			require.True(t, ok)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestToMapFromArrays(t *testing.T) {
+	f, ok := builtins["to_map"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name:   "same length",
+			args:   []interface{}{[]interface{}{"a", "b"}, []interface{}{1, "2"}},
+			result: map[string]interface{}{"a": 1, "b": "2"},
+		},
+		{
+			name:   "shorter values",
+			args:   []interface{}{[]interface{}{"a", "b", "c"}, []interface{}{1}},
+			result: map[string]interface{}{"a": 1},
+		},
+		{
+			name:   "shorter keys",
+			args:   []interface{}{[]interface{}{"a"}, []interface{}{1, 2}},
+			result: map[string]interface{}{"a": 1},
+		},
+		{
+			name:   "stringify keys",
+			args:   []interface{}{[]interface{}{1, true}, []interface{}{"x", "y"}},
+			result: map[string]interface{}{"1": "x", "true": "y"},
+		},
+		{
+			name:   "duplicate keys",
+			args:   []interface{}{[]interface{}{"a", "a"}, []interface{}{1, 2}},
+			result: map[string]interface{}{"a": 2},
+		},
+		{
+			name:   "empty",
+			args:   []interface{}{[]interface{}{}, []interface{}{1}},
+			result: map[string]interface{}{},
+		},
+		{
+			name:   "invalid keys",
+			args:   []interface{}{"a", []interface{}{1}},
+			result: fmt.Errorf("first argument should be an array but got a"),
+		},
+		{
+			name:   "invalid values",
+			args:   []interface{}{[]interface{}{"a"}, 1},
+			result: fmt.Errorf("second argument should be an array but got 1"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 arguments but found 1.")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}}), "Expect array type for parameter 2")
+}
+
 // pick with split 56.25 ns/op, split 35 ns/op
 // pick raw 15.57 ns/op
 // pick with contain 22 ns/op
@@ -812,6 +972,22 @@ func TestVal(t *testing.T) {
 				&ast.StringLiteral{Val: "bar"},
 			},
 			err: fmt.Errorf("Expect 1 arguments but found 2."),
+		}, {
+			name: "map_keys",
+			args: []ast.Expr{},
+			err:  fmt.Errorf("Expect 1 arguments but found 0."),
+		}, {
+			name: "map_get",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+			},
+			err: fmt.Errorf("the arguments for map_get should be 2 or 3"),
+		}, {
+			name: "map_get",
+			args: []ast.Expr{
+				&ast.FieldRef{Name: "foo"},
+				&ast.StringLiteral{Val: "bar"},
+			},
 		},
 	}
 	for i, tt := range tests {
@@ -823,3 +999,215 @@ func TestVal(t *testing.T) {
 		})
 	}
 }
+
+func TestFlatten(t *testing.T) {
+	f, ok := builtins["flatten"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	input := map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}, "d": "x"},
+		"e": []interface{}{10, map[string]interface{}{"f": true}},
+		"g": map[string]interface{}{},
+		"h": []interface{}{},
+		"i": nil,
+	}
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name: "default",
+			args: []interface{}{input},
+			result: map[string]interface{}{
+				"a.b.c": 1, "a.d": "x", "e.0": 10, "e.1.f": true, "g": map[string]interface{}{}, "h": []interface{}{}, "i": nil,
+			},
+		},
+		{
+			name: "separator",
+			args: []interface{}{input, "_"},
+			result: map[string]interface{}{
+				"a_b_c": 1, "a_d": "x", "e_0": 10, "e_1_f": true, "g": map[string]interface{}{}, "h": []interface{}{}, "i": nil,
+			},
+		},
+		{
+			name: "bracket",
+			args: []interface{}{input, ".", "bracket"},
+			result: map[string]interface{}{
+				"a.b.c": 1, "a.d": "x", "e[0]": 10, "e[1].f": true, "g": map[string]interface{}{}, "h": []interface{}{}, "i": nil,
+			},
+		},
+		{
+			name: "none",
+			args: []interface{}{input, ".", "none"},
+			result: map[string]interface{}{
+				"a.b.c": 1, "a.d": "x", "e": []interface{}{10, map[string]interface{}{"f": true}}, "g": map[string]interface{}{}, "h": []interface{}{}, "i": nil,
+			},
+		},
+		{
+			name:   "not map",
+			args:   []interface{}{"a"},
+			result: fmt.Errorf("the first argument should be a map, got a"),
+		},
+		{
+			name:   "empty separator",
+			args:   []interface{}{input, ""},
+			result: fmt.Errorf("the separator should be a non-empty string, got "),
+		},
+		{
+			name:   "invalid mode",
+			args:   []interface{}{input, ".", "dot"},
+			result: fmt.Errorf("the array mode should be one of index, bracket and none, got dot"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, r)
+		})
+	}
+}
+
+func TestFlattenValidation(t *testing.T) {
+	f, ok := builtins["flatten"]
+	require.True(t, ok)
+	tests := []struct {
+		args []ast.Expr
+		err  string
+	}{
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}}},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "_"}, &ast.StringLiteral{Val: "bracket"}}},
+		{args: []ast.Expr{}, err: "Expect 1 to 3 arguments but found 0."},
+		{args: []ast.Expr{&ast.StringLiteral{Val: "a"}}, err: "Expect map type for parameter 1"},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}, err: "Expect string type for parameter 2"},
+		{args: []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "_"}, &ast.StringLiteral{Val: "dot"}}, err: "the array mode should be one of index, bracket and none, got dot"},
+	}
+	for i, tt := range tests {
+		err := f.val(nil, tt.args)
+		if tt.err == "" {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.EqualError(t, err, tt.err, "case %d", i)
+		}
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	f, ok := builtins["unflatten"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{
+			name: "default",
+			args: []interface{}{map[string]interface{}{"a.b.c": 1, "a.d": "x", "e.0": 10, "f": nil}},
+			result: map[string]interface{}{
+				"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}, "d": "x"},
+				"e": map[string]interface{}{"0": 10},
+				"f": nil,
+			},
+		},
+		{
+			name: "separator",
+			args: []interface{}{map[string]interface{}{"a_b": 1, "a.c": 2}, "_"},
+			result: map[string]interface{}{
+				"a":   map[string]interface{}{"b": 1},
+				"a.c": 2,
+			},
+		},
+		{
+			name: "index",
+			args: []interface{}{map[string]interface{}{"e.0": 10, "e.1.f": true, "g.0": 1, "g.x": 2, "1": "top"}, ".", "index"},
+			result: map[string]interface{}{
+				"e": []interface{}{10, map[string]interface{}{"f": true}},
+				"g": map[string]interface{}{"0": 1, "x": 2},
+				"1": "top",
+			},
+		},
+		{
+			name: "bracket",
+			args: []interface{}{map[string]interface{}{"e[0]": 10, "e[1].f": true, "m[0][1]": 2, "m[0][0]": 1, "a.0": 3}, ".", "bracket"},
+			result: map[string]interface{}{
+				"e": []interface{}{10, map[string]interface{}{"f": true}},
+				"m": []interface{}{[]interface{}{1, 2}},
+				"a": map[string]interface{}{"0": 3},
+			},
+		},
+		{
+			name:   "conflict scalar and object",
+			args:   []interface{}{map[string]interface{}{"a": 1, "a.b": 2}},
+			result: fmt.Errorf("key a.b conflicts with key a"),
+		},
+		{
+			name:   "conflict with separator",
+			args:   []interface{}{map[string]interface{}{"a_b": 1, "a": 2}, "_"},
+			result: fmt.Errorf("key a_b conflicts with key a"),
+		},
+		{
+			name:   "not contiguous",
+			args:   []interface{}{map[string]interface{}{"a.0": 1, "a.2": 2}, ".", "index"},
+			result: fmt.Errorf("the array indexes of a are not contiguous"),
+		},
+		{
+			name: "non canonical index",
+			args: []interface{}{map[string]interface{}{"a.0": 1, "a.00": 2, "b.01": 3, "c[0]": 4, "c[00]": 5}, ".", "index"},
+			result: map[string]interface{}{
+				"a":     map[string]interface{}{"0": 1, "00": 2},
+				"b":     map[string]interface{}{"01": 3},
+				"c[0]":  4,
+				"c[00]": 5,
+			},
+		},
+		{
+			name:   "non canonical bracket",
+			args:   []interface{}{map[string]interface{}{"a[0]": 1, "a[00]": 2}, ".", "bracket"},
+			result: map[string]interface{}{"a": []interface{}{1}, "a[00]": 2},
+		},
+		{
+			name:   "mixed bracket",
+			args:   []interface{}{map[string]interface{}{"a[0]": 1, "a.b": 2}, ".", "bracket"},
+			result: fmt.Errorf("a has both array indexes and keys"),
+		},
+		{
+			name:   "not map",
+			args:   []interface{}{"a"},
+			result: fmt.Errorf("the first argument should be a map, got a"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := f.exec(fctx, tt.args)
+			require.Equal(t, tt.result, r)
+		})
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	input := map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}, "d": "x"},
+		"e": []interface{}{10, nil, map[string]interface{}{"f": []interface{}{true}}},
+		"g": map[string]interface{}{},
+	}
+	for _, mode := range []string{"index", "bracket", "none"} {
+		t.Run(mode, func(t *testing.T) {
+			flat, ok := builtins["flatten"].exec(fctx, []interface{}{input, "/", mode})
+			require.True(t, ok)
+			r, ok := builtins["unflatten"].exec(fctx, []interface{}{flat, "/", mode})
+			require.True(t, ok)
+			require.Equal(t, input, r)
+		})
+	}
+}
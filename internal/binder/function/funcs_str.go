@@ -16,14 +16,24 @@ package function
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
+	"math"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 	"golang.org/x/text/number"
@@ -35,6 +45,7 @@ import (
 func registerStrFunc() {
 	builtins["concat"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			var b bytes.Buffer
 			for _, arg := range args {
@@ -56,6 +67,7 @@ func registerStrFunc() {
 	}
 	builtins["endswith"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
 			return strings.HasSuffix(arg0, arg1), true
@@ -65,6 +77,7 @@ func registerStrFunc() {
 	}
 	builtins["indexof"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			if args[0] == nil || args[1] == nil {
 				return -1, true
@@ -76,6 +89,7 @@ func registerStrFunc() {
 	}
 	builtins["length"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			switch v := args[0].(type) {
@@ -94,6 +108,7 @@ func registerStrFunc() {
 	}
 	builtins["lower"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			return strings.ToLower(arg0), true
@@ -103,6 +118,7 @@ func registerStrFunc() {
 	}
 	builtins["lpad"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			arg1, err := cast.ToInt(args[1], cast.STRICT)
@@ -116,6 +132,7 @@ func registerStrFunc() {
 	}
 	builtins["ltrim"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			return strings.TrimLeftFunc(arg0, unicode.IsSpace), true
@@ -125,6 +142,7 @@ func registerStrFunc() {
 	}
 	builtins["numbytes"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			return len(arg0), true
@@ -132,8 +150,41 @@ func registerStrFunc() {
 		val:   ValidateOneStrArg,
 		check: return0IfHasAnyNil,
 	}
+	builtins["bytes_length"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			switch v := args[0].(type) {
+			case string:
+				return len(v), true
+			case []byte:
+				return len(v), true
+			default:
+				return fmt.Errorf("bytes_length requires string or bytea parameter but got %[1]T(%[1]v)", v), false
+			}
+		},
+		val:   ValidateOneStrArg,
+		check: return0IfHasAnyNil,
+	}
+	builtins["char_length"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			switch v := args[0].(type) {
+			case string:
+				return utf8.RuneCountInString(v), true
+			case []byte:
+				return fmt.Errorf("char_length does not support bytea, use bytes_length instead"), false
+			default:
+				return fmt.Errorf("char_length requires string parameter but got %[1]T(%[1]v)", v), false
+			}
+		},
+		val:   ValidateOneStrArg,
+		check: return0IfHasAnyNil,
+	}
 	builtins["regexp_matches"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
 			if matched, err := regexp.MatchString(arg1, arg0); err != nil {
@@ -147,6 +198,7 @@ func registerStrFunc() {
 	}
 	builtins["regexp_replace"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0, arg1, arg2 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1]), cast.ToStringAlways(args[2])
 			if re, err := regexp.Compile(arg1); err != nil {
@@ -170,6 +222,7 @@ func registerStrFunc() {
 	}
 	builtins["regexp_substr"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
 			if re, err := regexp.Compile(arg1); err != nil {
@@ -181,8 +234,67 @@ func registerStrFunc() {
 		val:   ValidateTwoStrArg,
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["regexp_extract"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
+			group, err := cast.ToInt(args[2], cast.STRICT)
+			if err != nil {
+				return fmt.Errorf("the group index must be an int but got %v", args[2]), false
+			}
+			if len(args) == 4 {
+				flags := cast.ToStringAlways(args[3])
+				if err := validateRegexpFlags(flags); err != nil {
+					return err, false
+				}
+				if flags != "" {
+					arg1 = "(?" + flags + ")" + arg1
+				}
+			}
+			re, err := regexp.Compile(arg1)
+			if err != nil {
+				return err, false
+			}
+			if group < 0 || group > re.NumSubexp() {
+				return fmt.Errorf("the group index %d is out of range, the pattern has %d groups", group, re.NumSubexp()), false
+			}
+			matches := re.FindStringSubmatch(arg0)
+			if matches == nil {
+				return "", true
+			}
+			return matches[group], true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if len(args) != 3 && len(args) != 4 {
+				return fmt.Errorf("the arguments for regexp_extract should be 3 or 4")
+			}
+			for i := 0; i < 2; i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "string")
+				}
+			}
+			if ast.IsFloatArg(args[2]) || ast.IsStringArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsBooleanArg(args[2]) {
+				return ProduceErrInfo(2, "int")
+			}
+			if g, ok := args[2].(*ast.IntegerLiteral); ok && g.Val < 0 {
+				return fmt.Errorf("the group index should not be a negative integer")
+			}
+			if len(args) == 4 {
+				if ast.IsNumericArg(args[3]) || ast.IsTimeArg(args[3]) || ast.IsBooleanArg(args[3]) {
+					return ProduceErrInfo(3, "string")
+				}
+				if f, ok := args[3].(*ast.StringLiteral); ok {
+					return validateRegexpFlags(f.Val)
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["reverse"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			runes := []rune(arg0)
@@ -196,6 +308,7 @@ func registerStrFunc() {
 	}
 	builtins["rpad"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			arg1, err := cast.ToInt(args[1], cast.STRICT)
@@ -209,6 +322,7 @@ func registerStrFunc() {
 	}
 	builtins["rtrim"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			return strings.TrimRightFunc(arg0, unicode.IsSpace), true
@@ -218,6 +332,7 @@ func registerStrFunc() {
 	}
 	builtins["substring"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			arg1, err := cast.ToInt(args[1], cast.STRICT)
@@ -284,8 +399,107 @@ func registerStrFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["substring_index"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			str, delim := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
+			count, err := cast.ToInt(args[2], cast.STRICT)
+			if err != nil {
+				return err, false
+			}
+			return substringIndex(str, delim, count), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(3, len(args)); err != nil {
+				return err
+			}
+			for i := 0; i < 2; i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "string")
+				}
+			}
+			if ast.IsFloatArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsBooleanArg(args[2]) || ast.IsStringArg(args[2]) {
+				return ProduceErrInfo(2, "int")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["mask"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			str := cast.ToStringAlways(args[0])
+			if len(args) == 2 {
+				r, err := maskByMode(str, cast.ToStringAlways(args[1]))
+				if err != nil {
+					return err, false
+				}
+				return r, true
+			}
+			start, err := cast.ToInt(args[1], cast.STRICT)
+			if err != nil {
+				return err, false
+			}
+			end, err := cast.ToInt(args[2], cast.STRICT)
+			if err != nil {
+				return err, false
+			}
+			if start < 0 || end < start {
+				return fmt.Errorf("invalid mask range [%d, %d)", start, end), false
+			}
+			c := []rune(cast.ToStringAlways(args[3]))
+			if len(c) != 1 {
+				return fmt.Errorf("the mask char must be a single character but got %s", string(c)), false
+			}
+			return maskRange(str, start, end, c[0]), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			l := len(args)
+			if l != 2 && l != 4 {
+				return fmt.Errorf("Expect two or four arguments but found %d.", l)
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			if l == 2 {
+				if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) {
+					return ProduceErrInfo(1, "string")
+				}
+				if m, ok := args[1].(*ast.StringLiteral); ok {
+					if _, err := maskByMode("", m.Val); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			for i := 1; i < 3; i++ {
+				if ast.IsFloatArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) || ast.IsStringArg(args[i]) {
+					return ProduceErrInfo(i, "int")
+				}
+			}
+			if ast.IsNumericArg(args[3]) || ast.IsTimeArg(args[3]) || ast.IsBooleanArg(args[3]) {
+				return ProduceErrInfo(3, "string")
+			}
+			if s, ok := args[1].(*ast.IntegerLiteral); ok {
+				if s.Val < 0 {
+					return fmt.Errorf("The start index should not be a nagtive integer.")
+				}
+				if e, ok := args[2].(*ast.IntegerLiteral); ok && e.Val < s.Val {
+					return fmt.Errorf("The end index should be larger than start index.")
+				}
+			}
+			if c, ok := args[3].(*ast.StringLiteral); ok && utf8.RuneCountInString(c.Val) != 1 {
+				return fmt.Errorf("the mask char must be a single character but got %s", c.Val)
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["startswith"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
 			return strings.HasPrefix(arg0, arg1), true
@@ -293,8 +507,129 @@ func registerStrFunc() {
 		val:   ValidateTwoStrArg,
 		check: returnFalseIfHasAnyNil,
 	}
+	builtins["starts_with"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
+			return strings.HasPrefix(arg0, arg1), true
+		},
+		val:   ValidateTwoStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["ends_with"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
+			return strings.HasSuffix(arg0, arg1), true
+		},
+		val:   ValidateTwoStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["contains"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
+			return strings.Contains(arg0, arg1), true
+		},
+		val:   ValidateTwoStrArg,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["levenshtein"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
+			if len(args) > 2 {
+				ignoreCase, err := cast.ToBool(args[2], cast.STRICT)
+				if err != nil {
+					return err, false
+				}
+				if ignoreCase {
+					arg0, arg1 = strings.ToLower(arg0), strings.ToLower(arg1)
+				}
+			}
+			return levenshtein([]rune(arg0), []rune(arg1)), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			l := len(args)
+			if l != 2 && l != 3 {
+				return fmt.Errorf("the arguments for levenshtein should be 2 or 3")
+			}
+			for i := 0; i < 2; i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "string")
+				}
+			}
+			if l == 3 && (ast.IsNumericArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsStringArg(args[2])) {
+				return ProduceErrInfo(2, "bool")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["interpolate"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			tmpl, ok := args[0].(string)
+			if !ok {
+				return fmt.Errorf("the template should be a string but got %v", args[0]), false
+			}
+			var lookup func(string) (interface{}, bool)
+			switch vals := args[1].(type) {
+			case map[string]interface{}:
+				lookup = func(name string) (interface{}, bool) {
+					v, ok := vals[name]
+					return v, ok
+				}
+			case []interface{}:
+				lookup = func(name string) (interface{}, bool) {
+					i, err := strconv.Atoi(name)
+					if err != nil || i < 0 || i >= len(vals) {
+						return nil, false
+					}
+					return vals[i], true
+				}
+			default:
+				return fmt.Errorf("the values should be a map or an array but got %v", args[1]), false
+			}
+			strict := false
+			if len(args) > 2 {
+				strict, ok = args[2].(bool)
+				if !ok {
+					return fmt.Errorf("the strict arg should be a bool but got %v", args[2]), false
+				}
+			}
+			r, err := interpolate(tmpl, lookup, strict)
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			l := len(args)
+			if l != 2 && l != 3 {
+				return fmt.Errorf("the arguments for interpolate should be 2 or 3")
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "string")
+			}
+			if ast.IsNumericArg(args[1]) || ast.IsTimeArg(args[1]) || ast.IsBooleanArg(args[1]) || ast.IsStringArg(args[1]) {
+				return ProduceErrInfo(1, "map or array")
+			}
+			if l == 3 && (ast.IsNumericArg(args[2]) || ast.IsTimeArg(args[2]) || ast.IsStringArg(args[2])) {
+				return ProduceErrInfo(2, "bool")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
 	builtins["split_value"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0, arg1 := cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1])
 			ss := strings.Split(arg0, arg1)
@@ -341,6 +676,7 @@ func registerStrFunc() {
 	}
 	builtins["trim"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			return strings.TrimSpace(arg0), true
@@ -350,6 +686,7 @@ func registerStrFunc() {
 	}
 	builtins["upper"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			arg0 := cast.ToStringAlways(args[0])
 			return strings.ToUpper(arg0), true
@@ -359,6 +696,7 @@ func registerStrFunc() {
 	}
 	builtins["format"] = builtinFunc{
 		fType: ast.FuncTypeScalar,
+		pure:  true,
 		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
 			var v1 float64
 			var v2 int
@@ -400,4 +738,369 @@ func registerStrFunc() {
 		},
 		check: returnNilIfHasAnyNil,
 	}
+	builtins["escape"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			r, err := escapeString(cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1]))
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val:   validateEscapeArgs,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["unescape"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			r, err := unescapeString(cast.ToStringAlways(args[0]), cast.ToStringAlways(args[1]))
+			if err != nil {
+				return err, false
+			}
+			return r, true
+		},
+		val:   validateEscapeArgs,
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["transcode"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			b, err := cast.ToBytes(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			r, isStr, err := transcode(b, cast.ToStringAlways(args[1]), cast.ToStringAlways(args[2]))
+			if err != nil {
+				return err, false
+			}
+			if isStr {
+				return string(r), true
+			}
+			return r, true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(3, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "bytea")
+			}
+			for i := 1; i < 3; i++ {
+				if ast.IsNumericArg(args[i]) || ast.IsTimeArg(args[i]) || ast.IsBooleanArg(args[i]) {
+					return ProduceErrInfo(i, "string")
+				}
+				if s, ok := args[i].(*ast.StringLiteral); ok {
+					if _, err := getCharset(s.Val); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+	builtins["detect_charset"] = builtinFunc{
+		fType: ast.FuncTypeScalar,
+		pure:  true,
+		exec: func(ctx api.FunctionContext, args []interface{}) (interface{}, bool) {
+			b, err := cast.ToBytes(args[0], cast.CONVERT_SAMEKIND)
+			if err != nil {
+				return err, false
+			}
+			return detectCharset(b), true
+		},
+		val: func(_ api.FunctionContext, args []ast.Expr) error {
+			if err := ValidateLen(1, len(args)); err != nil {
+				return err
+			}
+			if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+				return ProduceErrInfo(0, "bytea")
+			}
+			return nil
+		},
+		check: returnNilIfHasAnyNil,
+	}
+}
+
+// levenshtein returns the edit distance between two rune slices. It takes O(n*m) time
+// and O(min(n,m)) memory, so avoid comparing very long strings in a hot path.
+func levenshtein(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(b) == 0 {
+		return len(a)
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := prev[j] + 1
+			if cur[j-1]+1 < d {
+				d = cur[j-1] + 1
+			}
+			if prev[j-1]+cost < d {
+				d = prev[j-1] + cost
+			}
+			cur[j] = d
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// interpolate replaces the {name} placeholders in the template with the stringified values found by lookup.
+// Use {{ and }} to write literal braces. Unresolved placeholders are kept as is unless strict is set.
+func interpolate(tmpl string, lookup func(string) (interface{}, bool), strict bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		switch {
+		case c == '{' && i+1 < len(tmpl) && tmpl[i+1] == '{':
+			b.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(tmpl) && tmpl[i+1] == '}':
+			b.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexAny(tmpl[i+1:], "{}")
+			if end < 0 || tmpl[i+1+end] != '}' {
+				if strict {
+					return "", fmt.Errorf("unclosed placeholder at position %d", i)
+				}
+				b.WriteByte(c)
+				continue
+			}
+			name := tmpl[i+1 : i+1+end]
+			if v, ok := lookup(name); ok {
+				b.WriteString(cast.ToStringAlways(v))
+			} else if strict {
+				return "", fmt.Errorf("unresolved placeholder {%s}", name)
+			} else {
+				b.WriteString(tmpl[i : i+2+end])
+			}
+			i += 1 + end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// validateRegexpFlags checks the flags are supported by the Go regexp syntax
+func validateRegexpFlags(flags string) error {
+	for _, f := range flags {
+		switch f {
+		case 'i', 'm', 's', 'U':
+		default:
+			return fmt.Errorf("unsupported regexp flag %c, expect i, m, s or U", f)
+		}
+	}
+	return nil
+}
+
+// substringIndex returns the substring before the count-th occurrence of delim like MySQL.
+// A negative count counts from the right and returns the substring after the occurrence.
+func substringIndex(str, delim string, count int) string {
+	if count == 0 || delim == "" {
+		return ""
+	}
+	parts := strings.Split(str, delim)
+	if count > 0 {
+		if count >= len(parts) {
+			return str
+		}
+		return strings.Join(parts[:count], delim)
+	}
+	if -count >= len(parts) {
+		return str
+	}
+	return strings.Join(parts[len(parts)+count:], delim)
+}
+
+// maskByMode masks the string with asterisks by the predefined mode:
+//   - email: keep the first char of the local part and the domain
+//   - tail4: keep the last 4 chars
+//   - all: mask all chars
+func maskByMode(str string, mode string) (string, error) {
+	switch mode {
+	case "email":
+		local, domain := str, ""
+		if i := strings.LastIndex(str, "@"); i >= 0 {
+			local, domain = str[:i], str[i:]
+		}
+		return maskRange(local, 1, math.MaxInt, '*') + domain, nil
+	case "tail4":
+		return maskRange(str, 0, utf8.RuneCountInString(str)-4, '*'), nil
+	case "all":
+		return maskRange(str, 0, math.MaxInt, '*'), nil
+	default:
+		return "", fmt.Errorf("unknown mask mode %s, only support email, tail4 and all", mode)
+	}
+}
+
+// maskRange replaces the chars in the range [start, end) with the mask char.
+// The range is truncated to the length of the string.
+func maskRange(str string, start, end int, c rune) string {
+	rs := []rune(str)
+	if end > len(rs) {
+		end = len(rs)
+	}
+	for i := start; i < end; i++ {
+		rs[i] = c
+	}
+	return string(rs)
+}
+
+var (
+	xmlEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "'", "&apos;", `"`, "&quot;")
+	sqlEscaper   = strings.NewReplacer("'", "''")
+	sqlUnescaper = strings.NewReplacer("''", "'")
+)
+
+func validateEscapeArgs(_ api.FunctionContext, args []ast.Expr) error {
+	if err := ValidateLen(2, len(args)); err != nil {
+		return err
+	}
+	if ast.IsNumericArg(args[0]) || ast.IsTimeArg(args[0]) || ast.IsBooleanArg(args[0]) {
+		return ProduceErrInfo(0, "string")
+	}
+	f, ok := args[1].(*ast.StringLiteral)
+	if !ok {
+		return ProduceErrInfo(1, "string literal")
+	}
+	_, err := escapeString("", f.Val)
+	return err
+}
+
+// escapeString escapes the string to be embedded in the target format. For json, it returns the content of a json
+// string without the quotes. For csv, the field is quoted only if needed.
+func escapeString(str string, format string) (string, error) {
+	switch format {
+	case "json":
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(str); err != nil {
+			return "", err
+		}
+		b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		return string(b[1 : len(b)-1]), nil
+	case "csv":
+		if str == "" || !strings.ContainsAny(str, ",\"\r\n") && !unicode.IsSpace([]rune(str)[0]) {
+			return str, nil
+		}
+		return `"` + strings.ReplaceAll(str, `"`, `""`) + `"`, nil
+	case "url":
+		return url.QueryEscape(str), nil
+	case "xml":
+		return xmlEscaper.Replace(str), nil
+	case "sql":
+		return sqlEscaper.Replace(str), nil
+	default:
+		return "", fmt.Errorf("unsupported escape format %s, expect one of json, csv, url, xml, sql", format)
+	}
+}
+
+// unescapeString reverts the escaping of escapeString
+func unescapeString(str string, format string) (string, error) {
+	switch format {
+	case "json":
+		var r string
+		if err := json.Unmarshal([]byte(`"`+str+`"`), &r); err != nil {
+			return "", fmt.Errorf("invalid json escaped string: %v", err)
+		}
+		return r, nil
+	case "csv":
+		if !strings.HasPrefix(str, `"`) {
+			return str, nil
+		}
+		if len(str) < 2 || !strings.HasSuffix(str, `"`) {
+			return "", fmt.Errorf("invalid csv field: unclosed quote")
+		}
+		inner := str[1 : len(str)-1]
+		if strings.Contains(strings.ReplaceAll(inner, `""`, ""), `"`) {
+			return "", fmt.Errorf("invalid csv field: unescaped quote")
+		}
+		return strings.ReplaceAll(inner, `""`, `"`), nil
+	case "url":
+		r, err := url.QueryUnescape(str)
+		if err != nil {
+			return "", fmt.Errorf("invalid url escaped string: %v", err)
+		}
+		return r, nil
+	case "xml":
+		return html.UnescapeString(str), nil
+	case "sql":
+		return sqlUnescaper.Replace(str), nil
+	default:
+		return "", fmt.Errorf("unsupported escape format %s, expect one of json, csv, url, xml, sql", format)
+	}
+}
+
+// getCharset finds the encoding by its IANA name such as gbk or iso-8859-1. The WHATWG labels like utf8 are
+// accepted as a fallback.
+func getCharset(name string) (encoding.Encoding, error) {
+	if e, err := ianaindex.IANA.Encoding(name); err == nil && e != nil {
+		return e, nil
+	}
+	if e, err := htmlindex.Get(name); err == nil {
+		return e, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %s", name)
+}
+
+// transcode converts the bytes from one charset to another through utf-8. The second return value tells
+// whether the target charset is utf-8 so that the result can be returned as a string.
+func transcode(b []byte, from string, to string) ([]byte, bool, error) {
+	fe, err := getCharset(from)
+	if err != nil {
+		return nil, false, err
+	}
+	te, err := getCharset(to)
+	if err != nil {
+		return nil, false, err
+	}
+	u, err := fe.NewDecoder().Bytes(b)
+	if err != nil {
+		return nil, false, fmt.Errorf("fail to decode from %s: %v", from, err)
+	}
+	if te == xunicode.UTF8 {
+		return u, true, nil
+	}
+	r, err := te.NewEncoder().Bytes(u)
+	if err != nil {
+		return nil, false, fmt.Errorf("fail to encode to %s: %v", to, err)
+	}
+	return r, false, nil
+}
+
+// detectCharset guesses the charset of the bytes. It is only a heuristic: the BOM is checked first, then valid
+// utf-8, then gbk. Anything else is reported as iso-8859-1 which can decode any byte sequence.
+func detectCharset(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case utf8.Valid(b):
+		return "utf-8"
+	}
+	if u, err := simplifiedchinese.GBK.NewDecoder().Bytes(b); err == nil && !bytes.ContainsRune(u, utf8.RuneError) {
+		return "gbk"
+	}
+	return "iso-8859-1"
 }
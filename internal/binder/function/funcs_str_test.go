@@ -55,7 +55,7 @@ func TestStrFuncNil(t *testing.T) {
 			r, b := function.exec(fctx, []interface{}{nil})
 			require.True(t, b, fmt.Sprintf("%v failed", name))
 			require.Equal(t, -1, r)
-		case "length", "numbytes":
+		case "length", "numbytes", "bytes_length", "char_length":
 			r, b := function.check([]interface{}{nil})
 			require.True(t, b, fmt.Sprintf("%v failed", name))
 			require.Equal(t, 0, r)
@@ -109,6 +109,337 @@ func TestSplitValueFunctions(t *testing.T) {
 	}
 }
 
+func TestSubstrPredicates(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		funcName string
+		args     []interface{}
+		result   interface{}
+	}{
+		{"starts_with", []interface{}{"device_001", "device"}, true},
+		{"starts_with", []interface{}{"device_001", "001"}, false},
+		{"starts_with", []interface{}{"device_001", ""}, true},
+		{"ends_with", []interface{}{"device_001", "001"}, true},
+		{"ends_with", []interface{}{"device_001", "device"}, false},
+		{"contains", []interface{}{"device_001", "ce_0"}, true},
+		{"contains", []interface{}{"device_001", "abc"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s(%v)", tt.funcName, tt.args), func(t *testing.T) {
+			f, ok := builtins[tt.funcName]
+			require.True(t, ok)
+			r, b := f.check(tt.args)
+			require.False(t, b)
+			require.Nil(t, r)
+			r, b = f.exec(fctx, tt.args)
+			require.True(t, b)
+			require.Equal(t, tt.result, r)
+		})
+	}
+	for _, name := range []string{"starts_with", "ends_with", "contains"} {
+		f := builtins[name]
+		r, b := f.check([]interface{}{"a", nil})
+		require.True(t, b)
+		require.Nil(t, r)
+		require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}}))
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 arguments but found 1.")
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	f, ok := builtins["levenshtein"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"kitten", "sitting"}, 3},
+		{[]interface{}{"", "abc"}, 3},
+		{[]interface{}{"abc", ""}, 3},
+		{[]interface{}{"same", "same"}, 0},
+		{[]interface{}{"Sensor-A", "sensor-a"}, 2},
+		{[]interface{}{"Sensor-A", "sensor-a", true}, 0},
+		{[]interface{}{"Sensor-A", "sensor-a", false}, 2},
+		{[]interface{}{"温度计", "温度"}, 1},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.args))
+	}
+	r, b := f.check([]interface{}{"a", nil})
+	require.True(t, b)
+	require.Nil(t, r)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}, &ast.BooleanLiteral{Val: true}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}, &ast.StringLiteral{Val: "c"}}), "Expect bool type for parameter 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "the arguments for levenshtein should be 2 or 3")
+}
+
+func TestInterpolate(t *testing.T) {
+	f, ok := builtins["interpolate"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"http://{host}:{port}/api/{id}", map[string]interface{}{"host": "localhost", "port": 9081, "id": "a1"}}, "http://localhost:9081/api/a1"},
+		{[]interface{}{"{0} is {1} degrees", []interface{}{"temperature", 23.5}}, "temperature is 23.5 degrees"},
+		{[]interface{}{"{a}{a}-{b}", map[string]interface{}{"a": true, "b": nil}}, "truetrue-"},
+		{[]interface{}{"{{a}} is {a}", map[string]interface{}{"a": 1}}, "{a} is 1"},
+		{[]interface{}{"{a} and {missing}", map[string]interface{}{"a": 1}}, "1 and {missing}"},
+		{[]interface{}{"{0} {1} {-1}", []interface{}{"x"}}, "x {1} {-1}"},
+		{[]interface{}{"{a} {", map[string]interface{}{"a": 1}}, "1 {"},
+		{[]interface{}{"{x{a}}", map[string]interface{}{"a": 1}}, "{x1}"},
+		{[]interface{}{"no placeholder", map[string]interface{}{}}, "no placeholder"},
+		{[]interface{}{"{a} and {missing}", map[string]interface{}{"a": 1}, true}, errors.New("unresolved placeholder {missing}")},
+		{[]interface{}{"{a} {", map[string]interface{}{"a": 1}, true}, errors.New("unclosed placeholder at position 4")},
+		{[]interface{}{"{a}", map[string]interface{}{"a": 1}, true}, "1"},
+		{[]interface{}{"{a}", "a"}, errors.New("the values should be a map or an array but got a")},
+		{[]interface{}{1, map[string]interface{}{}}, errors.New("the template should be a string but got 1")},
+		{[]interface{}{"{a}", map[string]interface{}{}, "true"}, errors.New("the strict arg should be a bool but got true")},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.args))
+	}
+	r, b := f.check([]interface{}{"a", nil})
+	require.True(t, b)
+	require.Nil(t, r)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "{a}"}, &ast.FieldRef{Name: "m"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "{a}"}, &ast.FieldRef{Name: "m"}, &ast.BooleanLiteral{Val: true}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.FieldRef{Name: "m"}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "{a}"}, &ast.StringLiteral{Val: "m"}}), "Expect map or array type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "{a}"}, &ast.FieldRef{Name: "m"}, &ast.StringLiteral{Val: "c"}}), "Expect bool type for parameter 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.StringLiteral{Val: "{a}"}}), "the arguments for interpolate should be 2 or 3")
+}
+
+func TestSubstringIndex(t *testing.T) {
+	f, ok := builtins["substring_index"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"a.b.c.d", ".", 2}, "a.b"},
+		{[]interface{}{"a.b.c.d", ".", 1}, "a"},
+		{[]interface{}{"a.b.c.d", ".", -1}, "d"},
+		{[]interface{}{"a.b.c.d", ".", -2}, "c.d"},
+		{[]interface{}{"a.b.c.d", ".", 4}, "a.b.c.d"},
+		{[]interface{}{"a.b.c.d", ".", -10}, "a.b.c.d"},
+		{[]interface{}{"a.b.c.d", ".", 0}, ""},
+		{[]interface{}{"a.b.c.d", "", 1}, ""},
+		{[]interface{}{"a.b.c.d", "/", 1}, "a.b.c.d"},
+		{[]interface{}{"www.mysql.com", "sql", 1}, "www.my"},
+		{[]interface{}{"温度.湿度.压力", ".", -2}, "湿度.压力"},
+		{[]interface{}{"a.b", ".", "1"}, fmt.Errorf("cannot convert string(1) to int")},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.args))
+	}
+	r, b := f.check([]interface{}{"a", nil, 1})
+	require.True(t, b)
+	require.Nil(t, r)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "."}, &ast.IntegerLiteral{Val: -1}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "."}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "."}, &ast.StringLiteral{Val: "1"}}), "Expect int type for parameter 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "."}}), "Expect 3 arguments but found 2.")
+}
+
+func TestMask(t *testing.T) {
+	f, ok := builtins["mask"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"john.doe@example.com", "email"}, "j*******@example.com"},
+		{[]interface{}{"a@b.c", "email"}, "a@b.c"},
+		{[]interface{}{"john", "email"}, "j***"},
+		{[]interface{}{"13812345678", "tail4"}, "*******5678"},
+		{[]interface{}{"123", "tail4"}, "123"},
+		{[]interface{}{"secret", "all"}, "******"},
+		{[]interface{}{"密码", "all"}, "**"},
+		{[]interface{}{"secret", "none"}, errors.New("unknown mask mode none, only support email, tail4 and all")},
+		{[]interface{}{"13812345678", 3, 7, "#"}, "138####5678"},
+		{[]interface{}{"13812345678", 3, 100, "#"}, "138########"},
+		{[]interface{}{"13812345678", 20, 30, "#"}, "13812345678"},
+		{[]interface{}{"张三丰", 1, 2, "*"}, "张*丰"},
+		{[]interface{}{"abc", 2, 1, "*"}, errors.New("invalid mask range [2, 1)")},
+		{[]interface{}{"abc", 0, 1, "**"}, errors.New("the mask char must be a single character but got **")},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.args))
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "email"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "mode"}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 3}, &ast.StringLiteral{Val: "*"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "head4"}}), "unknown mask mode head4, only support email, tail4 and all")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "all"}}), "Expect string type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "1"}, &ast.IntegerLiteral{Val: 3}, &ast.StringLiteral{Val: "*"}}), "Expect int type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 3}, &ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "*"}}), "The end index should be larger than start index.")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 3}, &ast.StringLiteral{Val: ""}}), "the mask char must be a single character but got ")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 3}}), "Expect two or four arguments but found 3.")
+}
+
+func TestEscape(t *testing.T) {
+	escape, ok := builtins["escape"]
+	require.True(t, ok)
+	unescape, ok := builtins["unescape"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		str     string
+		format  string
+		escaped string
+	}{
+		{"say \"hi\"\n<a&b>\t\\", "json", `say \"hi\"\n<a&b>\t\\`},
+		{"中文", "json", "中文"},
+		{"plain", "csv", "plain"},
+		{"", "csv", ""},
+		{"a,b", "csv", `"a,b"`},
+		{`say "hi"`, "csv", `"say ""hi"""`},
+		{"line1\nline2", "csv", "\"line1\nline2\""},
+		{" lead", "csv", `" lead"`},
+		{"a b&c=d/中", "url", "a+b%26c%3Dd%2F%E4%B8%AD"},
+		{`<a href="x">Tom & 'Jerry'</a>`, "xml", "&lt;a href=&quot;x&quot;&gt;Tom &amp; &apos;Jerry&apos;&lt;/a&gt;"},
+		{"O'Reilly", "sql", "O''Reilly"},
+	}
+	for _, tt := range tests {
+		r, ok := escape.exec(fctx, []interface{}{tt.str, tt.format})
+		require.True(t, ok, r)
+		require.Equal(t, tt.escaped, r, "escape %s with %s", tt.str, tt.format)
+		r, ok = unescape.exec(fctx, []interface{}{tt.escaped, tt.format})
+		require.True(t, ok, r)
+		require.Equal(t, tt.str, r, "unescape %s with %s", tt.escaped, tt.format)
+	}
+	errTests := []struct {
+		args []interface{}
+		err  string
+	}{
+		{[]interface{}{`a"b`, "json"}, `invalid json escaped string: invalid character 'b' after top-level value`},
+		{[]interface{}{`"abc`, "csv"}, "invalid csv field: unclosed quote"},
+		{[]interface{}{`"a"b"`, "csv"}, "invalid csv field: unescaped quote"},
+		{[]interface{}{"%zz", "url"}, `invalid url escaped string: invalid URL escape "%zz"`},
+		{[]interface{}{"a", "yaml"}, "unsupported escape format yaml, expect one of json, csv, url, xml, sql"},
+	}
+	for _, tt := range errTests {
+		r, ok := unescape.exec(fctx, tt.args)
+		require.False(t, ok)
+		require.EqualError(t, r.(error), tt.err)
+	}
+	r, ok := unescape.exec(fctx, []interface{}{"&#60;&#x3E;", "xml"})
+	require.True(t, ok)
+	require.Equal(t, "<>", r)
+
+	require.NoError(t, escape.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "json"}}))
+	require.EqualError(t, escape.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}), "Expect 2 arguments but found 1.")
+	require.EqualError(t, escape.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "json"}}), "Expect string type for parameter 1")
+	require.EqualError(t, unescape.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "f"}}), "Expect string literal type for parameter 2")
+	require.EqualError(t, unescape.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "html"}}), "unsupported escape format html, expect one of json, csv, url, xml, sql")
+}
+
+func TestBytesAndCharLength(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		name   string
+		args   []interface{}
+		result interface{}
+	}{
+		{"bytes_length", []interface{}{"device"}, 6},
+		{"bytes_length", []interface{}{"设备01"}, 8},
+		{"bytes_length", []interface{}{[]byte{1, 2, 3}}, 3},
+		{"bytes_length", []interface{}{10}, errors.New("bytes_length requires string or bytea parameter but got int(10)")},
+		{"char_length", []interface{}{"device"}, 6},
+		{"char_length", []interface{}{"设备01"}, 4},
+		{"char_length", []interface{}{[]byte{1, 2, 3}}, errors.New("char_length does not support bytea, use bytes_length instead")},
+		{"char_length", []interface{}{true}, errors.New("char_length requires string parameter but got bool(true)")},
+	}
+	for _, tt := range tests {
+		f, ok := builtins[tt.name]
+		require.True(t, ok)
+		r, _ := f.exec(fctx, tt.args)
+		require.Equal(t, tt.result, r, fmt.Sprintf("%s%v", tt.name, tt.args))
+	}
+	for _, name := range []string{"bytes_length", "char_length"} {
+		f := builtins[name]
+		require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}}))
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 1")
+		require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}), "Expect 1 arguments but found 2.")
+	}
+}
+
+func TestRegexpExtract(t *testing.T) {
+	f, ok := builtins["regexp_extract"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"device-42/temp", `device-(\d+)/(\w+)`, 1}, "42"},
+		{[]interface{}{"device-42/temp", `device-(\d+)/(\w+)`, 2}, "temp"},
+		{[]interface{}{"id: device-42/temp", `device-(\d+)/(\w+)`, 0}, "device-42/temp"},
+		{[]interface{}{"sensor", `device-(\d+)`, 1}, ""},
+		{[]interface{}{"DEVICE-42", `device-(\d+)`, 1}, ""},
+		{[]interface{}{"DEVICE-42", `device-(\d+)`, 1, "i"}, "42"},
+		{[]interface{}{"DEVICE-42", `device-(\d+)`, 1, ""}, ""},
+		{[]interface{}{"device-42", `device-(\d+)`, 2}, errors.New("the group index 2 is out of range, the pattern has 1 groups")},
+		{[]interface{}{"device-42", `device-(\d+`, 1}, errors.New("error parsing regexp: missing closing ): `device-(\\d+`")},
+		{[]interface{}{"device-42", `device-(\d+)`, 1, "x"}, errors.New("unsupported regexp flag x, expect i, m, s or U")},
+	}
+	for _, tt := range tests {
+		r, _ := f.exec(fctx, tt.args)
+		if e, ok := tt.result.(error); ok {
+			require.EqualError(t, r.(error), e.Error(), fmt.Sprintf("%v", tt.args))
+		} else {
+			require.Equal(t, tt.result, r, fmt.Sprintf("%v", tt.args))
+		}
+	}
+	r, b := f.check([]interface{}{nil, "a", 1})
+	require.True(t, b)
+	require.Nil(t, r)
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}, &ast.IntegerLiteral{Val: 1}}))
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}, &ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "is"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}}), "the arguments for regexp_extract should be 3 or 4")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}, &ast.IntegerLiteral{Val: 1}}), "Expect string type for parameter 2")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}, &ast.StringLiteral{Val: "1"}}), "Expect int type for parameter 3")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}, &ast.IntegerLiteral{Val: -1}}), "the group index should not be a negative integer")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "b"}, &ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "g"}}), "unsupported regexp flag g, expect i, m, s or U")
+}
+
 func TestStrFunc(t *testing.T) {
 	contextLogger := conf.Log.WithField("rule", "testExec")
 	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
@@ -359,3 +690,70 @@ func TestStringFuncVal(t *testing.T) {
 		})
 	}
 }
+
+func TestTranscode(t *testing.T) {
+	f, ok := builtins["transcode"]
+	require.True(t, ok)
+	d, ok := builtins["detect_charset"]
+	require.True(t, ok)
+	contextLogger := conf.Log.WithField("rule", "testExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	gbk := []byte{0xD6, 0xD0, 0xCE, 0xC4}
+	latin1 := []byte{'c', 'a', 'f', 0xE9}
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{gbk, "gbk", "utf-8"}, "中文"},
+		{[]interface{}{gbk, "GB18030", "UTF8"}, "中文"},
+		{[]interface{}{"中文", "utf-8", "gbk"}, gbk},
+		{[]interface{}{latin1, "iso-8859-1", "utf-8"}, "café"},
+		{[]interface{}{"café", "utf-8", "latin1"}, latin1},
+		{[]interface{}{[]byte{0xFF, 0xFE, 'h', 0, 'i', 0}, "utf-16", "utf-8"}, "hi"},
+		{[]interface{}{"hi", "utf-8", "utf-16le"}, []byte{'h', 0, 'i', 0}},
+	}
+	for _, tt := range tests {
+		r, ok := f.exec(fctx, tt.args)
+		require.True(t, ok, r)
+		require.Equal(t, tt.result, r, "transcode %v", tt.args)
+	}
+	errTests := []struct {
+		args []interface{}
+		err  string
+	}{
+		{[]interface{}{"a", "foo", "utf-8"}, "unsupported charset foo"},
+		{[]interface{}{"a", "utf-8", "bar"}, "unsupported charset bar"},
+		{[]interface{}{"中文", "utf-8", "iso-8859-1"}, "fail to encode to iso-8859-1: encoding: rune not supported by encoding."},
+		{[]interface{}{1, "utf-8", "gbk"}, "cannot convert int(1) to bytes"},
+	}
+	for _, tt := range errTests {
+		r, ok := f.exec(fctx, tt.args)
+		require.False(t, ok)
+		require.EqualError(t, r.(error), tt.err)
+	}
+	require.NoError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "gbk"}, &ast.FieldRef{Name: "b"}}))
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "foo"}, &ast.StringLiteral{Val: "utf-8"}}), "unsupported charset foo")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.IntegerLiteral{Val: 1}, &ast.StringLiteral{Val: "gbk"}, &ast.StringLiteral{Val: "utf-8"}}), "Expect bytea type for parameter 1")
+	require.EqualError(t, f.val(fctx, []ast.Expr{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "gbk"}}), "Expect 3 arguments but found 2.")
+
+	detectTests := []struct {
+		input  interface{}
+		result string
+	}{
+		{"hello", "utf-8"},
+		{[]byte{0xEF, 0xBB, 0xBF, 'a'}, "utf-8"},
+		{[]byte{0xFF, 0xFE, 'a', 0}, "utf-16le"},
+		{[]byte{0xFE, 0xFF, 0, 'a'}, "utf-16be"},
+		{"中文", "utf-8"},
+		{gbk, "gbk"},
+		{latin1, "iso-8859-1"},
+	}
+	for _, tt := range detectTests {
+		r, ok := d.exec(fctx, []interface{}{tt.input})
+		require.True(t, ok, r)
+		require.Equal(t, tt.result, r, "detect %v", tt.input)
+	}
+	require.EqualError(t, d.val(fctx, []ast.Expr{&ast.BooleanLiteral{Val: true}}), "Expect bytea type for parameter 1")
+}
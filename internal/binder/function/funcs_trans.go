@@ -16,6 +16,7 @@ package function
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 	"github.com/lf-edge/ekuiper/v2/pkg/message"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
 type compressFunc struct {
@@ -40,7 +42,13 @@ func (c *compressFunc) Validate(args []any) error {
 			return fmt.Errorf("receive invalid arg %v", arg)
 		}
 	}
-	return ValidateTwoStrArg(nil, eargs)
+	if err := ValidateTwoStrArg(nil, eargs); err != nil {
+		return err
+	}
+	if s, ok := eargs[1].(*ast.StringLiteral); ok && !compressor.IsCompressorSupported(s.Val) {
+		return fmt.Errorf("unsupported compressor: %s, expect one of %s", s.Val, strings.Join(compressor.CompressorNames(), ", "))
+	}
+	return nil
 }
 
 func (c *compressFunc) Exec(ctx api.FunctionContext, args []any) (any, bool) {
@@ -90,7 +98,13 @@ func (d *decompressFunc) Validate(args []any) error {
 			return fmt.Errorf("receive invalid arg %v", arg)
 		}
 	}
-	return ValidateTwoStrArg(nil, eargs)
+	if err := ValidateTwoStrArg(nil, eargs); err != nil {
+		return err
+	}
+	if s, ok := eargs[1].(*ast.StringLiteral); ok && !compressor.IsDecompressorSupported(s.Val) {
+		return fmt.Errorf("unsupported decompressor: %s, expect one of %s", s.Val, strings.Join(compressor.DecompressorNames(), ", "))
+	}
+	return nil
 }
 
 func (d *decompressFunc) Exec(ctx api.FunctionContext, args []any) (any, bool) {
@@ -124,3 +138,59 @@ func (d *decompressFunc) Exec(ctx api.FunctionContext, args []any) (any, bool) {
 func (d *decompressFunc) IsAggregate() bool {
 	return false
 }
+
+// throttleState is the checkpointed state of the throttle function
+type throttleState struct {
+	LastEmit int64
+}
+
+// throttleFunc emits the value at most once per interval. The records in between are suppressed by returning nil.
+// Returning false is not used for suppression because it indicates an error for the function call.
+type throttleFunc struct{}
+
+func (t *throttleFunc) Validate(args []any) error {
+	if err := ValidateLen(2, len(args)); err != nil {
+		return err
+	}
+	arg, ok := args[1].(ast.Expr)
+	if !ok {
+		// should never happen
+		return fmt.Errorf("receive invalid arg %v", args[1])
+	}
+	if ast.IsFloatArg(arg) || ast.IsTimeArg(arg) || ast.IsBooleanArg(arg) || ast.IsStringArg(arg) {
+		return ProduceErrInfo(1, "int")
+	}
+	if s, ok := arg.(*ast.IntegerLiteral); ok && s.Val <= 0 {
+		return fmt.Errorf("the interval should be a positive integer but got %d", s.Val)
+	}
+	return nil
+}
+
+func (t *throttleFunc) Exec(ctx api.FunctionContext, args []any) (any, bool) {
+	interval, err := cast.ToInt64(args[1], cast.STRICT)
+	if err != nil {
+		return fmt.Errorf("error converting second arg %v to int: %v", args[1], err), false
+	}
+	if interval <= 0 {
+		return fmt.Errorf("the interval should be a positive integer but got %d", interval), false
+	}
+	const key = "throttle"
+	v, err := ctx.GetState(key)
+	if err != nil {
+		return fmt.Errorf("error getting state for %s: %v", key, err), false
+	}
+	now := timex.GetNowInMilli()
+	st, _ := v.(*throttleState)
+	if st != nil && now-st.LastEmit < interval {
+		return nil, true
+	}
+	err = ctx.PutState(key, &throttleState{LastEmit: now})
+	if err != nil {
+		return fmt.Errorf("error setting state for %s: %v", key, err), false
+	}
+	return args[0], true
+}
+
+func (t *throttleFunc) IsAggregate() bool {
+	return false
+}
@@ -15,14 +15,22 @@
 package function
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
 	kctx "github.com/lf-edge/ekuiper/v2/internal/topo/context"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/message"
+	"github.com/lf-edge/ekuiper/v2/pkg/modules"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
 func TestCompressExec(t *testing.T) {
@@ -132,3 +140,110 @@ func TestDecompressExec(t *testing.T) {
 		}
 	}
 }
+
+func TestThrottleExec(t *testing.T) {
+	ff, ok := builtinStatfulFuncs["throttle"]
+	if !ok {
+		t.Fatal("builtin not found")
+	}
+	f := ff()
+	contextLogger := conf.Log.WithField("rule", "testThrottleExec")
+	ctx := kctx.WithValue(kctx.Background(), kctx.LoggerKey, contextLogger)
+	tempStore, _ := state.CreateStore("mockRule0", def.AtMostOnce)
+	fctx := kctx.NewDefaultFuncContext(ctx.WithMeta("mockRule0", "test", tempStore), 2)
+	tests := []struct {
+		now    int64
+		args   []interface{}
+		result interface{}
+	}{
+		{now: 1000, args: []interface{}{"a", 100}, result: "a"},
+		{now: 1050, args: []interface{}{"b", 100}, result: nil},
+		{now: 1099, args: []interface{}{"c", 100}, result: nil},
+		{now: 1100, args: []interface{}{"d", 100}, result: "d"},
+		{now: 1150, args: []interface{}{"e", 100}, result: nil},
+		{now: 1300, args: []interface{}{"f", 100}, result: "f"},
+		{now: 1310, args: []interface{}{"g", "100"}, result: fmt.Errorf("error converting second arg 100 to int: cannot convert string(100) to int64")},
+		{now: 1320, args: []interface{}{"h", 0}, result: fmt.Errorf("the interval should be a positive integer but got 0")},
+	}
+	for i, tt := range tests {
+		timex.Set(tt.now)
+		result, _ := f.Exec(fctx, tt.args)
+		require.Equal(t, tt.result, result, "case %d", i)
+	}
+	// A new instance with the same context restores the state
+	f = ff()
+	timex.Set(1350)
+	result, ok := f.Exec(fctx, []interface{}{"i", 100})
+	require.True(t, ok)
+	require.Nil(t, result)
+	timex.Set(1400)
+	result, ok = f.Exec(fctx, []interface{}{"j", 100})
+	require.True(t, ok)
+	require.Equal(t, "j", result)
+}
+
+func TestCompressValidate(t *testing.T) {
+	c := builtinStatfulFuncs["compress"]()
+	d := builtinStatfulFuncs["decompress"]()
+	tests := []struct {
+		args []any
+		cerr string
+		derr string
+	}{
+		{args: []any{&ast.FieldRef{Name: "a"}}, cerr: "Expect 2 arguments but found 1.", derr: "Expect 2 arguments but found 1."},
+		{args: []any{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1}}, cerr: "Expect string type for parameter 2", derr: "Expect string type for parameter 2"},
+		{args: []any{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "lz4"}}, cerr: "unsupported compressor: lz4, expect one of flate, gzip, zlib, zstd", derr: "unsupported decompressor: lz4, expect one of flate, gzip, zlib, zstd"},
+		{args: []any{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "zlib"}}},
+		{args: []any{&ast.FieldRef{Name: "a"}, &ast.FieldRef{Name: "b"}}},
+	}
+	for i, tt := range tests {
+		err := c.(*compressFunc).Validate(tt.args)
+		if tt.cerr == "" {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.EqualError(t, err, tt.cerr, "case %d", i)
+		}
+		err = d.(*decompressFunc).Validate(tt.args)
+		if tt.derr == "" {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.EqualError(t, err, tt.derr, "case %d", i)
+		}
+	}
+	// the registered codec is validated dynamically
+	modules.RegisterCompressor("lz4", func(_ map[string]any) (message.Compressor, error) {
+		return nil, nil
+	})
+	defer delete(modules.Compressors, "lz4")
+	require.NoError(t, c.(*compressFunc).Validate([]any{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "lz4"}}))
+}
+
+func TestThrottleValidate(t *testing.T) {
+	f := builtinStatfulFuncs["throttle"]()
+	tests := []struct {
+		args []any
+		err  string
+	}{
+		{args: []any{&ast.FieldRef{Name: "a"}}, err: "Expect 2 arguments but found 1."},
+		{args: []any{&ast.FieldRef{Name: "a"}, &ast.StringLiteral{Val: "1"}}, err: "Expect int type for parameter 2"},
+		{args: []any{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: -1}}, err: "the interval should be a positive integer but got -1"},
+		{args: []any{&ast.FieldRef{Name: "a"}, &ast.IntegerLiteral{Val: 1000}}},
+	}
+	for i, tt := range tests {
+		err := f.(*throttleFunc).Validate(tt.args)
+		if tt.err == "" {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.EqualError(t, err, tt.err, "case %d", i)
+		}
+	}
+}
+
+func TestThrottleStateGob(t *testing.T) {
+	var buf bytes.Buffer
+	var in interface{} = &throttleState{LastEmit: 1000}
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&in))
+	var out interface{}
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&out))
+	require.Equal(t, in, out)
+}
@@ -33,6 +33,10 @@ type (
 
 type builtinFunc struct {
 	fType ast.FuncType
+	// pure marks the scalar functions which always return the same result for the same arguments in a rule run.
+	// They must not depend on the time, randomness, states or the context of the current row, and must not have
+	// side effects. A builtin is not pure unless it is set explicitly.
+	pure  bool
 	exec  funcExe
 	val   funcVal
 	check funcCheckNil
@@ -70,15 +74,20 @@ func init() {
 //}
 
 var analyticFuncs = map[string]struct{}{
-	"lag":         {},
-	"changed_col": {},
-	"had_changed": {},
-	"latest":      {},
-	"acc_sum":     {},
-	"acc_min":     {},
-	"acc_max":     {},
-	"acc_avg":     {},
-	"acc_count":   {},
+	"lag":            {},
+	"prev":           {},
+	"changed_col":    {},
+	"changed":        {},
+	"had_changed":    {},
+	"latest":         {},
+	"acc_sum":        {},
+	"acc_min":        {},
+	"acc_max":        {},
+	"acc_avg":        {},
+	"acc_count":      {},
+	"running_sum":    {},
+	"ema":            {},
+	"collect_window": {},
 }
 
 var windowFuncs = map[string]struct{}{
@@ -97,6 +106,13 @@ func IsAnalyticFunc(name string) bool {
 	return ok
 }
 
+// IsInvariantFunc returns whether the function is a pure builtin scalar function which always returns the same result
+// for the same arguments in a rule run. The plugin and service functions are unknown so that they are not invariant.
+func IsInvariantFunc(name string) bool {
+	f, ok := builtins[name]
+	return ok && f.pure
+}
+
 type Manager struct{}
 
 // Function the name is converted to lowercase if needed during parsing
@@ -21,6 +21,7 @@ import (
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/modules"
 )
 
@@ -84,3 +85,41 @@ func TestRegister(t *testing.T) {
 	_, err := m.Function("nouse")
 	assert.NoError(t, err)
 }
+
+func TestIsInvariantFunc(t *testing.T) {
+	tests := []struct {
+		name      string
+		invariant bool
+	}{
+		{name: "upper", invariant: true},
+		{name: "rule_id", invariant: true},
+		{name: "concat", invariant: true},
+		{name: "newuuid", invariant: false},
+		{name: "tstamp", invariant: false},
+		{name: "current_timestamp", invariant: false},
+		{name: "rand", invariant: false},
+		{name: "set_keyed_state", invariant: false},
+		{name: "set_keyed_states", invariant: false},
+		{name: "props", invariant: false},
+		{name: "lag", invariant: false},
+		{name: "count", invariant: false},
+		{name: "unnest", invariant: false},
+		{name: "nouse", invariant: false},
+		{name: "notexist", invariant: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.invariant, IsInvariantFunc(tt.name), tt.name)
+	}
+	// the pure functions must be the builtin scalar functions
+	for name, f := range builtins {
+		if !f.pure {
+			continue
+		}
+		assert.Equal(t, ast.FuncTypeScalar, f.fType, name)
+		assert.False(t, IsAnalyticFunc(name), name)
+	}
+	// the synonyms are as pure as the original functions
+	assert.True(t, IsInvariantFunc("ceiling"))
+	assert.True(t, IsInvariantFunc("power"))
+	assert.True(t, IsInvariantFunc("day"))
+}
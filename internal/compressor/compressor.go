@@ -17,21 +17,51 @@ package compressor
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/message"
+	"github.com/lf-edge/ekuiper/v2/pkg/modules"
 )
 
 type CompressorInstantiator func(name string, props map[string]any) (message.Compressor, error)
 
 var compressors = map[string]CompressorInstantiator{}
 
+// GetCompressor returns the built-in compressor or the one registered by modules.RegisterCompressor.
+// The built-in compressors take precedence.
 func GetCompressor(name string, props map[string]any) (message.Compressor, error) {
 	if instantiator, ok := compressors[name]; ok {
 		return instantiator(name, props)
 	}
+	if provider, ok := modules.Compressors[name]; ok {
+		return provider(props)
+	}
 	return nil, fmt.Errorf("unsupported compressor: %s", name)
 }
 
+func IsCompressorSupported(name string) bool {
+	if _, ok := compressors[name]; ok {
+		return true
+	}
+	_, ok := modules.Compressors[name]
+	return ok
+}
+
+// CompressorNames returns the sorted names of all the built-in and registered compressors
+func CompressorNames() []string {
+	names := make([]string, 0, len(compressors)+len(modules.Compressors))
+	for n := range compressors {
+		names = append(names, n)
+	}
+	for n := range modules.Compressors {
+		if _, ok := compressors[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 type CompressWriterIns func(reader io.Writer) (io.Writer, error)
 
 var compressWriters = map[string]CompressWriterIns{}
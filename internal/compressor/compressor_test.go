@@ -19,6 +19,11 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/message"
+	"github.com/lf-edge/ekuiper/v2/pkg/modules"
 )
 
 func BenchmarkCompressor(b *testing.B) {
@@ -222,3 +227,54 @@ func TestCompressAndDecompress(t *testing.T) {
 		})
 	}
 }
+
+type reverseCodec struct{}
+
+func (r *reverseCodec) reverse(data []byte) []byte {
+	result := make([]byte, len(data))
+	for i, b := range data {
+		result[len(data)-1-i] = b
+	}
+	return result
+}
+
+func (r *reverseCodec) Compress(data []byte) ([]byte, error) {
+	return r.reverse(data), nil
+}
+
+func (r *reverseCodec) Decompress(data []byte) ([]byte, error) {
+	return r.reverse(data), nil
+}
+
+func TestRegisteredCompressor(t *testing.T) {
+	require.False(t, IsCompressorSupported("reverse"))
+	require.False(t, IsDecompressorSupported("reverse"))
+	modules.RegisterCompressor("reverse", func(_ map[string]any) (message.Compressor, error) {
+		return &reverseCodec{}, nil
+	})
+	modules.RegisterDecompressor("reverse", func() (message.Decompressor, error) {
+		return &reverseCodec{}, nil
+	})
+	defer func() {
+		delete(modules.Compressors, "reverse")
+		delete(modules.Decompressors, "reverse")
+	}()
+	require.True(t, IsCompressorSupported("reverse"))
+	require.True(t, IsDecompressorSupported("reverse"))
+	require.Equal(t, []string{FLATE, GZIP, "reverse", ZLIB, ZSTD}, CompressorNames())
+	require.Equal(t, []string{FLATE, GZIP, "reverse", ZLIB, ZSTD}, DecompressorNames())
+
+	c, err := GetCompressor("reverse", nil)
+	require.NoError(t, err)
+	compressed, err := c.Compress([]byte("abc"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("cba"), compressed)
+	d, err := GetDecompressor("reverse")
+	require.NoError(t, err)
+	decompressed, err := d.Decompress(compressed)
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), decompressed)
+
+	_, err = GetCompressor("unknown", nil)
+	require.EqualError(t, err, "unsupported compressor: unknown")
+}
@@ -17,21 +17,51 @@ package compressor
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/message"
+	"github.com/lf-edge/ekuiper/v2/pkg/modules"
 )
 
 type DecompressorInstantiator func(name string) (message.Decompressor, error)
 
 var decompressors = map[string]DecompressorInstantiator{}
 
+// GetDecompressor returns the built-in decompressor or the one registered by modules.RegisterDecompressor.
+// The built-in decompressors take precedence.
 func GetDecompressor(name string) (message.Decompressor, error) {
 	if instantiator, ok := decompressors[name]; ok {
 		return instantiator(name)
 	}
+	if provider, ok := modules.Decompressors[name]; ok {
+		return provider()
+	}
 	return nil, fmt.Errorf("unsupported decompressor: %s", name)
 }
 
+func IsDecompressorSupported(name string) bool {
+	if _, ok := decompressors[name]; ok {
+		return true
+	}
+	_, ok := modules.Decompressors[name]
+	return ok
+}
+
+// DecompressorNames returns the sorted names of all the built-in and registered decompressors
+func DecompressorNames() []string {
+	names := make([]string, 0, len(decompressors)+len(modules.Decompressors))
+	for n := range decompressors {
+		names = append(names, n)
+	}
+	for n := range modules.Decompressors {
+		if _, ok := decompressors[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 type DecompressReaderIns func(reader io.Reader) (io.ReadCloser, error)
 
 var decompressReaders = map[string]DecompressReaderIns{}
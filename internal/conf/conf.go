@@ -125,6 +125,9 @@ func InitConf() {
 		Config.Basic.GracefulShutdownTimeout = cast.DurationConf(3 * time.Second)
 	}
 
+	if Config.Basic.ZoneInfoPath != "" {
+		cast.SetZoneInfoPath(Config.Basic.ZoneInfoPath)
+	}
 	if Config.Basic.TimeZone != "" {
 		if err := cast.SetTimeZone(Config.Basic.TimeZone); err != nil {
 			Log.Fatal(err)
@@ -273,6 +276,11 @@ func ValidateRuleOption(option *def.RuleOption) error {
 		Log.Warnf("bufferLength is negative, set to 1024")
 		errs = errors.Join(errs, errors.New("invalidBufferLength:bufferLength must be greater than 0"))
 	}
+	if option.DistinctCacheSize < 0 {
+		option.DistinctCacheSize = 0
+		Log.Warnf("distinctCacheSize is negative, set to 0 to use the default size")
+		errs = errors.Join(errs, errors.New("invalidDistinctCacheSize:distinctCacheSize must not be negative"))
+	}
 	if option.LateTol < 0 {
 		option.LateTol = cast.DurationConf(time.Second)
 		Log.Warnf("lateTol is negative, set to 1 second")
@@ -308,6 +316,11 @@ func ValidateRuleOption(option *def.RuleOption) error {
 	if err := schedule.ValidateRanges(option.CronDatetimeRange); err != nil {
 		errs = errors.Join(errs, fmt.Errorf("validate cronDatetimeRange failed, err:%v", err))
 	}
+	if option.TimeZone != "" {
+		if _, err := cast.LoadLocation(option.TimeZone); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalidTimeZone:invalid timezone %s: %v", option.TimeZone, err))
+		}
+	}
 	return errs
 }
 
@@ -163,6 +163,44 @@ func TestRuleOptionValidate(t *testing.T) {
 			},
 			err: "invalidRestartMultiplier:restart multiplier must be greater than 0\ninvalidRestartAttempts:restart attempts must be greater than 0\ninvalidRestartDelay:restart delay must be greater than 0\ninvalidRestartMaxDelay:restart maxDelay must be greater than 0\ninvalidRestartJitterFactor:restart jitterFactor must between [0, 1)",
 		},
+		{
+			s: &def.RuleOption{
+				Concurrency:  1,
+				BufferLength: 1024,
+				TimeZone:     "Asia/Shanghai",
+			},
+			e: &def.RuleOption{
+				Concurrency:  1,
+				BufferLength: 1024,
+				TimeZone:     "Asia/Shanghai",
+			},
+		},
+		{
+			s: &def.RuleOption{
+				Concurrency:  1,
+				BufferLength: 1024,
+				TimeZone:     "Nowhere/Invalid",
+			},
+			e: &def.RuleOption{
+				Concurrency:  1,
+				BufferLength: 1024,
+				TimeZone:     "Nowhere/Invalid",
+			},
+			err: "invalidTimeZone:invalid timezone Nowhere/Invalid: unknown time zone Nowhere/Invalid",
+		},
+		{
+			s: &def.RuleOption{
+				Concurrency:       1,
+				BufferLength:      1024,
+				DistinctCacheSize: -1,
+			},
+			e: &def.RuleOption{
+				Concurrency:       1,
+				BufferLength:      1024,
+				DistinctCacheSize: 0,
+			},
+			err: "invalidDistinctCacheSize:distinctCacheSize must not be negative",
+		},
 	}
 	fmt.Printf("The test bucket size is %d.\n\n", len(tests))
 	for i, tt := range tests {
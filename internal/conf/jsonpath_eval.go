@@ -1,4 +1,4 @@
-// Copyright 2021 EMQ Technologies Co., Ltd.
+// Copyright 2021-2024 EMQ Technologies Co., Ltd.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -19,9 +19,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/PaesslerAG/gval"
 	"github.com/PaesslerAG/jsonpath"
+	lru "github.com/hashicorp/golang-lru/v2"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 )
@@ -67,3 +69,32 @@ func GetJsonPathEval(jsonpath string) (JsonPathEval, error) {
 	}
 	return &gvalPathEval{valuer: e}, nil
 }
+
+const defaultJsonPathCacheSize = 1024
+
+var (
+	jsonPathCache     *lru.Cache[string, JsonPathEval]
+	jsonPathCacheOnce sync.Once
+)
+
+// GetCachedJsonPathEval gets the compiled json path from the LRU cache shared by all rules to avoid
+// parsing the same path for every record. It is the only json path cache, the cache size is set by basic.jsonPathCacheSize.
+func GetCachedJsonPathEval(jsonpath string) (JsonPathEval, error) {
+	jsonPathCacheOnce.Do(func() {
+		size := defaultJsonPathCacheSize
+		if Config != nil && Config.Basic.JsonPathCacheSize > 0 {
+			size = Config.Basic.JsonPathCacheSize
+		}
+		// only return error when size is not positive
+		jsonPathCache, _ = lru.New[string, JsonPathEval](size)
+	})
+	if je, ok := jsonPathCache.Get(jsonpath); ok {
+		return je, nil
+	}
+	je, err := GetJsonPathEval(jsonpath)
+	if err != nil {
+		return nil, err
+	}
+	jsonPathCache.Add(jsonpath, je)
+	return je, nil
+}
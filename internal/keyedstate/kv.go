@@ -15,11 +15,30 @@
 package keyedstate
 
 import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
 	kv2 "github.com/lf-edge/ekuiper/v2/pkg/kv"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+const (
+	// sweepInterval is the interval to delete the expired keyed states from the storage
+	sweepInterval = time.Minute
+	// namespaceSep separates the namespace and the key. It is not allowed in the rule id so that the keys of
+	// different rules never collide.
+	namespaceSep = "/"
 )
 
-var kv kv2.KeyValue
+var (
+	kv        kv2.KeyValue
+	sweepOnce sync.Once
+)
 
 type Manager struct {
 	kv kv2.KeyValue
@@ -27,6 +46,30 @@ type Manager struct {
 
 func InitKeyedStateKV() {
 	kv, _ = store.GetExtStateKV("keyed_state")
+	sweepOnce.Do(func() {
+		if e, ok := kv.(kv2.KeyedStateExpirer); ok {
+			go sweep(e)
+		}
+	})
+}
+
+func sweep(e kv2.KeyedStateExpirer) {
+	ticker := timex.GetTicker(sweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := e.SweepExpired(); err != nil {
+			conf.Log.Warnf("fail to sweep the expired keyed states: %v", err)
+		}
+	}
+}
+
+// NamespacedKey returns the storage key of the key in the namespace such as the rule id.
+// The key is returned as is for the empty namespace, which is shared by all rules.
+func NamespacedKey(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespace + namespaceSep + key
 }
 
 func GetKeyedState(key string) (interface{}, error) {
@@ -37,6 +80,76 @@ func SetKeyedState(key string, value interface{}) error {
 	return kv.SetKeyedState(key, value)
 }
 
+// SetKeyedStateWithTTL sets the keyed state which reads as absent after ttl. A zero ttl means no expiry
+func SetKeyedStateWithTTL(key string, value interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		return kv.SetKeyedState(key, value)
+	}
+	e, ok := kv.(kv2.KeyedStateExpirer)
+	if !ok {
+		return fmt.Errorf("the keyed state store does not support ttl")
+	}
+	return e.SetKeyedStateWithTTL(key, value, ttl)
+}
+
 func ClearKeyedState() error {
 	return kv.Drop()
 }
+
+// GetKeyedStates returns the values of the keys in the same order. The value is nil if the key does not exist.
+// The backend fetches all keys in one round trip if it supports batching, otherwise they are fetched one by one.
+// When fetched one by one, the value of a key that fails to read is also nil and the error is logged.
+func GetKeyedStates(keys []string) ([]interface{}, error) {
+	if b, ok := kv.(kv2.KeyedStateBatcher); ok {
+		return b.GetKeyedStates(keys)
+	}
+	result := make([]interface{}, len(keys))
+	for i, key := range keys {
+		v, err := kv.GetKeyedState(key)
+		if err != nil {
+			var ec errorx.ErrorWithCode
+			if !errors.As(err, &ec) || ec.Code() != errorx.NOT_FOUND {
+				conf.Log.Warnf("fail to get the keyed state %s: %v", key, err)
+			}
+			continue
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+// SetKeyedStates sets all the states in one round trip if the backend supports batching
+func SetKeyedStates(states map[string]interface{}) error {
+	if b, ok := kv.(kv2.KeyedStateBatcher); ok {
+		return b.SetKeyedStates(states)
+	}
+	for key, value := range states {
+		if err := kv.SetKeyedState(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CommitKeyedStates sets the states and deletes the keys together. The changes are committed atomically if the backend
+// supports transactions. Otherwise, they are applied one by one and may be partially applied when an error happens.
+func CommitKeyedStates(sets map[string]interface{}, deletes []string) error {
+	if t, ok := kv.(kv2.KeyedStateTransactor); ok {
+		return t.CommitKeyedStates(sets, deletes)
+	}
+	if len(sets) > 0 {
+		if err := SetKeyedStates(sets); err != nil {
+			return err
+		}
+	}
+	for _, key := range deletes {
+		if err := kv.Delete(key); err != nil {
+			var ec errorx.ErrorWithCode
+			if errors.As(err, &ec) && ec.Code() == errorx.NOT_FOUND {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -18,7 +18,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/lf-edge/ekuiper/v2/internal/testx"
+	kv2 "github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
 
 func init() {
@@ -64,6 +67,51 @@ func TestGetKeyedState(t *testing.T) {
 			}
 		})
 	}
+	t.Run("batch", testGetKeyedStates)
+	t.Run("commit", testCommitKeyedStates)
 
 	_ = ClearKeyedState()
 }
+
+// noBatchKV hides the batching ability of the underlying kv
+type noBatchKV struct {
+	kv2.KeyValue
+}
+
+func testGetKeyedStates(t *testing.T) {
+	native := kv
+	defer func() {
+		kv = native
+	}()
+	for name, store := range map[string]kv2.KeyValue{"native": native, "loop": noBatchKV{native}} {
+		t.Run(name, func(t *testing.T) {
+			kv = store
+			require.NoError(t, SetKeyedStates(map[string]interface{}{"a": "1", "b": "2"}))
+			got, err := GetKeyedStates([]string{"b", "none", "a"})
+			require.NoError(t, err)
+			require.Equal(t, []interface{}{"2", nil, "1"}, got)
+		})
+	}
+}
+
+func testCommitKeyedStates(t *testing.T) {
+	native := kv
+	defer func() {
+		kv = native
+	}()
+	for name, store := range map[string]kv2.KeyValue{"native": native, "loop": noBatchKV{native}} {
+		t.Run(name, func(t *testing.T) {
+			kv = store
+			require.NoError(t, SetKeyedState("old", "0"))
+			require.NoError(t, CommitKeyedStates(map[string]interface{}{"a": "1", "b": "2"}, []string{"old", "none"}))
+			got, err := GetKeyedStates([]string{"a", "b", "old"})
+			require.NoError(t, err)
+			require.Equal(t, []interface{}{"1", "2", nil}, got)
+		})
+	}
+}
+
+func TestNamespacedKey(t *testing.T) {
+	require.Equal(t, "counter", NamespacedKey("", "counter"))
+	require.Equal(t, "rule1/counter", NamespacedKey("rule1", "counter"))
+}
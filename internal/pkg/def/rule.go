@@ -34,6 +34,9 @@ type RuleOption struct {
 	SendMetaToSink            bool                     `json:"sendMetaToSink" yaml:"sendMetaToSink"`
 	SendNil                   bool                     `json:"sendNilField" yaml:"sendNilField"`
 	SendError                 bool                     `json:"sendError" yaml:"sendError"`
+	SendSchema                bool                     `json:"sendSchema,omitempty" yaml:"sendSchema,omitempty"`
+	DistinctCacheSize         int                      `json:"distinctCacheSize,omitempty" yaml:"distinctCacheSize,omitempty"`
+	WildcardPrefix            map[string]string        `json:"wildcardPrefix,omitempty" yaml:"wildcardPrefix,omitempty"`
 	Qos                       Qos                      `json:"qos,omitempty" yaml:"qos,omitempty"`
 	CheckpointInterval        cast.DurationConf        `json:"checkpointInterval,omitempty" yaml:"checkpointInterval,omitempty"`
 	RestartStrategy           *RestartStrategy         `json:"restartStrategy,omitempty" yaml:"restartStrategy,omitempty"`
@@ -46,10 +49,18 @@ type RuleOption struct {
 	EnableSaveStateBeforeStop bool                     `json:"enableSaveStateBeforeStop,omitempty" yaml:"enableSaveStateBeforeStop,omitempty"`
 	ForceExitTimeout          cast.DurationConf        `json:"forceExitTimeout,omitempty" yaml:"forceExitTimeout,omitempty"`
 	Experiment                *ExpOpts                 `json:"experiment,omitempty" yaml:"experiment,omitempty"`
+	// TimeZone is the default time zone of the rule to parse the time without zone information. It overrides the
+	// global basic.timezone configuration.
+	TimeZone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	// ShareKeyedState disables the rule id namespace of the keyed state so that the keys are shared with other rules
+	// and the external writers.
+	ShareKeyedState bool `json:"shareKeyedState,omitempty" yaml:"shareKeyedState,omitempty"`
 }
 
 type ExpOpts struct {
 	UseSliceTuple bool `json:"useSliceTuple" yaml:"useSliceTuple"`
+	// ProfileFields records the evaluation time of each projected field and reports the most expensive ones in the metrics
+	ProfileFields bool `json:"profileFields,omitempty" yaml:"profileFields,omitempty"`
 }
 
 type PlanOptimizeStrategy struct {
@@ -16,9 +16,17 @@ package definition
 
 import "time"
 
+// SchemaVersion is the version of the layout of the stored data.
+// Bump it when the layout changes and implement the transformation in the Migrate of each backend.
+const SchemaVersion = "1"
+
 type Database interface {
 	Connect() error
 	Disconnect() error
+	// Migrate transforms the stored data from the layout of fromVersion to toVersion.
+	// It is called when connecting if the stored schema version differs from SchemaVersion.
+	// The fromVersion is empty if the data is stored before the versioning is introduced.
+	Migrate(fromVersion, toVersion string) error
 }
 
 type Config struct {
@@ -27,6 +35,9 @@ type Config struct {
 	Redis        RedisConfig
 	Sqlite       SqliteConfig
 	Fdb          FdbConfig
+	Memory       MemoryConfig
+	// Metrics enables collecting the metrics of the kv store operations
+	Metrics bool
 }
 
 type RedisConfig struct {
@@ -41,6 +52,10 @@ type SqliteConfig struct {
 	Name string
 }
 
+// MemoryConfig is the config of the memory store whose data is kept in the process and lost on restart.
+// There is nothing to configure for now.
+type MemoryConfig struct{}
+
 type FdbConfig struct {
 	Path       string
 	APIVersion int
@@ -22,17 +22,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/directory"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
 
 	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
 type fdbKvStore struct {
 	database *fdb.Database
 	subspace directory.DirectorySubspace
+	// ttl saves the expire time in milliseconds of the keyed states with ttl
+	ttl directory.DirectorySubspace
 }
 
 func createFdbKvStore(fdb *fdb.Database, db string, table string) (*fdbKvStore, error) {
@@ -40,9 +44,14 @@ func createFdbKvStore(fdb *fdb.Database, db string, table string) (*fdbKvStore,
 	if err != nil {
 		return nil, err
 	}
+	ttl, err := directory.CreateOrOpen(fdb, []string{db, table, "__ttl"}, nil)
+	if err != nil {
+		return nil, err
+	}
 	store := &fdbKvStore{
 		database: fdb,
 		subspace: dir,
+		ttl:      ttl,
 	}
 	return store, nil
 }
@@ -96,36 +105,174 @@ func (kv fdbKvStore) Get(key string, value interface{}) (bool, error) {
 }
 
 func (kv fdbKvStore) GetKeyedState(key string) (interface{}, error) {
-	val, err := kv.database.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
-		ret, e = tr.Get(kv.subspace.Pack(tuple.Tuple{key})).Get()
-		return
-	})
+	values, err := kv.GetKeyedStates([]string{key})
 	if err != nil {
 		return nil, err
 	}
-	var value interface{}
-	if err := json.Unmarshal(val.([]byte), &value); err != nil {
-		return nil, err
+	if values[0] == nil {
+		return nil, fmt.Errorf("%s is not found", key)
 	}
-	return value, nil
+	return values[0], nil
 }
 
 func (kv fdbKvStore) SetKeyedState(key string, value interface{}) error {
+	return kv.SetKeyedStateWithTTL(key, value, 0)
+}
+
+// SetKeyedStateWithTTL saves the expire time of the key in the ttl subspace. Expired keys are filtered out when reading
+// and deleted by SweepExpired
+func (kv fdbKvStore) SetKeyedStateWithTTL(key string, value interface{}, ttl time.Duration) error {
 	b, err := json.Marshal(value)
 	if nil != err {
 		return err
 	}
+	var expire int64
+	if ttl > 0 {
+		expire = timex.GetNow().Add(ttl).UnixMilli()
+	}
 	_, err = kv.database.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
-		tr.Set(kv.subspace.Pack(tuple.Tuple{key}), b)
+		kv.setKeyedState(tr, key, b, expire)
+		return
+	})
+	return err
+}
+
+// setKeyedState sets the encoded value and its expire time. An expire time of 0 means no expiry
+func (kv fdbKvStore) setKeyedState(tr fdb.Transaction, key string, b []byte, expire int64) {
+	tr.Set(kv.subspace.Pack(tuple.Tuple{key}), b)
+	if expire > 0 {
+		tr.Set(kv.ttl.Pack(tuple.Tuple{key}), tuple.Tuple{expire}.Pack())
+	} else {
+		tr.Clear(kv.ttl.Pack(tuple.Tuple{key}))
+	}
+}
+
+// GetKeyedStates fetches all the keys in one transaction
+func (kv fdbKvStore) GetKeyedStates(keys []string) ([]interface{}, error) {
+	now := timex.GetNowInMilli()
+	vals, err := kv.database.ReadTransact(func(tr fdb.ReadTransaction) (interface{}, error) {
+		futures := make([]fdb.FutureByteSlice, len(keys))
+		ttlFutures := make([]fdb.FutureByteSlice, len(keys))
+		for i, key := range keys {
+			futures[i] = tr.Get(kv.subspace.Pack(tuple.Tuple{key}))
+			ttlFutures[i] = tr.Get(kv.ttl.Pack(tuple.Tuple{key}))
+		}
+		bs := make([][]byte, len(keys))
+		for i, f := range futures {
+			b, err := f.Get()
+			if err != nil {
+				return nil, err
+			}
+			e, err := ttlFutures[i].Get()
+			if err != nil {
+				return nil, err
+			}
+			if isExpired(e, now) {
+				continue
+			}
+			bs[i] = b
+		}
+		return bs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(keys))
+	for i, b := range vals.([][]byte) {
+		if b == nil {
+			continue
+		}
+		if err := json.Unmarshal(b, &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// SetKeyedStates sets all the states in one transaction
+func (kv fdbKvStore) SetKeyedStates(states map[string]interface{}) error {
+	encoded := make(map[string][]byte, len(states))
+	for k, v := range states {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		encoded[k] = b
+	}
+	_, err := kv.database.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
+		for k, b := range encoded {
+			kv.setKeyedState(tr, k, b, 0)
+		}
+		return
+	})
+	return err
+}
+
+// CommitKeyedStates sets the states and deletes the keys in one transaction
+func (kv fdbKvStore) CommitKeyedStates(sets map[string]interface{}, deletes []string) error {
+	encoded := make(map[string][]byte, len(sets))
+	for k, v := range sets {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		encoded[k] = b
+	}
+	_, err := kv.database.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
+		for k, b := range encoded {
+			kv.setKeyedState(tr, k, b, 0)
+		}
+		for _, k := range deletes {
+			tr.Clear(kv.subspace.Pack(tuple.Tuple{k}))
+			tr.Clear(kv.ttl.Pack(tuple.Tuple{k}))
+		}
 		return
 	})
+	return err
+}
 
+// SweepExpired deletes the expired keyed states
+func (kv fdbKvStore) SweepExpired() error {
+	now := timex.GetNowInMilli()
+	_, err := kv.database.Transact(func(tr fdb.Transaction) (interface{}, error) {
+		it := tr.GetRange(kv.ttl, fdb.RangeOptions{}).Iterator()
+		for it.Advance() {
+			keyVal, err := it.Get()
+			if err != nil {
+				return nil, err
+			}
+			if !isExpired(keyVal.Value, now) {
+				continue
+			}
+			ks, err := kv.ttl.Unpack(keyVal.Key)
+			if err != nil {
+				return nil, err
+			}
+			tr.Clear(kv.subspace.Pack(ks))
+			tr.Clear(keyVal.Key)
+		}
+		return nil, nil
+	})
 	return err
 }
 
+// isExpired checks the packed expire time against now. A nil expire time means no expiry
+func isExpired(e []byte, now int64) bool {
+	if e == nil {
+		return false
+	}
+	t, err := tuple.Unpack(e)
+	if err != nil || len(t) == 0 {
+		return false
+	}
+	expire, ok := t[0].(int64)
+	return ok && expire <= now
+}
+
 func (kv fdbKvStore) Delete(key string) error {
 	_, err := kv.database.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		tr.Clear(kv.subspace.Pack(tuple.Tuple{key}))
+		tr.Clear(kv.ttl.Pack(tuple.Tuple{key}))
 		return
 	})
 	return err
@@ -185,6 +332,7 @@ func (kv fdbKvStore) All() (map[string]string, error) {
 func (kv fdbKvStore) Clean() error {
 	_, err := kv.database.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		tr.ClearRange(kv.subspace)
+		tr.ClearRange(kv.ttl)
 		return
 	})
 	if err != nil {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/test/common"
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
 const (
@@ -72,6 +73,27 @@ func TestFdbKvGetKeyedState(t *testing.T) {
 	common.TestKvGetKeyedState(ks, t)
 }
 
+func TestFdbKvKeyedStates(t *testing.T) {
+	ks, db, subspace := setupFdbKv()
+	defer cleanFdbKv(db, subspace)
+
+	common.TestKvKeyedStates(ks, t)
+}
+
+func TestFdbKvCommitKeyedStates(t *testing.T) {
+	ks, db, subspace := setupFdbKv()
+	defer cleanFdbKv(db, subspace)
+
+	common.TestKvCommitKeyedStates(ks, t)
+}
+
+func TestFdbKvKeyedStateTTL(t *testing.T) {
+	ks, db, subspace := setupFdbKv()
+	defer cleanFdbKv(db, subspace)
+
+	common.TestKvKeyedStateTTL(ks, timex.Add, t)
+}
+
 func cleanKV(client fdb.Database, subspace directory.DirectorySubspace) error {
 	_, err := client.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		tr.ClearRange(subspace)
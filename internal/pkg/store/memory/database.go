@@ -0,0 +1,94 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sync"
+
+	"github.com/lf-edge/ekuiper/v2/internal/conf/logger"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+)
+
+// Database keeps all the tables in the process memory. The data is lost when disconnecting or restarting by design,
+// so it is only suitable for tests and ephemeral deployments.
+type Database struct {
+	mu   sync.Mutex
+	name string
+	kvs  map[string]*kvStore
+	tss  map[string]*ts
+}
+
+func NewMemoryDatabase(_ definition.Config, name string) *Database {
+	logger.Log.Infof("use memory as store %v", name)
+	return &Database{
+		name: name,
+		kvs:  make(map[string]*kvStore),
+		tss:  make(map[string]*ts),
+	}
+}
+
+func (d *Database) Connect() error {
+	return nil
+}
+
+// Disconnect drops all the data
+func (d *Database) Disconnect() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.kvs = make(map[string]*kvStore)
+	d.tss = make(map[string]*ts)
+	return nil
+}
+
+// Migrate does nothing as the data is never persisted
+func (d *Database) Migrate(_, _ string) error {
+	return nil
+}
+
+// kv returns the kv table and creates it if not exists. The same table is returned for the same name so that the
+// data is shared like the persisted stores.
+func (d *Database) kv(table string) *kvStore {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if s, ok := d.kvs[table]; ok {
+		return s
+	}
+	s := newKvStore(d, table)
+	d.kvs[table] = s
+	return s
+}
+
+func (d *Database) ts(table string) *ts {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if s, ok := d.tss[table]; ok {
+		return s
+	}
+	s := newTs(d, table)
+	d.tss[table] = s
+	return s
+}
+
+func (d *Database) dropKv(table string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.kvs, table)
+}
+
+func (d *Database) dropTs(table string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.tss, table)
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+// kvStore saves the gob encoded values like other stores so that the values are copied. The keyed states are saved
+// as they are.
+type kvStore struct {
+	database *Database
+	table    string
+
+	mu   sync.RWMutex
+	data map[string]interface{}
+	// expire saves the expire time in milliseconds of the keyed states with ttl
+	expire map[string]int64
+}
+
+func newKvStore(d *Database, table string) *kvStore {
+	return &kvStore{
+		database: d,
+		table:    table,
+		data:     make(map[string]interface{}),
+		expire:   make(map[string]int64),
+	}
+}
+
+func (kv *kvStore) Setnx(key string, value interface{}) error {
+	b, err := kvEncoding.Encode(value)
+	if err != nil {
+		return err
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	if _, ok := kv.data[key]; ok {
+		return fmt.Errorf(`Item %s already exists`, key)
+	}
+	kv.data[key] = b
+	return nil
+}
+
+func (kv *kvStore) Set(key string, value interface{}) error {
+	b, err := kvEncoding.Encode(value)
+	if err != nil {
+		return err
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.data[key] = b
+	delete(kv.expire, key)
+	return nil
+}
+
+func (kv *kvStore) Get(key string, value interface{}) (bool, error) {
+	kv.mu.RLock()
+	v, ok := kv.data[key]
+	kv.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	b, ok := v.([]byte)
+	if !ok {
+		return false, fmt.Errorf("the value of %s is not encoded", key)
+	}
+	if err := gob.NewDecoder(bytes.NewBuffer(b)).Decode(value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (kv *kvStore) GetKeyedState(key string) (interface{}, error) {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	v, ok := kv.getKeyedState(key, timex.GetNowInMilli())
+	if !ok {
+		return nil, errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("%s is not found", key))
+	}
+	return v, nil
+}
+
+// getKeyedState returns the value if it exists and is not expired. It must be called with the lock held
+func (kv *kvStore) getKeyedState(key string, now int64) (interface{}, bool) {
+	v, ok := kv.data[key]
+	if !ok {
+		return nil, false
+	}
+	if e, ok := kv.expire[key]; ok && e <= now {
+		return nil, false
+	}
+	return v, true
+}
+
+func (kv *kvStore) SetKeyedState(key string, value interface{}) error {
+	return kv.SetKeyedStateWithTTL(key, value, 0)
+}
+
+func (kv *kvStore) SetKeyedStateWithTTL(key string, value interface{}, ttl time.Duration) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.data[key] = value
+	if ttl > 0 {
+		kv.expire[key] = timex.GetNow().Add(ttl).UnixMilli()
+	} else {
+		delete(kv.expire, key)
+	}
+	return nil
+}
+
+func (kv *kvStore) GetKeyedStates(keys []string) ([]interface{}, error) {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	now := timex.GetNowInMilli()
+	result := make([]interface{}, len(keys))
+	for i, k := range keys {
+		result[i], _ = kv.getKeyedState(k, now)
+	}
+	return result, nil
+}
+
+func (kv *kvStore) SetKeyedStates(states map[string]interface{}) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	for k, v := range states {
+		kv.data[k] = v
+		delete(kv.expire, k)
+	}
+	return nil
+}
+
+// CommitKeyedStates applies all the changes while holding the lock so that the readers never see a partial update
+func (kv *kvStore) CommitKeyedStates(sets map[string]interface{}, deletes []string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	for k, v := range sets {
+		kv.data[k] = v
+		delete(kv.expire, k)
+	}
+	for _, k := range deletes {
+		delete(kv.data, k)
+		delete(kv.expire, k)
+	}
+	return nil
+}
+
+func (kv *kvStore) SweepExpired() error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	now := timex.GetNowInMilli()
+	for k, e := range kv.expire {
+		if e <= now {
+			delete(kv.data, k)
+			delete(kv.expire, k)
+		}
+	}
+	return nil
+}
+
+func (kv *kvStore) Delete(key string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	if _, ok := kv.data[key]; !ok {
+		return errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("%s is not found", key))
+	}
+	delete(kv.data, key)
+	delete(kv.expire, key)
+	return nil
+}
+
+// Keys returns the keys in order to be consistent with the sqlite store
+func (kv *kvStore) Keys() ([]string, error) {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	keys := make([]string, 0, len(kv.data))
+	for k := range kv.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (kv *kvStore) All() (map[string]string, error) {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	all := make(map[string]string, len(kv.data))
+	for k, v := range kv.data {
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("the value of %s is not encoded", k)
+		}
+		var value string
+		if err := gob.NewDecoder(bytes.NewBuffer(b)).Decode(&value); err != nil {
+			return nil, err
+		}
+		all[k] = value
+	}
+	return all, nil
+}
+
+func (kv *kvStore) Clean() error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.data = make(map[string]interface{})
+	kv.expire = make(map[string]int64)
+	return nil
+}
+
+func (kv *kvStore) Drop() error {
+	if err := kv.Clean(); err != nil {
+		return err
+	}
+	kv.database.dropKv(kv.table)
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/test/common"
+	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
+)
+
+var _ definition.Database = &Database{}
+
+func TestMemoryKvSetnx(t *testing.T) {
+	common.TestKvSetnx(setupMemoryKv(t), t)
+}
+
+func TestMemoryKvSet(t *testing.T) {
+	common.TestKvSet(setupMemoryKv(t), t)
+}
+
+func TestMemoryKvSetGet(t *testing.T) {
+	common.TestKvSetGet(setupMemoryKv(t), t)
+}
+
+func TestMemoryKvGet(t *testing.T) {
+	common.TestKvGet(setupMemoryKv(t), t)
+}
+
+func TestMemoryKvKeys(t *testing.T) {
+	common.TestKvKeys(10, setupMemoryKv(t), t)
+}
+
+func TestMemoryKvAll(t *testing.T) {
+	common.TestKvAll(10, setupMemoryKv(t), t)
+}
+
+func TestMemoryKvGetKeyedState(t *testing.T) {
+	common.TestKvGetKeyedState(setupMemoryKv(t), t)
+}
+
+func TestMemoryKvKeyedStates(t *testing.T) {
+	common.TestKvKeyedStates(setupMemoryKv(t), t)
+}
+
+func TestMemoryKvCommitKeyedStates(t *testing.T) {
+	common.TestKvCommitKeyedStates(setupMemoryKv(t), t)
+}
+
+func TestMemoryKvKeyedStateTTL(t *testing.T) {
+	ks := setupMemoryKv(t)
+	common.TestKvKeyedStateTTL(ks, timex.Add, t)
+	keys, err := ks.Keys()
+	require.NoError(t, err)
+	require.NotContains(t, keys, "short")
+}
+
+func TestMemoryKvDelete(t *testing.T) {
+	ks := setupMemoryKv(t)
+	require.NoError(t, ks.Set("foo", "bar"))
+	require.NoError(t, ks.Delete("foo"))
+	err := ks.Delete("foo")
+	require.Error(t, err)
+	var ec errorx.ErrorWithCode
+	require.ErrorAs(t, err, &ec)
+	require.Equal(t, errorx.NOT_FOUND, ec.Code())
+}
+
+func TestMemoryKvShared(t *testing.T) {
+	_, builder := setupMemoryDatabase(t)
+	ks1, err := builder.CreateStore("test")
+	require.NoError(t, err)
+	require.NoError(t, ks1.Set("foo", "bar"))
+	// The same table shares the data
+	ks2, err := builder.CreateStore("test")
+	require.NoError(t, err)
+	var v string
+	ok, err := ks2.Get("foo", &v)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "bar", v)
+	// Dropped table is recreated empty
+	require.NoError(t, ks2.Drop())
+	ks3, err := builder.CreateStore("test")
+	require.NoError(t, err)
+	ok, err = ks3.Get("foo", &v)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestMemoryDisconnect(t *testing.T) {
+	d, builder := setupMemoryDatabase(t)
+	ks, err := builder.CreateStore("test")
+	require.NoError(t, err)
+	require.NoError(t, ks.Set("foo", "bar"))
+	require.NoError(t, d.Disconnect())
+	ks, err = builder.CreateStore("test")
+	require.NoError(t, err)
+	keys, err := ks.Keys()
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func setupMemoryDatabase(t *testing.T) (*Database, StoreBuilder) {
+	d := NewMemoryDatabase(definition.Config{Type: "memory"}, "test")
+	require.NoError(t, d.Connect())
+	return d, NewStoreBuilder(d)
+}
+
+func setupMemoryKv(t *testing.T) kv.KeyValue {
+	_, builder := setupMemoryDatabase(t)
+	ks, err := builder.CreateStore("test")
+	require.NoError(t, err)
+	return ks
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync"
+
+	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
+)
+
+type ts struct {
+	database *Database
+	table    string
+
+	mu   sync.RWMutex
+	data map[int64][]byte
+	last int64
+}
+
+func newTs(d *Database, table string) *ts {
+	return &ts{
+		database: d,
+		table:    table,
+		data:     make(map[int64][]byte),
+	}
+}
+
+func (t *ts) Set(key int64, value interface{}) (bool, error) {
+	b, err := kvEncoding.Encode(value)
+	if err != nil {
+		return false, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if key <= t.last {
+		return false, nil
+	}
+	t.data[key] = b
+	t.last = key
+	return true, nil
+}
+
+func (t *ts) Get(key int64, value interface{}) (bool, error) {
+	t.mu.RLock()
+	b, ok := t.data[key]
+	t.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	if err := gob.NewDecoder(bytes.NewBuffer(b)).Decode(value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (t *ts) Last(value interface{}) (int64, error) {
+	t.mu.RLock()
+	last := t.last
+	t.mu.RUnlock()
+	_, err := t.Get(last, value)
+	if err != nil {
+		return 0, err
+	}
+	return last, nil
+}
+
+func (t *ts) Delete(key int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.data, key)
+	return nil
+}
+
+func (t *ts) DeleteBefore(key int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k := range t.data {
+		if k < key {
+			delete(t.data, k)
+		}
+	}
+	return nil
+}
+
+func (t *ts) Close() error {
+	return nil
+}
+
+func (t *ts) Drop() error {
+	t.mu.Lock()
+	t.data = make(map[int64][]byte)
+	t.last = 0
+	t.mu.Unlock()
+	t.database.dropTs(t.table)
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/test/common"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+func TestMemoryTsSet(t *testing.T) {
+	common.TestTsSet(setupMemoryTs(t), t)
+}
+
+func TestMemoryTsLast(t *testing.T) {
+	common.TestTsLast(setupMemoryTs(t), t)
+}
+
+func TestMemoryTsGet(t *testing.T) {
+	common.TestTsGet(setupMemoryTs(t), t)
+}
+
+func TestMemoryTsDelete(t *testing.T) {
+	common.TestTsDelete(setupMemoryTs(t), t)
+}
+
+func TestMemoryTsDeleteBefore(t *testing.T) {
+	common.TestTsDeleteBefore(setupMemoryTs(t), t)
+}
+
+func setupMemoryTs(t *testing.T) kv.Tskv {
+	d := NewMemoryDatabase(definition.Config{Type: "memory"}, "test")
+	require.NoError(t, d.Connect())
+	ks, err := NewTsBuilder(d).CreateTs("test")
+	require.NoError(t, err)
+	return ks
+}
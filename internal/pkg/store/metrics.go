@@ -0,0 +1,267 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+// The labels are the same as the metrics package which cannot be imported here due to the import cycle
+const (
+	lblType    = "type"
+	lblStore   = "store"
+	lblStatus  = "status"
+	lblSuccess = "success"
+	lblErr     = "err"
+)
+
+var (
+	StoreOpCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kuiper",
+		Subsystem: "store",
+		Name:      "counter",
+		Help:      "counter of store operations",
+	}, []string{lblType, lblStore, lblStatus})
+
+	StoreOpDurationHist = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kuiper",
+		Subsystem: "store",
+		Name:      "duration_microseconds",
+		Help:      "Historgram Duration of store operations",
+		Buckets:   prometheus.ExponentialBuckets(10, 2, 20), // 10us ~ 5s
+	}, []string{lblType, lblStore})
+)
+
+func init() {
+	prometheus.MustRegister(StoreOpCounter)
+	prometheus.MustRegister(StoreOpDurationHist)
+}
+
+// newMetricsKV wraps the kv store to collect the count and the latency of each operation. The optional interfaces of
+// the wrapped store are kept so that the callers can still detect the capabilities by type assertion. Thus, there is a
+// wrapper type for every combination of the optional interfaces.
+func newMetricsKV(ks kv.KeyValue, store string) kv.KeyValue {
+	m := &metricsKV{ks: ks, store: store}
+	var (
+		mb *metricsBatcher
+		me *metricsExpirer
+		mt *metricsTransactor
+	)
+	if b, ok := ks.(kv.KeyedStateBatcher); ok {
+		mb = &metricsBatcher{m: m, b: b}
+	}
+	if e, ok := ks.(kv.KeyedStateExpirer); ok {
+		me = &metricsExpirer{m: m, e: e}
+	}
+	if t, ok := ks.(kv.KeyedStateTransactor); ok {
+		mt = &metricsTransactor{m: m, t: t}
+	}
+	switch {
+	case mb != nil && me != nil && mt != nil:
+		return &metricsBatchExpireTxKV{metricsKV: m, metricsBatcher: mb, metricsExpirer: me, metricsTransactor: mt}
+	case mb != nil && me != nil:
+		return &metricsBatchExpireKV{metricsKV: m, metricsBatcher: mb, metricsExpirer: me}
+	case mb != nil && mt != nil:
+		return &metricsBatchTxKV{metricsKV: m, metricsBatcher: mb, metricsTransactor: mt}
+	case me != nil && mt != nil:
+		return &metricsExpireTxKV{metricsKV: m, metricsExpirer: me, metricsTransactor: mt}
+	case mb != nil:
+		return &metricsBatchKV{metricsKV: m, metricsBatcher: mb}
+	case me != nil:
+		return &metricsExpireKV{metricsKV: m, metricsExpirer: me}
+	case mt != nil:
+		return &metricsTxKV{metricsKV: m, metricsTransactor: mt}
+	default:
+		return m
+	}
+}
+
+type metricsKV struct {
+	ks    kv.KeyValue
+	store string
+}
+
+func (m *metricsKV) observe(op string, start time.Time, err error) {
+	status := lblSuccess
+	if err != nil {
+		status = lblErr
+	}
+	StoreOpCounter.WithLabelValues(op, m.store, status).Inc()
+	StoreOpDurationHist.WithLabelValues(op, m.store).Observe(float64(time.Since(start).Microseconds()))
+}
+
+func (m *metricsKV) Setnx(key string, value interface{}) error {
+	start := time.Now()
+	err := m.ks.Setnx(key, value)
+	m.observe("setnx", start, err)
+	return err
+}
+
+func (m *metricsKV) Set(key string, value interface{}) error {
+	start := time.Now()
+	err := m.ks.Set(key, value)
+	m.observe("set", start, err)
+	return err
+}
+
+func (m *metricsKV) Get(key string, val interface{}) (bool, error) {
+	start := time.Now()
+	ok, err := m.ks.Get(key, val)
+	m.observe("get", start, err)
+	return ok, err
+}
+
+func (m *metricsKV) GetKeyedState(key string) (interface{}, error) {
+	start := time.Now()
+	v, err := m.ks.GetKeyedState(key)
+	m.observe("getKeyedState", start, err)
+	return v, err
+}
+
+func (m *metricsKV) SetKeyedState(key string, value interface{}) error {
+	start := time.Now()
+	err := m.ks.SetKeyedState(key, value)
+	m.observe("setKeyedState", start, err)
+	return err
+}
+
+func (m *metricsKV) Delete(key string) error {
+	start := time.Now()
+	err := m.ks.Delete(key)
+	m.observe("delete", start, err)
+	return err
+}
+
+func (m *metricsKV) Keys() ([]string, error) {
+	start := time.Now()
+	keys, err := m.ks.Keys()
+	m.observe("keys", start, err)
+	return keys, err
+}
+
+func (m *metricsKV) All() (map[string]string, error) {
+	start := time.Now()
+	all, err := m.ks.All()
+	m.observe("all", start, err)
+	return all, err
+}
+
+func (m *metricsKV) Clean() error {
+	start := time.Now()
+	err := m.ks.Clean()
+	m.observe("clean", start, err)
+	return err
+}
+
+func (m *metricsKV) Drop() error {
+	start := time.Now()
+	err := m.ks.Drop()
+	m.observe("drop", start, err)
+	return err
+}
+
+type metricsBatcher struct {
+	m *metricsKV
+	b kv.KeyedStateBatcher
+}
+
+func (mb *metricsBatcher) GetKeyedStates(keys []string) ([]interface{}, error) {
+	start := time.Now()
+	v, err := mb.b.GetKeyedStates(keys)
+	mb.m.observe("getKeyedStates", start, err)
+	return v, err
+}
+
+func (mb *metricsBatcher) SetKeyedStates(states map[string]interface{}) error {
+	start := time.Now()
+	err := mb.b.SetKeyedStates(states)
+	mb.m.observe("setKeyedStates", start, err)
+	return err
+}
+
+type metricsExpirer struct {
+	m *metricsKV
+	e kv.KeyedStateExpirer
+}
+
+func (me *metricsExpirer) SetKeyedStateWithTTL(key string, value interface{}, ttl time.Duration) error {
+	start := time.Now()
+	err := me.e.SetKeyedStateWithTTL(key, value, ttl)
+	me.m.observe("setKeyedStateWithTTL", start, err)
+	return err
+}
+
+func (me *metricsExpirer) SweepExpired() error {
+	start := time.Now()
+	err := me.e.SweepExpired()
+	me.m.observe("sweepExpired", start, err)
+	return err
+}
+
+type metricsTransactor struct {
+	m *metricsKV
+	t kv.KeyedStateTransactor
+}
+
+func (mt *metricsTransactor) CommitKeyedStates(sets map[string]interface{}, deletes []string) error {
+	start := time.Now()
+	err := mt.t.CommitKeyedStates(sets, deletes)
+	mt.m.observe("commitKeyedStates", start, err)
+	return err
+}
+
+type metricsBatchKV struct {
+	*metricsKV
+	*metricsBatcher
+}
+
+type metricsExpireKV struct {
+	*metricsKV
+	*metricsExpirer
+}
+
+type metricsTxKV struct {
+	*metricsKV
+	*metricsTransactor
+}
+
+type metricsBatchExpireKV struct {
+	*metricsKV
+	*metricsBatcher
+	*metricsExpirer
+}
+
+type metricsBatchTxKV struct {
+	*metricsKV
+	*metricsBatcher
+	*metricsTransactor
+}
+
+type metricsExpireTxKV struct {
+	*metricsKV
+	*metricsExpirer
+	*metricsTransactor
+}
+
+type metricsBatchExpireTxKV struct {
+	*metricsKV
+	*metricsBatcher
+	*metricsExpirer
+	*metricsTransactor
+}
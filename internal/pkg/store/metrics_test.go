@@ -0,0 +1,156 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/test/common"
+	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+)
+
+func TestMetricsKV(t *testing.T) {
+	c := definition.Config{
+		Type:         "sqlite",
+		ExtStateType: "sqlite",
+		Sqlite: definition.SqliteConfig{
+			Path: t.TempDir(),
+		},
+		Metrics: true,
+	}
+	s, err := newStores(c, "metricsKV.db")
+	require.NoError(t, err)
+	ks, err := s.GetKV("test")
+	require.NoError(t, err)
+	// sqlite kv supports batch, expiry and transaction
+	_, ok := ks.(*metricsBatchExpireTxKV)
+	require.True(t, ok)
+	defer func() {
+		_ = ks.Drop()
+	}()
+
+	common.TestKvSetnx(ks, t)
+	common.TestKvGetKeyedState(ks, t)
+	require.Equal(t, float64(1), counterValue(t, "setnx", lblSuccess))
+	require.Equal(t, float64(1), counterValue(t, "setnx", lblErr))
+	require.Equal(t, float64(1), counterValue(t, "getKeyedState", lblSuccess))
+	m := &dto.Metric{}
+	require.NoError(t, StoreOpDurationHist.WithLabelValues("setnx", "metricsKV").(prometheus.Histogram).Write(m))
+	require.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+}
+
+func counterValue(t *testing.T, op string, status string) float64 {
+	m := &dto.Metric{}
+	require.NoError(t, StoreOpCounter.WithLabelValues(op, "metricsKV", status).Write(m))
+	return m.GetCounter().GetValue()
+}
+
+func TestMetricsKVDisabled(t *testing.T) {
+	c := definition.Config{
+		Type:         "sqlite",
+		ExtStateType: "sqlite",
+		Sqlite: definition.SqliteConfig{
+			Path: t.TempDir(),
+		},
+	}
+	s, err := newStores(c, "noMetricsKV.db")
+	require.NoError(t, err)
+	ks, err := s.GetKV("test")
+	require.NoError(t, err)
+	defer func() {
+		_ = ks.Drop()
+	}()
+	_, ok := ks.(*metricsBatchExpireTxKV)
+	require.False(t, ok)
+}
+
+func TestMetricsKVCapabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		ks         kv.KeyValue
+		batcher    bool
+		expirer    bool
+		transactor bool
+	}{
+		{name: "plain", ks: plainKV{}},
+		{name: "batcher", ks: batcherKV{}, batcher: true},
+		{name: "expirer", ks: expirerKV{}, expirer: true},
+		{name: "transactor", ks: transactorKV{}, transactor: true},
+		{name: "batcher and expirer", ks: batchExpireKV{}, batcher: true, expirer: true},
+		{name: "batcher and transactor", ks: batchTxKV{}, batcher: true, transactor: true},
+		{name: "expirer and transactor", ks: expireTxKV{}, expirer: true, transactor: true},
+		{name: "all", ks: fullKV{}, batcher: true, expirer: true, transactor: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMetricsKV(tt.ks, "test")
+			_, ok := m.(kv.KeyedStateBatcher)
+			require.Equal(t, tt.batcher, ok)
+			_, ok = m.(kv.KeyedStateExpirer)
+			require.Equal(t, tt.expirer, ok)
+			_, ok = m.(kv.KeyedStateTransactor)
+			require.Equal(t, tt.transactor, ok)
+		})
+	}
+}
+
+type plainKV struct {
+	kv.KeyValue
+}
+
+type batcherKV struct {
+	kv.KeyValue
+	kv.KeyedStateBatcher
+}
+
+type expirerKV struct {
+	kv.KeyValue
+	kv.KeyedStateExpirer
+}
+
+type transactorKV struct {
+	kv.KeyValue
+	kv.KeyedStateTransactor
+}
+
+type batchExpireKV struct {
+	kv.KeyValue
+	kv.KeyedStateBatcher
+	kv.KeyedStateExpirer
+}
+
+type batchTxKV struct {
+	kv.KeyValue
+	kv.KeyedStateBatcher
+	kv.KeyedStateTransactor
+}
+
+type expireTxKV struct {
+	kv.KeyValue
+	kv.KeyedStateExpirer
+	kv.KeyedStateTransactor
+}
+
+type fullKV struct {
+	kv.KeyValue
+	kv.KeyedStateBatcher
+	kv.KeyedStateExpirer
+	kv.KeyedStateTransactor
+}
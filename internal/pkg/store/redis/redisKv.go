@@ -21,6 +21,7 @@ import (
 	"encoding/gob"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
@@ -89,7 +90,54 @@ func (kv redisKvStore) GetKeyedState(key string) (interface{}, error) {
 }
 
 func (kv redisKvStore) SetKeyedState(key string, value interface{}) error {
-	return kv.database.Set(context.Background(), key, value, 0).Err()
+	return kv.SetKeyedStateWithTTL(key, value, 0)
+}
+
+// SetKeyedStateWithTTL expires the key natively by redis
+func (kv redisKvStore) SetKeyedStateWithTTL(key string, value interface{}, ttl time.Duration) error {
+	return kv.database.Set(context.Background(), key, value, ttl).Err()
+}
+
+// SweepExpired does nothing as redis deletes the expired keys by itself
+func (kv redisKvStore) SweepExpired() error {
+	return nil
+}
+
+// GetKeyedStates fetches all the keys by one MGET command
+func (kv redisKvStore) GetKeyedStates(keys []string) ([]interface{}, error) {
+	if len(keys) == 0 {
+		return []interface{}{}, nil
+	}
+	return kv.database.MGet(context.Background(), keys...).Result()
+}
+
+// SetKeyedStates sets all the states by one MSET command
+func (kv redisKvStore) SetKeyedStates(states map[string]interface{}) error {
+	if len(states) == 0 {
+		return nil
+	}
+	pairs := make([]interface{}, 0, len(states)*2)
+	for k, v := range states {
+		pairs = append(pairs, k, v)
+	}
+	return kv.database.MSet(context.Background(), pairs...).Err()
+}
+
+// CommitKeyedStates sets the states and deletes the keys in a MULTI/EXEC transaction
+func (kv redisKvStore) CommitKeyedStates(sets map[string]interface{}, deletes []string) error {
+	if len(sets) == 0 && len(deletes) == 0 {
+		return nil
+	}
+	_, err := kv.database.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		for k, v := range sets {
+			pipe.Set(context.Background(), k, v, 0)
+		}
+		if len(deletes) > 0 {
+			pipe.Del(context.Background(), deletes...)
+		}
+		return nil
+	})
+	return err
 }
 
 func (kv redisKvStore) Delete(key string) error {
@@ -74,6 +74,27 @@ func TestRedisKvGetKeyedState(t *testing.T) {
 	common.TestKvGetKeyedState(ks, t)
 }
 
+func TestRedisKvKeyedStates(t *testing.T) {
+	ks, db, minRedis := setupRedisKv()
+	defer cleanRedisKv(db, minRedis)
+
+	common.TestKvKeyedStates(ks, t)
+}
+
+func TestRedisKvCommitKeyedStates(t *testing.T) {
+	ks, db, minRedis := setupRedisKv()
+	defer cleanRedisKv(db, minRedis)
+
+	common.TestKvCommitKeyedStates(ks, t)
+}
+
+func TestRedisKvKeyedStateTTL(t *testing.T) {
+	ks, db, minRedis := setupRedisKv()
+	defer cleanRedisKv(db, minRedis)
+
+	common.TestKvKeyedStateTTL(ks, minRedis.FastForward, t)
+}
+
 func setupRedisKv() (kv.KeyValue, *redis.Client, *miniredis.Miniredis) {
 	minRedis, err := miniredis.Run()
 	if err != nil {
@@ -103,3 +124,33 @@ func stringToInt(svalue string) int {
 	}
 	return ivalue
 }
+
+func BenchmarkRedisKeyedStates(b *testing.B) {
+	ks, db, minRedis := setupRedisKv()
+	defer cleanRedisKv(db, minRedis)
+	keys := make([]string, 50)
+	states := make(map[string]interface{}, len(keys))
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+		states[keys[i]] = i
+	}
+	if err := ks.(kv.KeyedStateBatcher).SetKeyedStates(states); err != nil {
+		b.Fatal(err)
+	}
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, k := range keys {
+				if _, err := ks.GetKeyedState(k); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ks.(kv.KeyedStateBatcher).GetKeyedStates(keys); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
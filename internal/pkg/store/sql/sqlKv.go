@@ -20,14 +20,24 @@ import (
 	"encoding/gob"
 	"fmt"
 	"strings"
+	"time"
 
 	kvEncoding "github.com/lf-edge/ekuiper/v2/internal/pkg/store/encoding"
 	"github.com/lf-edge/ekuiper/v2/pkg/errorx"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
+// ttlTable saves the expire time in milliseconds of the keyed states with ttl for all the ttl stores
+const ttlTable = "__keyed_state_ttl"
+
+// notExpiredCond filters out the expired keys of the table aliased as s. The parameters are the table name and the current time
+var notExpiredCond = fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s AS e WHERE e.tbl=? AND e.key=s.key AND e.expire<=?)", ttlTable)
+
 type sqlKvStore struct {
 	database Database
 	table    string
+	// ttl is only set for the sqlTTLKvStore. The ttl table is only read and cleaned for it
+	ttl bool
 
 	preparedGetStmt         *sql.Stmt
 	preparedSetStmt         *sql.Stmt
@@ -35,17 +45,40 @@ type sqlKvStore struct {
 	preparedDeleteStmt      *sql.Stmt
 }
 
+// sqlTTLKvStore supports the keyed states with ttl. It is only created for the keyed state table so that the other
+// tables do not pay for the ttl filter and cleanup
+type sqlTTLKvStore struct {
+	*sqlKvStore
+}
+
 func createSqlKvStore(database Database, table string) (*sqlKvStore, error) {
+	return newSqlKvStore(database, table, false)
+}
+
+func createSqlTTLKvStore(database Database, table string) (*sqlTTLKvStore, error) {
+	store, err := newSqlKvStore(database, table, true)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTTLKvStore{sqlKvStore: store}, nil
+}
+
+func newSqlKvStore(database Database, table string, ttl bool) (*sqlKvStore, error) {
 	if !isValidTableName(table) {
 		return nil, fmt.Errorf("invalid table name: %s", table)
 	}
 	store := &sqlKvStore{
 		database: database,
 		table:    table,
+		ttl:      ttl,
 	}
 	err := store.database.Apply(func(db *sql.DB) error {
 		query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS '%s'('key' VARCHAR(255) PRIMARY KEY, 'val' BLOB);", table)
 		_, err := db.Exec(query)
+		if err != nil || !ttl {
+			return err
+		}
+		_, err = db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s(tbl VARCHAR(255), key VARCHAR(255), expire INTEGER, PRIMARY KEY (tbl, key));", ttlTable))
 		return err
 	})
 	if err != nil {
@@ -137,32 +170,181 @@ func (kv *sqlKvStore) Get(key string, value interface{}) (bool, error) {
 func (kv *sqlKvStore) GetKeyedState(key string) (interface{}, error) {
 	var value interface{}
 	err := kv.database.Apply(func(db *sql.DB) error {
-		query := fmt.Sprintf("SELECT val FROM '%s' WHERE key=?;", kv.table)
-		stmt, err := db.Prepare(query)
+		if !kv.ttl {
+			return db.QueryRow(fmt.Sprintf("SELECT val FROM '%s' WHERE key=?;", kv.table), key).Scan(&value)
+		}
+		query := fmt.Sprintf("SELECT val FROM '%s' AS s WHERE s.key=? AND %s;", kv.table, notExpiredCond)
+		return db.QueryRow(query, key, kv.table, timex.GetNowInMilli()).Scan(&value)
+	})
+	return value, err
+}
+
+func (kv *sqlKvStore) SetKeyedState(key string, value interface{}) error {
+	return kv.setKeyedStateWithExpire(key, value, 0)
+}
+
+// SetKeyedStateWithTTL saves the expire time of the key in the ttl table. Expired keys are filtered out when reading
+// and deleted by SweepExpired
+func (kv *sqlTTLKvStore) SetKeyedStateWithTTL(key string, value interface{}, ttl time.Duration) error {
+	var expire int64
+	if ttl > 0 {
+		expire = timex.GetNow().Add(ttl).UnixMilli()
+	}
+	return kv.setKeyedStateWithExpire(key, value, expire)
+}
+
+func (kv *sqlKvStore) setKeyedStateWithExpire(key string, value interface{}, expire int64) error {
+	return kv.database.Apply(func(db *sql.DB) error {
+		tx, err := db.Begin()
 		if err != nil {
 			return err
 		}
-		row := stmt.QueryRow(key)
-		err = row.Scan(&value)
+		if err := kv.setKeyedState(tx, key, value, expire); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// setKeyedState sets the value and its expire time. An expire time of 0 means no expiry
+func (kv *sqlKvStore) setKeyedState(tx *sql.Tx, key string, value interface{}, expire int64) error {
+	if _, err := tx.Exec(fmt.Sprintf("REPLACE INTO '%s'(key,val) values(?,?);", kv.table), key, value); err != nil || !kv.ttl {
+		return err
+	}
+	var err error
+	if expire > 0 {
+		_, err = tx.Exec(fmt.Sprintf("REPLACE INTO %s(tbl,key,expire) values(?,?,?);", ttlTable), kv.table, key, expire)
+	} else {
+		_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE tbl=? AND key=?;", ttlTable), kv.table, key)
+	}
+	return err
+}
+
+// maxKeysPerQuery limits the keys in the IN list of one query below the variable limit of SQLite, which is 999
+// before 3.32.0
+const maxKeysPerQuery = 500
+
+// GetKeyedStates fetches the keys by one query for every maxKeysPerQuery keys
+func (kv *sqlKvStore) GetKeyedStates(keys []string) ([]interface{}, error) {
+	result := make([]interface{}, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	err := kv.database.Apply(func(db *sql.DB) error {
+		values := make(map[string]interface{}, len(keys))
+		for start := 0; start < len(keys); start += maxKeysPerQuery {
+			end := min(start+maxKeysPerQuery, len(keys))
+			if err := kv.queryKeyedStates(db, keys[start:end], values); err != nil {
+				return err
+			}
+		}
+		for i, k := range keys {
+			result[i] = values[k]
+		}
+		return nil
+	})
+	return result, err
+}
+
+// queryKeyedStates fetches the keys by one query and puts the found values into the values map
+func (kv *sqlKvStore) queryKeyedStates(db *sql.DB, keys []string, values map[string]interface{}) error {
+	query := fmt.Sprintf("SELECT s.key, s.val FROM '%s' AS s WHERE s.key IN (%s)", kv.table, strings.TrimSuffix(strings.Repeat("?,", len(keys)), ","))
+	args := make([]interface{}, 0, len(keys)+2)
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	if kv.ttl {
+		query += " AND " + notExpiredCond
+		args = append(args, kv.table, timex.GetNowInMilli())
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			key   string
+			value interface{}
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		values[key] = value
+	}
+	return rows.Err()
+}
+
+// SetKeyedStates sets all the states in one transaction
+func (kv *sqlKvStore) SetKeyedStates(states map[string]interface{}) error {
+	if len(states) == 0 {
+		return nil
+	}
+	return kv.database.Apply(func(db *sql.DB) error {
+		tx, err := db.Begin()
 		if err != nil {
 			return err
 		}
+		for k, v := range states {
+			if err := kv.setKeyedState(tx, k, v, 0); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// CommitKeyedStates sets the states and deletes the keys between BEGIN and COMMIT. The transaction is rolled back
+// if any of the changes fails
+func (kv *sqlKvStore) CommitKeyedStates(sets map[string]interface{}, deletes []string) error {
+	if len(sets) == 0 && len(deletes) == 0 {
 		return nil
+	}
+	return kv.database.Apply(func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		for k, v := range sets {
+			if err := kv.setKeyedState(tx, k, v, 0); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		for _, k := range deletes {
+			_, err := tx.Exec(fmt.Sprintf("DELETE FROM '%s' WHERE key=?;", kv.table), k)
+			if err == nil && kv.ttl {
+				_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE tbl=? AND key=?;", ttlTable), kv.table, k)
+			}
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		return tx.Commit()
 	})
-	return value, err
 }
 
-func (kv *sqlKvStore) SetKeyedState(key string, value interface{}) error {
-	err := kv.database.Apply(func(db *sql.DB) error {
-		query := fmt.Sprintf("REPLACE INTO '%s'(key,val) values(?,?);", kv.table)
-		stmt, err := db.Prepare(query)
+// SweepExpired deletes the expired keyed states of this table
+func (kv *sqlTTLKvStore) SweepExpired() error {
+	now := timex.GetNowInMilli()
+	return kv.database.Apply(func(db *sql.DB) error {
+		tx, err := db.Begin()
 		if err != nil {
 			return err
 		}
-		_, err = stmt.Exec(key, value)
-		return err
+		_, err = tx.Exec(fmt.Sprintf("DELETE FROM '%s' WHERE key IN (SELECT key FROM %s WHERE tbl=? AND expire<=?);", kv.table, ttlTable), kv.table, now)
+		if err == nil {
+			_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE tbl=? AND expire<=?;", ttlTable), kv.table, now)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		return tx.Commit()
 	})
-	return err
 }
 
 func (kv *sqlKvStore) Delete(key string) error {
@@ -175,6 +357,10 @@ func (kv *sqlKvStore) Delete(key string) error {
 			return errorx.NewWithCode(errorx.NOT_FOUND, fmt.Sprintf("%s is not found", key))
 		}
 		_, err = kv.preparedDeleteStmt.Exec(key)
+		if err != nil || !kv.ttl {
+			return err
+		}
+		_, err = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE tbl=? AND key=?;", ttlTable), kv.table, key)
 		return err
 	})
 }
@@ -237,6 +423,10 @@ func (kv *sqlKvStore) Clean() error {
 	return kv.database.Apply(func(db *sql.DB) error {
 		query := fmt.Sprintf("DELETE FROM '%s'", kv.table)
 		_, err := db.Exec(query)
+		if err != nil || !kv.ttl {
+			return err
+		}
+		_, err = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE tbl=?;", ttlTable), kv.table)
 		return err
 	})
 }
@@ -245,6 +435,10 @@ func (kv *sqlKvStore) Drop() error {
 	return kv.database.Apply(func(db *sql.DB) error {
 		query := fmt.Sprintf("Drop table '%s';", kv.table)
 		_, err := db.Exec(query)
+		if err != nil || !kv.ttl {
+			return err
+		}
+		_, err = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE tbl=?;", ttlTable), kv.table)
 		return err
 	})
 }
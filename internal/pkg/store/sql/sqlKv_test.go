@@ -15,6 +15,8 @@
 package sql
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -27,6 +29,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/sql/sqlite"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/test/common"
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
+	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
 
 const (
@@ -114,6 +117,69 @@ func TestSqlKvGetKeyedState(t *testing.T) {
 	common.TestKvGetKeyedState(ks, t)
 }
 
+func TestSqlKvKeyedStates(t *testing.T) {
+	ks, db, abs := setupSqlKv()
+	defer cleanSqlKv(db, abs)
+
+	common.TestKvKeyedStates(ks, t)
+}
+
+func TestSqlKvKeyedStatesChunked(t *testing.T) {
+	ks, db, abs := setupSqlKv()
+	defer cleanSqlKv(db, abs)
+
+	b := ks.(kv.KeyedStateBatcher)
+	states := make(map[string]interface{}, maxKeysPerQuery+10)
+	keys := make([]string, 0, maxKeysPerQuery+11)
+	expected := make([]interface{}, 0, maxKeysPerQuery+11)
+	for i := 0; i < maxKeysPerQuery+10; i++ {
+		k := fmt.Sprintf("key%d", i)
+		states[k] = fmt.Sprintf("val%d", i)
+		keys = append(keys, k)
+		expected = append(expected, states[k])
+	}
+	keys = append(keys, "none")
+	expected = append(expected, nil)
+	require.NoError(t, b.SetKeyedStates(states))
+	v, err := b.GetKeyedStates(keys)
+	require.NoError(t, err)
+	require.Equal(t, expected, v)
+}
+
+func TestSqlKvCommitKeyedStates(t *testing.T) {
+	ks, db, abs := setupSqlKv()
+	defer cleanSqlKv(db, abs)
+
+	common.TestKvCommitKeyedStates(ks, t)
+}
+
+func TestSqlKvKeyedStateTTL(t *testing.T) {
+	ks, db, abs := setupSqlKvTable(keyedStateTable)
+	defer cleanSqlKv(db, abs)
+
+	common.TestKvKeyedStateTTL(ks, timex.Add, t)
+	keys, err := ks.Keys()
+	require.NoError(t, err)
+	require.NotContains(t, keys, "short")
+	require.NoError(t, ks.Delete("long"))
+	require.NoError(t, ks.Clean())
+}
+
+func TestSqlKvWithoutTTL(t *testing.T) {
+	ks, db, abs := setupSqlKv()
+	defer cleanSqlKv(db, abs)
+
+	_, ok := ks.(kv.KeyedStateExpirer)
+	require.False(t, ok)
+	common.TestKvKeyedStates(ks, t)
+	require.NoError(t, ks.Clean())
+	var n int
+	require.NoError(t, db.(Database).Apply(func(sdb *sql.DB) error {
+		return sdb.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?;", ttlTable).Scan(&n)
+	}))
+	require.Equal(t, 0, n)
+}
+
 func TestInvalidTableName(t *testing.T) {
 	absPath, err := filepath.Abs("test")
 	require.NoError(t, err)
@@ -130,6 +196,7 @@ func TestInvalidTableName(t *testing.T) {
 	db, _ := sqlite.NewSqliteDatabase(config, "sqliteKV.db")
 	err = db.Connect()
 	require.NoError(t, err)
+	defer cleanSqlKv(db, absPath)
 	builder := NewStoreBuilder(db.(Database))
 	_, err = builder.CreateStore("1_abc")
 	require.EqualError(t, err, "invalid table name: 1_abc")
@@ -143,7 +210,32 @@ func deleteIfExists(abs string) error {
 	return nil
 }
 
+func TestSqlSchemaVersion(t *testing.T) {
+	_, db, abs := setupSqlKv()
+	defer cleanSqlKv(db, abs)
+	readVersion := func(d definition.Database) string {
+		var v string
+		require.NoError(t, d.(Database).Apply(func(sdb *sql.DB) error {
+			return sdb.QueryRow("SELECT version FROM __schema_version WHERE id = 0;").Scan(&v)
+		}))
+		return v
+	}
+	require.Equal(t, definition.SchemaVersion, readVersion(db))
+	// Simulate a store of an old version, the version should be migrated when connecting
+	require.NoError(t, db.(Database).Apply(func(sdb *sql.DB) error {
+		_, err := sdb.Exec("UPDATE __schema_version SET version = '0' WHERE id = 0;")
+		return err
+	}))
+	require.NoError(t, db.Disconnect())
+	require.NoError(t, db.Connect())
+	require.Equal(t, definition.SchemaVersion, readVersion(db))
+}
+
 func setupSqlKv() (kv.KeyValue, definition.Database, string) {
+	return setupSqlKvTable(STable)
+}
+
+func setupSqlKvTable(table string) (kv.KeyValue, definition.Database, string) {
 	absPath, err := filepath.Abs("test")
 	if err != nil {
 		panic(err)
@@ -169,7 +261,7 @@ func setupSqlKv() (kv.KeyValue, definition.Database, string) {
 
 	builder := NewStoreBuilder(db.(Database))
 	var store kv.KeyValue
-	store, err = builder.CreateStore(STable)
+	store, err = builder.CreateStore(table)
 	if err != nil {
 		panic(err)
 	}
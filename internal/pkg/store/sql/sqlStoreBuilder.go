@@ -18,6 +18,9 @@ import (
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
 
+// keyedStateTable is the table of the keyed states. It is the only table supporting ttl
+const keyedStateTable = "keyed_state"
+
 type StoreBuilder struct {
 	database Database
 }
@@ -29,5 +32,8 @@ func NewStoreBuilder(d Database) StoreBuilder {
 }
 
 func (b StoreBuilder) CreateStore(table string) (kv.KeyValue, error) {
+	if table == keyedStateTable {
+		return createSqlTTLKvStore(b.database, table)
+	}
 	return createSqlKvStore(b.database, table)
 }
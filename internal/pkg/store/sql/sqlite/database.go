@@ -16,6 +16,7 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -28,6 +29,9 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
 )
 
+// versionTable saves the schema version of the stored data
+const versionTable = "__schema_version"
+
 type Database struct {
 	db   *sql.DB
 	Path string
@@ -61,6 +65,33 @@ func (d *Database) Connect() error {
 	db.SetMaxOpenConns(1)
 	db.SetConnMaxLifetime(-1)
 	d.db = db
+	return d.checkVersion()
+}
+
+// checkVersion reads the stored schema version and migrates the data if it differs from the current one
+func (d *Database) checkVersion() error {
+	_, err := d.db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY CHECK (id = 0), version TEXT NOT NULL);", versionTable))
+	if err != nil {
+		return fmt.Errorf("fail to create schema version table: %v", err)
+	}
+	var stored string
+	err = d.db.QueryRow(fmt.Sprintf("SELECT version FROM %s WHERE id = 0;", versionTable)).Scan(&stored)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("fail to read schema version: %v", err)
+	}
+	if stored == definition.SchemaVersion {
+		return nil
+	}
+	if err := d.Migrate(stored, definition.SchemaVersion); err != nil {
+		return fmt.Errorf("fail to migrate store %s from version %s to %s: %v", d.Path, stored, definition.SchemaVersion, err)
+	}
+	_, err = d.db.Exec(fmt.Sprintf("INSERT OR REPLACE INTO %s (id, version) VALUES (0, ?);", versionTable), definition.SchemaVersion)
+	return err
+}
+
+// Migrate transforms the stored data between versions. No migration is needed for now.
+func (d *Database) Migrate(fromVersion, toVersion string) error {
+	logger.Log.Infof("migrate store %s from version %q to %q", d.Path, fromVersion, toVersion)
 	return nil
 }
 
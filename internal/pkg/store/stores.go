@@ -21,6 +21,7 @@ import (
 	"sync"
 
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/memory"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/sql"
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
@@ -30,6 +31,7 @@ type StoreCreator func(conf definition.Config, name string) (definition.StoreBui
 var (
 	storeBuilders = map[string]StoreCreator{
 		"sqlite": sql.BuildStores,
+		"memory": memory.BuildStores,
 	}
 	globalStores   *stores = nil
 	cacheStores    *stores = nil
@@ -44,6 +46,8 @@ type stores struct {
 	mu        sync.Mutex
 	kvBuilder definition.StoreBuilder
 	tsBuilder definition.TsBuilder
+	// metricsName is the store label of the metrics. The metrics are not collected if it is empty
+	metricsName string
 }
 
 func newStores(c definition.Config, name string) (*stores, error) {
@@ -54,11 +58,12 @@ func newStores(c definition.Config, name string) (*stores, error) {
 			return nil, err
 		} else {
 			return &stores{
-				kv:        make(map[string]kv.KeyValue),
-				ts:        make(map[string]kv.Tskv),
-				mu:        sync.Mutex{},
-				kvBuilder: kvBuilder,
-				tsBuilder: tsBuilder,
+				kv:          make(map[string]kv.KeyValue),
+				ts:          make(map[string]kv.Tskv),
+				mu:          sync.Mutex{},
+				kvBuilder:   kvBuilder,
+				tsBuilder:   tsBuilder,
+				metricsName: storeMetricsName(c, name),
 			}, nil
 		}
 	} else {
@@ -74,11 +79,12 @@ func newExtStateStores(c definition.Config, name string) (*stores, error) {
 			return nil, err
 		} else {
 			return &stores{
-				kv:        make(map[string]kv.KeyValue),
-				ts:        make(map[string]kv.Tskv),
-				mu:        sync.Mutex{},
-				kvBuilder: kvBuilder,
-				tsBuilder: tsBuilder,
+				kv:          make(map[string]kv.KeyValue),
+				ts:          make(map[string]kv.Tskv),
+				mu:          sync.Mutex{},
+				kvBuilder:   kvBuilder,
+				tsBuilder:   tsBuilder,
+				metricsName: storeMetricsName(c, name),
 			}, nil
 		}
 	} else {
@@ -86,6 +92,14 @@ func newExtStateStores(c definition.Config, name string) (*stores, error) {
 	}
 }
 
+// storeMetricsName returns the store label like sqliteKV for the database file sqliteKV.db
+func storeMetricsName(c definition.Config, name string) string {
+	if !c.Metrics {
+		return ""
+	}
+	return strings.TrimSuffix(name, ".db")
+}
+
 func (s *stores) GetKV(table string) (kv.KeyValue, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -96,6 +110,9 @@ func (s *stores) GetKV(table string) (kv.KeyValue, error) {
 	if err != nil {
 		return nil, err
 	}
+	if s.metricsName != "" {
+		ks = newMetricsKV(ks, s.metricsName)
+	}
 	s.kv[table] = ks
 	return ks, nil
 }
@@ -0,0 +1,48 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lf-edge/ekuiper/v2/internal/pkg/store/definition"
+)
+
+func TestSetupMemory(t *testing.T) {
+	err := Setup(definition.Config{
+		Type:         "memory",
+		ExtStateType: "memory",
+		Sqlite: definition.SqliteConfig{
+			Path: t.TempDir(),
+		},
+	})
+	require.NoError(t, err)
+	ks, err := GetKV("test")
+	require.NoError(t, err)
+	require.NoError(t, ks.Set("foo", "bar"))
+	var v string
+	ok, err := ks.Get("foo", &v)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "bar", v)
+	// The cache store is a different database
+	cks, err := GetCacheKV("test")
+	require.NoError(t, err)
+	ok, err = cks.Get("foo", &v)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/kv"
 )
@@ -113,6 +114,90 @@ func TestKvGetKeyedState(ks kv.KeyValue, t *testing.T) {
 	}
 }
 
+func TestKvKeyedStates(ks kv.KeyValue, t *testing.T) {
+	b, ok := ks.(kv.KeyedStateBatcher)
+	if !ok {
+		t.Fatal("should support batched keyed states")
+	}
+	if err := b.SetKeyedStates(map[string]interface{}{"foo": "bar", "baz": "qux"}); err != nil {
+		t.Error(err)
+	}
+	v, err := b.GetKeyedStates([]string{"baz", "none", "foo"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual([]interface{}{"qux", nil, "bar"}, v) {
+		t.Error("expect:[qux <nil> bar]", "get:", v)
+	}
+}
+
+func TestKvCommitKeyedStates(ks kv.KeyValue, t *testing.T) {
+	tx, ok := ks.(kv.KeyedStateTransactor)
+	if !ok {
+		t.Fatal("should support keyed state transaction")
+	}
+	if err := ks.SetKeyedState("old", "a"); err != nil {
+		t.Error(err)
+	}
+	if err := tx.CommitKeyedStates(map[string]interface{}{"foo": "bar", "baz": "qux"}, []string{"old", "none"}); err != nil {
+		t.Error(err)
+	}
+	v, err := ks.(kv.KeyedStateBatcher).GetKeyedStates([]string{"foo", "baz", "old"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual([]interface{}{"bar", "qux", nil}, v) {
+		t.Error("expect:[bar qux <nil>]", "get:", v)
+	}
+	if err := tx.CommitKeyedStates(nil, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestKvKeyedStateTTL checks the expiry of keyed states. The advance function moves the clock of the store forward
+func TestKvKeyedStateTTL(ks kv.KeyValue, advance func(d time.Duration), t *testing.T) {
+	e, ok := ks.(kv.KeyedStateExpirer)
+	if !ok {
+		t.Fatal("should support keyed states with ttl")
+	}
+	if err := e.SetKeyedStateWithTTL("short", "a", time.Second); err != nil {
+		t.Error(err)
+	}
+	if err := e.SetKeyedStateWithTTL("long", "b", time.Hour); err != nil {
+		t.Error(err)
+	}
+	if err := e.SetKeyedStateWithTTL("forever", "c", 0); err != nil {
+		t.Error(err)
+	}
+	// Set without ttl removes the ttl
+	if err := e.SetKeyedStateWithTTL("reset", "d", time.Second); err != nil {
+		t.Error(err)
+	}
+	if err := ks.SetKeyedState("reset", "e"); err != nil {
+		t.Error(err)
+	}
+	if v, err := ks.GetKeyedState("short"); err != nil || v != "a" {
+		t.Error("expect:a", "get:", v, err)
+	}
+	advance(2 * time.Second)
+	if v, err := ks.GetKeyedState("short"); err == nil {
+		t.Error("expect expired", "get:", v)
+	}
+	v, err := ks.(kv.KeyedStateBatcher).GetKeyedStates([]string{"short", "long", "forever", "reset"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual([]interface{}{nil, "b", "c", "e"}, v) {
+		t.Error("expect:[<nil> b c e]", "get:", v)
+	}
+	if err := e.SweepExpired(); err != nil {
+		t.Error(err)
+	}
+	if v, err := ks.GetKeyedState("long"); err != nil || v != "b" {
+		t.Error("expect:b", "get:", v, err)
+	}
+}
+
 func TestKvKeys(length int, ks kv.KeyValue, t *testing.T) {
 	expected := make([]string, 0)
 	for i := 0; i < length; i++ {
@@ -224,6 +224,7 @@ func clone(opt def.RuleOption) *def.RuleOption {
 		SendError:          opt.SendError,
 		Qos:                opt.Qos,
 		CheckpointInterval: opt.CheckpointInterval,
+		ShareKeyedState:    opt.ShareKeyedState,
 		RestartStrategy: &def.RestartStrategy{
 			Attempts:     opt.RestartStrategy.Attempts,
 			Delay:        opt.RestartStrategy.Delay,
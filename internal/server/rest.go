@@ -332,14 +332,25 @@ func explainRuleHandler(w http.ResponseWriter, r *http.Request) {
 		handleError(w, errors.New("only support explain sql now"), "explain rules error", logger)
 		return
 	}
-	var explainInfo string
-	explainInfo, err = planner.GetExplainInfoFromLogicalPlan(rule)
-	if err != nil {
-		handleError(w, err, "explain rules error", logger)
-		return
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "text":
+		var explainInfo string
+		explainInfo, err = planner.GetExplainInfoFromLogicalPlan(rule)
+		if err != nil {
+			handleError(w, err, "explain rules error", logger)
+			return
+		}
+		w.Write([]byte(explainInfo))
+	case "json":
+		tree, err := planner.GetExplainTreeFromLogicalPlan(rule)
+		if err != nil {
+			handleError(w, err, "explain rules error", logger)
+			return
+		}
+		jsonResponse(tree, w, logger)
+	default:
+		handleError(w, fmt.Errorf("unsupported explain format %s, expect text or json", format), "explain rules error", logger)
 	}
-	// resp := planner.BuildExplainResultFromLp(lp, 0)
-	w.Write([]byte(explainInfo))
 }
 
 func fileUploadHandler(w http.ResponseWriter, r *http.Request) {
@@ -38,6 +38,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
 	"github.com/lf-edge/ekuiper/v2/internal/processor"
 	"github.com/lf-edge/ekuiper/v2/internal/testx"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/planner"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/rule"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/connection"
@@ -433,6 +434,24 @@ func (suite *RestTestSuite) Test_rulesManageHandler() {
 	expect = "{\"error\":1002,\"message\":\"explain rules error: Rule rule32211 is not found.\"}\n"
 	assert.Equal(suite.T(), expect, returnStr)
 
+	// explain rule as json tree
+	req1, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/rule321/explain?format=json", bytes.NewBufferString("any"))
+	w1 = httptest.NewRecorder()
+	suite.r.ServeHTTP(w1, req1)
+	require.Equal(suite.T(), http.StatusOK, w1.Code)
+	returnVal, _ = io.ReadAll(w1.Result().Body)
+	tree := &planner.PlanExplainNode{}
+	require.NoError(suite.T(), json.Unmarshal(returnVal, tree))
+	require.Equal(suite.T(), "ProjectPlan_0", tree.Op)
+	require.Equal(suite.T(), "ProjectPlan", tree.Type)
+	require.Len(suite.T(), tree.Children, 1)
+	require.Equal(suite.T(), "DataSourcePlan", tree.Children[0].Type)
+
+	req1, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/rule321/explain?format=xml", bytes.NewBufferString("any"))
+	w1 = httptest.NewRecorder()
+	suite.r.ServeHTTP(w1, req1)
+	require.Equal(suite.T(), http.StatusBadRequest, w1.Code)
+
 	// get rule topo
 	req1, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/rules/rule321/topo", bytes.NewBufferString("any"))
 	w1 = httptest.NewRecorder()
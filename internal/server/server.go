@@ -132,6 +132,7 @@ func getStoreConfigByKuiperConfig(c *model.KuiperConf) (*store.StoreConf, error)
 		FdbConfig: definition.FdbConfig{
 			Path: c.Store.Fdb.Path,
 		},
+		Metrics: c.Store.Metrics,
 	}
 	return sc, nil
 }
@@ -145,6 +146,7 @@ func StartUp(Version string) {
 	if modules.ConfHook != nil {
 		modules.ConfHook(conf.Config)
 	}
+	function.SetRedactErrorArgs(conf.Config.Basic.RedactErrorArgs)
 	if conf.Config.Security != nil {
 		if conf.Config.Security.Encryption != nil {
 			encryptor.InitConf(conf.Config.Security.Encryption, conf.Config.AesKey)
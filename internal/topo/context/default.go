@@ -39,6 +39,9 @@ const (
 	RuleStartKey     = "$$ruleStart"
 	RuleWaitGroupKey = "$$ruleWaitGroup"
 	TraceStrategyKey = "$$TraceStrategyKey"
+	TimeZoneKey      = "$$timeZone"
+	// KeyedStateSharedKey is set if the keyed state of the rule is not namespaced by the rule id
+	KeyedStateSharedKey = "$$keyedStateShared"
 )
 
 const (
@@ -67,7 +70,6 @@ type DefaultContext struct {
 	snapshot map[string]interface{}
 	// cache
 	tpReg sync.Map
-	jpReg sync.Map
 }
 
 func RuleBackground(ruleName string) *DefaultContext {
@@ -205,18 +207,9 @@ func (c *DefaultContext) ParseTemplate(prop string, data interface{}) (string, e
 }
 
 func (c *DefaultContext) ParseJsonPath(prop string, data interface{}) (interface{}, error) {
-	var (
-		je  conf.JsonPathEval
-		err error
-	)
-	if raw, ok := c.jpReg.Load(prop); ok {
-		je = raw.(conf.JsonPathEval)
-	} else {
-		je, err = conf.GetJsonPathEval(prop)
-		if err != nil {
-			return nil, err
-		}
-		c.jpReg.Store(prop, je)
+	je, err := conf.GetCachedJsonPathEval(prop)
+	if err != nil {
+		return nil, err
 	}
 	return je.Eval(data)
 }
@@ -234,7 +227,6 @@ func (c *DefaultContext) WithMeta(ruleId string, opId string, store api.Store) a
 		store:          store,
 		state:          s,
 		tpReg:          sync.Map{},
-		jpReg:          sync.Map{},
 		isTraceEnabled: c.isTraceEnabled,
 		strategy:       c.strategy,
 	}
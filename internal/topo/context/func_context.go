@@ -32,6 +32,14 @@ func NewDefaultFuncContext(ctx api.StreamContext, id int) *DefaultFuncContext {
 	}
 }
 
+// Value returns nil if the function runs without a parent context, such as in the operator unit tests
+func (c *DefaultFuncContext) Value(key any) any {
+	if c.StreamContext == nil {
+		return nil
+	}
+	return c.StreamContext.Value(key)
+}
+
 func (c *DefaultFuncContext) IncrCounter(key string, amount int) error {
 	return c.StreamContext.IncrCounter(c.convertKey(key), amount)
 }
@@ -25,6 +25,8 @@ type Filter struct {
 type Select struct {
 	Fields []string `json:"fields"`
 	IsAgg  bool     `json:"isAgg"`
+	// SendNil emits explicit null for the selected fields which evaluate to nil
+	SendNil bool `json:"sendNil"`
 }
 
 type Watermark struct {
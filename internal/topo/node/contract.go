@@ -47,6 +47,11 @@ type MetricNode interface {
 	RemoveMetrics(ruleId string)
 }
 
+// ExtraMetricNode reports additional metrics besides the common ones. The keys are not prefixed.
+type ExtraMetricNode interface {
+	ExtraMetrics() ([]string, []any)
+}
+
 type OperatorNode interface {
 	DataSinkNode
 	Emitter
@@ -56,6 +56,14 @@ func (o *UnaryOperator) SetOperation(op UnOperation) {
 	o.op = op
 }
 
+// ExtraMetrics returns the additional metrics of the operation if it has any
+func (o *UnaryOperator) ExtraMetrics() ([]string, []any) {
+	if en, ok := o.op.(ExtraMetricNode); ok {
+		return en.ExtraMetrics()
+	}
+	return nil, nil
+}
+
 // Exec is the entry point for the executor
 func (o *UnaryOperator) Exec(ctx api.StreamContext, errCh chan<- error) {
 	o.prepareExec(ctx, errCh, "op")
@@ -121,6 +121,30 @@ func TestChangedColsFunc_Apply1(t *testing.T) {
 			}}, {{}}, {{
 				"c": "c2",
 			}}},
+		}, {
+			sql: `SELECT id, array_to_fields(temps, "temp") FROM test`,
+			data: []interface{}{
+				&xsql.Tuple{
+					Emitter: "test",
+					Message: xsql.Message{
+						"id":    1,
+						"temps": []interface{}{20.5, 21},
+					},
+				},
+				&xsql.Tuple{
+					Emitter: "test",
+					Message: xsql.Message{
+						"id": 2,
+					},
+				},
+			},
+			result: [][]map[string]interface{}{{{
+				"id":     1,
+				"temp_0": 20.5,
+				"temp_1": 21,
+			}}, {{
+				"id": 2,
+			}}},
 		},
 	}
 
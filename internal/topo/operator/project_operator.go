@@ -15,8 +15,14 @@
 package operator
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/lf-edge/ekuiper/contract/v2/api"
 
 	"github.com/lf-edge/ekuiper/v2/internal/binder/function"
@@ -31,9 +37,14 @@ type ProjectOp struct {
 	ExceptNames      []string   // list of except name
 	AllWildcard      bool
 	WildcardEmitters map[string]bool
+	// WildcardPrefixes renames the columns of the streams selected by wildcard with the prefix of each stream
+	WildcardPrefixes map[string]string
 	AliasFields      ast.Fields
 	ExprFields       ast.Fields
-	Fields           ast.Fields
+	// InvariantFields is the name set of the expression and alias fields which do not depend on the row.
+	// They are evaluated once and the results are reused in the rule run.
+	InvariantFields map[string]bool
+	Fields          ast.Fields
 	// the length of fields exclude invisible
 	FieldLen    int
 	IsAggregate bool // Whether the project is used in an aggregate context. This is set by planner by analyzing the SQL query
@@ -42,9 +53,23 @@ type ProjectOp struct {
 
 	SendMeta bool
 	SendNil  bool
+	// SendSchema attaches a map of the projected field name to its runtime type to the output
+	SendSchema bool
+	// Distinct suppresses the duplicate output rows. The rows of a collection are deduplicated within the collection.
+	// The single rows are deduplicated against the recently seen rows whose count is bounded by DistinctCacheSize.
+	Distinct          bool
+	DistinctCacheSize int
+	// PassThrough indicates a plain SELECT * without except, replace or any expression fields.
+	// The row can be emitted as is without evaluating the fields.
+	PassThrough bool
+	// ProfileFields enables the per-field evaluation time recording. It is only for debugging.
+	ProfileFields bool
 
-	kvs   []interface{}
-	alias []interface{}
+	kvs        []interface{}
+	alias      []interface{}
+	profile    fieldProfile
+	seen       *lru.Cache[[sha256.Size]byte, struct{}]
+	invariants map[string]interface{}
 }
 
 // Apply
@@ -55,6 +80,7 @@ type ProjectOp struct {
 func (pp *ProjectOp) Apply(ctx api.StreamContext, data interface{}, fv *xsql.FunctionValuer, afv *xsql.AggregateFunctionValuer) interface{} {
 	log := ctx.GetLogger()
 	log.Debugf("project plan receive %v", data)
+	// LIMIT 0 emits no rows. Negative limit is rejected by the planner.
 	if pp.LimitCount == 0 && pp.EnableLimit {
 		return []xsql.Row{}
 	}
@@ -62,58 +88,190 @@ func (pp *ProjectOp) Apply(ctx api.StreamContext, data interface{}, fv *xsql.Fun
 	case error:
 		return input
 	case xsql.Row:
+		if pp.PassThrough {
+			// Only drop the calculated columns, the message itself is kept untouched
+			input.Pick(true, nil, nil, nil, pp.SendNil)
+			if pp.Distinct && pp.isDuplicate(input.ToMap()) {
+				return nil
+			}
+			pp.attachSchema(input)
+			pp.attachMeta(input)
+			return data
+		}
 		ve := pp.getRowVE(input, nil, fv, afv)
 		if err := pp.project(input, ve); err != nil {
 			return fmt.Errorf("run Select error: %s", err)
-		} else {
-			if pp.SendMeta {
-				if md, ok := input.(xsql.MetaData); ok {
-					metadata := md.MetaData()
-					if metadata != nil {
-						input.Set(message.MetaKey, md.MetaData())
-					}
-				}
-			}
 		}
+		if pp.Distinct && pp.isDuplicate(input.ToMap()) {
+			return nil
+		}
+		pp.attachSchema(input)
+		pp.attachMeta(input)
 	case xsql.Collection:
 		var err error
+		// The limit is applied after deduplication for distinct
+		limit := pp.EnableLimit && pp.LimitCount > 0 && !pp.Distinct
 		if pp.IsAggregate {
 			input.SetIsAgg(true)
 			err = input.GroupRange(func(i int, aggRow xsql.CollectionRow) (bool, error) {
-				if pp.EnableLimit && pp.LimitCount > 0 && i >= pp.LimitCount {
+				if limit && i >= pp.LimitCount {
 					return false, nil
 				}
 				ve := pp.getVE(aggRow, aggRow, input.GetWindowRange(), fv, afv)
 				if err := pp.project(aggRow, ve); err != nil {
 					return false, fmt.Errorf("run Select error: %s", err)
 				}
+				pp.attachSchema(aggRow)
 				return true, nil
 			})
+			// Drop the groups beyond the limit which are not projected
+			if gs, ok := input.(*xsql.GroupedTuplesSet); ok && err == nil && limit && gs.Len() > pp.LimitCount {
+				gs.Filter(firstN(pp.LimitCount))
+			}
 		} else {
+			if limit && input.Len() > pp.LimitCount {
+				input = input.Filter(firstN(pp.LimitCount))
+			}
 			err = input.RangeSet(func(i int, row xsql.Row) (bool, error) {
-				if pp.EnableLimit && pp.LimitCount > 0 && i >= pp.LimitCount {
-					return false, nil
-				}
 				aggData, ok := input.(xsql.AggregateData)
 				if !ok {
 					return false, fmt.Errorf("unexpected type, cannot find aggregate data")
 				}
 				ve := pp.getVE(row, aggData, input.GetWindowRange(), fv, afv)
+				ve.Valuer = xsql.MultiValuer(&xsql.WindowIndexValuer{Index: i}, ve.Valuer)
 				if err := pp.project(row, ve); err != nil {
 					return false, fmt.Errorf("run Select error: %s", err)
 				}
+				pp.attachSchema(row)
 				return true, nil
 			})
 		}
 		if err != nil {
 			return err
 		}
+		if pp.Distinct {
+			return pp.distinctCollection(input)
+		}
 	default:
 		return fmt.Errorf("run Select error: invalid input %[1]T(%[1]v)", input)
 	}
 	return data
 }
 
+// isDuplicate checks the projected row against the recently seen rows and records it
+func (pp *ProjectOp) isDuplicate(m map[string]interface{}) bool {
+	if pp.seen == nil {
+		size := pp.DistinctCacheSize
+		if size <= 0 {
+			size = defaultDistinctCacheSize
+		}
+		pp.seen, _ = lru.New[[sha256.Size]byte, struct{}](size)
+	}
+	found, _ := pp.seen.ContainsOrAdd(fingerprint(m), struct{}{})
+	return found
+}
+
+// distinctCollection keeps the first of the duplicate rows or groups in the collection and applies the limit
+func (pp *ProjectOp) distinctCollection(input xsql.Collection) xsql.Collection {
+	maps := input.ToMaps()
+	seen := make(map[[sha256.Size]byte]struct{}, len(maps))
+	sel := make([]int, 0, len(maps))
+	for i, m := range maps {
+		if pp.EnableLimit && pp.LimitCount > 0 && len(sel) >= pp.LimitCount {
+			break
+		}
+		fp := fingerprint(m)
+		if _, ok := seen[fp]; !ok {
+			seen[fp] = struct{}{}
+			sel = append(sel, i)
+		}
+	}
+	if len(sel) == len(maps) {
+		return input
+	}
+	return input.Filter(sel)
+}
+
+// defaultDistinctCacheSize is the count of the recently seen rows to deduplicate the rows without window
+const defaultDistinctCacheSize = 1024
+
+// fingerprint identifies a row by its canonical json so that the equal nested values of different go types are the
+// same. The values which cannot be marshalled to json are printed in the go syntax instead.
+func fingerprint(m map[string]interface{}) [sha256.Size]byte {
+	b, err := function.CanonicalJSON(m)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%#v", m))
+	}
+	return sha256.Sum256(b)
+}
+
+func firstN(n int) []int {
+	sel := make([]int, n)
+	for i := range sel {
+		sel[i] = i
+	}
+	return sel
+}
+
+func (pp *ProjectOp) attachMeta(row xsql.Row) {
+	if pp.SendMeta {
+		if md, ok := row.(xsql.MetaData); ok {
+			metadata := md.MetaData()
+			if metadata != nil {
+				row.Set(message.MetaKey, metadata)
+			}
+		}
+	}
+}
+
+// attachSchema sets the type of each projected field derived from its runtime value.
+// It must run before attachMeta so that the metadata is not included in the schema.
+func (pp *ProjectOp) attachSchema(row xsql.RawRow) {
+	if pp.SendSchema {
+		values := row.ToMap()
+		schema := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			schema[k] = runtimeTypeName(v)
+		}
+		row.Set(message.SchemaKey, schema)
+	}
+}
+
+// runtimeTypeName returns the stream data type name of the value. Nil values are reported as null.
+func runtimeTypeName(v interface{}) string {
+	var dt ast.DataType
+	switch v.(type) {
+	case nil, *cast.TypedNil:
+		return "null"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		dt = ast.BIGINT
+	case float32, float64:
+		dt = ast.FLOAT
+	case string:
+		dt = ast.STRINGS
+	case []byte:
+		dt = ast.BYTEA
+	case time.Time:
+		dt = ast.DATETIME
+	case bool:
+		dt = ast.BOOLEAN
+	case []interface{}, []map[string]interface{}:
+		dt = ast.ARRAY
+	case map[string]interface{}:
+		dt = ast.STRUCT
+	default:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Slice, reflect.Array:
+			dt = ast.ARRAY
+		case reflect.Map, reflect.Struct:
+			dt = ast.STRUCT
+		default:
+			return "unknown"
+		}
+	}
+	return dt.String()
+}
+
 func (pp *ProjectOp) getVE(tuple xsql.RawRow, agg xsql.AggregateData, wr *xsql.WindowRange, fv *xsql.FunctionValuer, afv *xsql.AggregateFunctionValuer) *xsql.ValuerEval {
 	afv.SetData(agg)
 	if pp.IsAggregate {
@@ -138,7 +296,7 @@ func (pp *ProjectOp) project(row xsql.RawRow, ve *xsql.ValuerEval) error {
 	switch rt := row.(type) {
 	case *xsql.SliceTuple:
 		for _, f := range pp.AliasFields {
-			vi := ve.Eval(f.Expr)
+			vi := pp.eval(ve, &f)
 			if e, ok := vi.(error); ok {
 				return fmt.Errorf("expr: %s meet error, err:%v", f.Expr.String(), e)
 			}
@@ -152,7 +310,7 @@ func (pp *ProjectOp) project(row xsql.RawRow, ve *xsql.ValuerEval) error {
 		}
 		for _, f := range pp.Fields {
 			if f.AName == "" {
-				vi := ve.Eval(f.Expr)
+				vi := pp.eval(ve, &f)
 				if e, ok := vi.(error); ok {
 					return fmt.Errorf("expr: %s meet error, err:%v", f.Expr.String(), e)
 				}
@@ -176,23 +334,26 @@ func (pp *ProjectOp) project(row xsql.RawRow, ve *xsql.ValuerEval) error {
 			if f.Invisible {
 				continue
 			}
-			vi := ve.Eval(f.Expr)
+			vi := pp.evalField(ve, &f)
 			if e, ok := vi.(error); ok {
 				return fmt.Errorf("expr: %s meet error, err:%v", f.Expr.String(), e)
 			}
-			if vi != nil {
-				switch vt := vi.(type) {
-				case function.ResultCols:
-					for k, v := range vt {
-						pp.kvs = append(pp.kvs, k, v)
-					}
-				default:
-					pp.kvs = append(pp.kvs, f.Name, vi)
+			switch vt := vi.(type) {
+			case nil:
+				// emit explicit null so that optional fields do not vanish from the output
+				if pp.SendNil {
+					pp.kvs = append(pp.kvs, f.Name, nil)
 				}
+			case function.ResultCols:
+				for k, v := range vt {
+					pp.kvs = append(pp.kvs, k, v)
+				}
+			default:
+				pp.kvs = append(pp.kvs, f.Name, vi)
 			}
 		}
 		for _, f := range pp.AliasFields {
-			vi := ve.Eval(f.Expr)
+			vi := pp.evalField(ve, &f)
 			if e, ok := vi.(error); ok {
 				if ref, ok := f.Expr.(*ast.FieldRef); ok {
 					s := ref.AliasRef.Expression.String()
@@ -205,6 +366,11 @@ func (pp *ProjectOp) project(row xsql.RawRow, ve *xsql.ValuerEval) error {
 			}
 		}
 		row.Pick(pp.AllWildcard, pp.ColNames, pp.WildcardEmitters, pp.ExceptNames, pp.SendNil)
+		if len(pp.WildcardPrefixes) > 0 {
+			if cp, ok := row.(xsql.ColumnPrefixer); ok {
+				cp.PrefixColumns(pp.WildcardPrefixes)
+			}
+		}
 		for i := 0; i < len(pp.kvs); i += 2 {
 			row.Set(pp.kvs[i].(string), pp.kvs[i+1])
 		}
@@ -216,3 +382,91 @@ func (pp *ProjectOp) project(row xsql.RawRow, ve *xsql.ValuerEval) error {
 	}
 	return nil
 }
+
+// evalField evaluates the field. The result of an invariant field is evaluated once and cached unless it is an error.
+func (pp *ProjectOp) evalField(ve *xsql.ValuerEval, f *ast.Field) interface{} {
+	name := f.GetName()
+	if !pp.InvariantFields[name] {
+		return pp.eval(ve, f)
+	}
+	if vi, ok := pp.invariants[name]; ok {
+		return vi
+	}
+	vi := pp.eval(ve, f)
+	if _, ok := vi.(error); !ok {
+		if pp.invariants == nil {
+			pp.invariants = make(map[string]interface{})
+		}
+		pp.invariants[name] = vi
+	}
+	return vi
+}
+
+func (pp *ProjectOp) eval(ve *xsql.ValuerEval, f *ast.Field) interface{} {
+	if !pp.ProfileFields {
+		return ve.Eval(f.Expr)
+	}
+	start := time.Now()
+	vi := ve.Eval(f.Expr)
+	pp.profile.add(f.GetName(), time.Since(start))
+	return vi
+}
+
+// ExtraMetrics reports the accumulated evaluation time of the most expensive fields if profiling is enabled
+func (pp *ProjectOp) ExtraMetrics() ([]string, []any) {
+	if !pp.ProfileFields {
+		return nil, nil
+	}
+	costs := pp.profile.top(profileTopN)
+	keys := make([]string, 0, len(costs))
+	values := make([]any, 0, len(costs))
+	for _, c := range costs {
+		keys = append(keys, "field_cost_us_"+c.Name)
+		values = append(values, c.Cost.Microseconds())
+	}
+	return keys, values
+}
+
+// profileTopN is the count of the most expensive fields to report
+const profileTopN = 5
+
+type fieldCost struct {
+	Name string
+	Cost time.Duration
+}
+
+// fieldProfile accumulates the evaluation time of each projected field.
+// It is updated in the operator goroutine and read by the metrics reporter, so it is guarded by a lock.
+type fieldProfile struct {
+	sync.Mutex
+	costs map[string]time.Duration
+}
+
+func (p *fieldProfile) add(name string, d time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+	if p.costs == nil {
+		p.costs = make(map[string]time.Duration)
+	}
+	p.costs[name] += d
+}
+
+// top returns at most n fields sorted by the accumulated cost descending
+func (p *fieldProfile) top(n int) []fieldCost {
+	p.Lock()
+	result := make([]fieldCost, 0, len(p.costs))
+	for name, c := range p.costs {
+		result = append(result, fieldCost{Name: name, Cost: c})
+	}
+	p.Unlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cost == result[j].Cost {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Cost > result[j].Cost
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
@@ -20,6 +20,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -3218,6 +3219,417 @@ func TestProjectPlan_SendNil(t *testing.T) {
 	}
 }
 
+func TestProjectPlan_SendNilExpr(t *testing.T) {
+	tests := []struct {
+		sql     string
+		data    *xsql.Tuple
+		result  map[string]interface{}
+		nResult map[string]interface{}
+	}{
+		{
+			sql: "SELECT a, b as c, coalesce(b) FROM test",
+			data: &xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{
+					"a": map[string]interface{}{"e": 1},
+				},
+			},
+			result: map[string]interface{}{
+				"a":        map[string]interface{}{"e": 1},
+				"c":        nil,
+				"coalesce": nil,
+			},
+			nResult: map[string]interface{}{
+				"a": map[string]interface{}{"e": 1},
+			},
+		},
+		{
+			sql: "SELECT *, b as c FROM test",
+			data: &xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{
+					"a": 1,
+				},
+			},
+			result: map[string]interface{}{
+				"a": 1,
+				"c": nil,
+			},
+			nResult: map[string]interface{}{
+				"a": 1,
+			},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_SendNilExpr")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			for _, sendNil := range []bool{true, false} {
+				stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+				require.NoError(t, err)
+				pp := &ProjectOp{SendNil: sendNil}
+				parseStmt(pp, stmt.Fields)
+				fv, afv := xsql.NewFunctionValuersForOp(nil)
+				opResult := pp.Apply(ctx, tt.data.Clone(), fv, afv)
+				result, err := parseResult(opResult, pp.IsAggregate)
+				require.NoError(t, err)
+				if sendNil {
+					require.Equal(t, []map[string]interface{}{tt.result}, result)
+				} else {
+					require.Equal(t, []map[string]interface{}{tt.nResult}, result)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectPlan_PassThrough(t *testing.T) {
+	tests := []struct {
+		name     string
+		sendMeta bool
+		sendNil  bool
+		data     *xsql.Tuple
+		result   []map[string]interface{}
+	}{
+		{
+			name: "plain",
+			data: &xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{
+					"a": "val_a",
+					"b": nil,
+				},
+				Metadata: xsql.Metadata{
+					"id": 45,
+				},
+				AffiliateRow: xsql.AffiliateRow{
+					CalCols: map[string]interface{}{"$$a": 1},
+				},
+			},
+			result: []map[string]interface{}{{
+				"a": "val_a",
+				"b": nil,
+			}},
+		},
+		{
+			name:     "sendMeta",
+			sendMeta: true,
+			sendNil:  true,
+			data: &xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{
+					"a": "val_a",
+				},
+				Metadata: xsql.Metadata{
+					"id": 45,
+				},
+			},
+			result: []map[string]interface{}{{
+				"a": "val_a",
+				"__meta": xsql.Metadata{
+					"id": 45,
+				},
+			}},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_PassThrough")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader("SELECT * FROM test")).Parse()
+			require.NoError(t, err)
+			pp := &ProjectOp{SendMeta: tt.sendMeta, SendNil: tt.sendNil, PassThrough: true}
+			parseStmt(pp, stmt.Fields)
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			opResult := pp.Apply(ctx, tt.data, fv, afv)
+			result, err := parseResult(opResult, pp.IsAggregate)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestProjectPlan_SendSchema(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	tests := []struct {
+		name     string
+		sql      string
+		sendMeta bool
+		data     interface{}
+		result   []map[string]interface{}
+	}{
+		{
+			name: "row",
+			sql:  "SELECT a, b, c * 2 AS d, e, f, g, h, ts FROM test",
+			data: &xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{
+					"a":  "val_a",
+					"b":  1.5,
+					"c":  2,
+					"e":  nil,
+					"f":  true,
+					"g":  []interface{}{1, 2},
+					"h":  map[string]interface{}{"x": 1},
+					"ts": now,
+				},
+			},
+			result: []map[string]interface{}{{
+				"a":  "val_a",
+				"b":  1.5,
+				"d":  int64(4),
+				"e":  nil,
+				"f":  true,
+				"g":  []interface{}{1, 2},
+				"h":  map[string]interface{}{"x": 1},
+				"ts": now,
+				"__schema__": map[string]interface{}{
+					"a":  "string",
+					"b":  "float",
+					"d":  "bigint",
+					"e":  "null",
+					"f":  "boolean",
+					"g":  "array",
+					"h":  "struct",
+					"ts": "datetime",
+				},
+			}},
+		},
+		{
+			name:     "with meta",
+			sql:      "SELECT a FROM test",
+			sendMeta: true,
+			data: &xsql.Tuple{
+				Emitter:  "test",
+				Message:  xsql.Message{"a": []byte("val_a")},
+				Metadata: xsql.Metadata{"id": 45},
+			},
+			result: []map[string]interface{}{{
+				"a":          []byte("val_a"),
+				"__schema__": map[string]interface{}{"a": "bytea"},
+				"__meta":     xsql.Metadata{"id": 45},
+			}},
+		},
+		{
+			name: "window",
+			sql:  "SELECT id1 FROM src1 GROUP BY TUMBLINGWINDOW(ss, 10)",
+			data: &xsql.WindowTuples{
+				Content: []xsql.Row{
+					&xsql.Tuple{Emitter: "src1", Message: xsql.Message{"id1": 1}},
+					&xsql.Tuple{Emitter: "src1", Message: xsql.Message{"id1": "2"}},
+				},
+			},
+			result: []map[string]interface{}{{
+				"id1":        1,
+				"__schema__": map[string]interface{}{"id1": "bigint"},
+			}, {
+				"id1":        "2",
+				"__schema__": map[string]interface{}{"id1": "string"},
+			}},
+		},
+		{
+			name: "aggregate",
+			sql:  "SELECT count(*) AS c, max(id1) AS m FROM src1 GROUP BY TUMBLINGWINDOW(ss, 10)",
+			data: &xsql.WindowTuples{
+				Content: []xsql.Row{
+					&xsql.Tuple{Emitter: "src1", Message: xsql.Message{"id1": 1.5}},
+					&xsql.Tuple{Emitter: "src1", Message: xsql.Message{"id1": 2.5}},
+				},
+			},
+			result: []map[string]interface{}{{
+				"c":          2,
+				"m":          2.5,
+				"__schema__": map[string]interface{}{"c": "bigint", "m": "float"},
+			}},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_SendSchema")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+			require.NoError(t, err)
+			pp := &ProjectOp{SendMeta: tt.sendMeta, SendNil: true, SendSchema: true, IsAggregate: xsql.WithAggFields(stmt)}
+			parseStmt(pp, stmt.Fields)
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			opResult := pp.Apply(ctx, tt.data, fv, afv)
+			result, err := parseResult(opResult, pp.IsAggregate)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestProjectPlan_WildcardPrefix(t *testing.T) {
+	src1 := &xsql.Tuple{Emitter: "src1", Message: xsql.Message{"id": 1, "temp": 20}}
+	src2 := &xsql.Tuple{Emitter: "src2", Message: xsql.Message{"id": 1, "temp": 30}}
+	tests := []struct {
+		name     string
+		sql      string
+		prefixes map[string]string
+		data     interface{}
+		result   []map[string]interface{}
+	}{
+		{
+			name:     "row",
+			sql:      "SELECT *, temp + 1 AS t FROM src1",
+			prefixes: map[string]string{"src1": "p_"},
+			data:     src1.Clone(),
+			result:   []map[string]interface{}{{"p_id": 1, "p_temp": 20, "t": int64(21)}},
+		},
+		{
+			name:     "join with one wildcard emitter",
+			sql:      "SELECT src1.*, src2.temp FROM src1 INNER JOIN src2 ON src1.id = src2.id GROUP BY TUMBLINGWINDOW(ss, 10)",
+			prefixes: map[string]string{"src1": "src1_"},
+			data: &xsql.JoinTuples{
+				Content: []*xsql.JoinTuple{{Tuples: []xsql.Row{src1, src2}}},
+			},
+			result: []map[string]interface{}{{"src1_id": 1, "src1_temp": 20, "temp": 30}},
+		},
+		{
+			name:     "join with all wildcard",
+			sql:      "SELECT * FROM src1 INNER JOIN src2 ON src1.id = src2.id GROUP BY TUMBLINGWINDOW(ss, 10)",
+			prefixes: map[string]string{"src1": "src1_", "src2": "src2_"},
+			data: &xsql.JoinTuples{
+				Content: []*xsql.JoinTuple{{Tuples: []xsql.Row{src1, src2}}},
+			},
+			result: []map[string]interface{}{{"src1_id": 1, "src1_temp": 20, "src2_id": 1, "src2_temp": 30}},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_WildcardPrefix")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+			require.NoError(t, err)
+			pp := &ProjectOp{}
+			parseStmt(pp, stmt.Fields)
+			pp.WildcardPrefixes = tt.prefixes
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			opResult := pp.Apply(ctx, tt.data, fv, afv)
+			result, err := parseResult(opResult, pp.IsAggregate)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+	// the shared messages are not changed
+	require.Equal(t, xsql.Message{"id": 1, "temp": 20}, src1.Message)
+	require.Equal(t, xsql.Message{"id": 1, "temp": 30}, src2.Message)
+}
+
+func TestProjectPlan_Distinct(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_Distinct")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	newOp := func(sql string, cacheSize int) *ProjectOp {
+		stmt, err := xsql.NewParser(strings.NewReader(sql)).Parse()
+		require.NoError(t, err)
+		require.True(t, stmt.Distinct)
+		pp := &ProjectOp{Distinct: true, DistinctCacheSize: cacheSize, IsAggregate: xsql.WithAggFields(stmt)}
+		if lc, ok := stmt.Limit.(*ast.LimitExpr); ok {
+			pp.EnableLimit = true
+			pp.LimitCount = int(lc.LimitCount.Val)
+		}
+		parseStmt(pp, stmt.Fields)
+		return pp
+	}
+	fv, afv := xsql.NewFunctionValuersForOp(nil)
+
+	t.Run("rows", func(t *testing.T) {
+		pp := newOp("SELECT DISTINCT a FROM test", 2)
+		var result []interface{}
+		for _, v := range []interface{}{1, "1", 1, 2, 1, 3, 1} {
+			r := pp.Apply(ctx, &xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": v, "b": v}, Metadata: xsql.Metadata{"v": v}}, fv, afv)
+			if r != nil {
+				result = append(result, r.(xsql.Row).ToMap()["a"])
+			}
+		}
+		// the cache holds the 2 recently seen rows, so 1 is emitted again after it is evicted by "1" and 2
+		require.Equal(t, []interface{}{1, "1", 2, 1, 3}, result)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		pp := newOp("SELECT DISTINCT a FROM test", 0)
+		var result []interface{}
+		for _, v := range []interface{}{
+			map[string]interface{}{"x": 1, "y": []interface{}{1, 2}},
+			map[interface{}]interface{}{"y": []interface{}{1, 2}, "x": 1},
+			map[string]interface{}{"x": 1, "y": []interface{}{2, 1}},
+		} {
+			r := pp.Apply(ctx, &xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": v}}, fv, afv)
+			if r != nil {
+				result = append(result, r.(xsql.Row).ToMap()["a"])
+			}
+		}
+		// the map types do not matter but the array order does
+		require.Len(t, result, 2)
+	})
+
+	t.Run("window", func(t *testing.T) {
+		pp := newOp("SELECT DISTINCT a FROM test GROUP BY TUMBLINGWINDOW(ss, 10) LIMIT 2", 0)
+		data := &xsql.WindowTuples{
+			Content: []xsql.Row{
+				&xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": 1, "b": 1}},
+				&xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": 1, "b": 2}},
+				&xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": 2, "b": 3}},
+				&xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": 3, "b": 4}},
+			},
+		}
+		result, err := parseResult(pp.Apply(ctx, data, fv, afv), pp.IsAggregate)
+		require.NoError(t, err)
+		require.Equal(t, []map[string]interface{}{{"a": 1}, {"a": 2}}, result)
+		// the duplicates of different windows are not suppressed
+		data = &xsql.WindowTuples{
+			Content: []xsql.Row{
+				&xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": 1}},
+			},
+		}
+		result, err = parseResult(pp.Apply(ctx, data, fv, afv), pp.IsAggregate)
+		require.NoError(t, err)
+		require.Equal(t, []map[string]interface{}{{"a": 1}}, result)
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		pp := newOp("SELECT DISTINCT count(*) AS c FROM test GROUP BY b, TUMBLINGWINDOW(ss, 10)", 0)
+		data := &xsql.GroupedTuplesSet{
+			Groups: []*xsql.GroupedTuples{
+				{Content: []xsql.Row{&xsql.Tuple{Emitter: "test", Message: xsql.Message{"b": 1}}}},
+				{Content: []xsql.Row{&xsql.Tuple{Emitter: "test", Message: xsql.Message{"b": 2}}}},
+				{Content: []xsql.Row{
+					&xsql.Tuple{Emitter: "test", Message: xsql.Message{"b": 3}},
+					&xsql.Tuple{Emitter: "test", Message: xsql.Message{"b": 3}},
+				}},
+			},
+		}
+		result, err := parseResult(pp.Apply(ctx, data, fv, afv), pp.IsAggregate)
+		require.NoError(t, err)
+		require.Equal(t, []map[string]interface{}{{"c": 1}, {"c": 2}}, result)
+	})
+}
+
+func TestRuntimeTypeName(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		name string
+	}{
+		{nil, "null"},
+		{cast.TNil, "null"},
+		{int32(1), "bigint"},
+		{uint64(1), "bigint"},
+		{float32(1), "float"},
+		{"a", "string"},
+		{[]byte("a"), "bytea"},
+		{time.Now(), "datetime"},
+		{false, "boolean"},
+		{[]map[string]interface{}{}, "array"},
+		{[]string{"a"}, "array"},
+		{map[string]int{"a": 1}, "struct"},
+		{make(chan int), "unknown"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.name, runtimeTypeName(tt.v), fmt.Sprintf("%T", tt.v))
+	}
+}
+
 func TestProjectSlice(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -3358,3 +3770,176 @@ func TestSliceNilField(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectPlan_WindowIndex(t *testing.T) {
+	tests := []struct {
+		sql    string
+		data   interface{}
+		result []map[string]interface{}
+	}{
+		{
+			sql: "SELECT a, window_index() as idx FROM test GROUP BY TumblingWindow(ss, 10)",
+			data: &xsql.WindowTuples{
+				Content: []xsql.Row{
+					&xsql.Tuple{
+						Emitter: "test",
+						Message: xsql.Message{"a": 53},
+					}, &xsql.Tuple{
+						Emitter: "test",
+						Message: xsql.Message{"a": 27},
+					}, &xsql.Tuple{
+						Emitter: "test",
+						Message: xsql.Message{"a": 123123},
+					},
+				},
+				WindowRange: xsql.NewWindowRange(1541152486013, 1541152487013, 1541152487013),
+			},
+			result: []map[string]interface{}{
+				{"a": 53, "idx": 0},
+				{"a": 27, "idx": 1},
+				{"a": 123123, "idx": 2},
+			},
+		},
+		{
+			sql: "SELECT a, window_index() as idx FROM test GROUP BY TumblingWindow(ss, 10)",
+			data: &xsql.WindowTuples{
+				Content: []xsql.Row{
+					&xsql.Tuple{
+						Emitter: "test",
+						Message: xsql.Message{"a": 1},
+					},
+				},
+				WindowRange: xsql.NewWindowRange(1541152487013, 1541152488013, 1541152488013),
+			},
+			result: []map[string]interface{}{
+				{"a": 1, "idx": 0},
+			},
+		},
+		{
+			sql: "SELECT a, window_index() as idx FROM test",
+			data: &xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{"a": 1},
+			},
+			result: []map[string]interface{}{
+				{"a": 1},
+			},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_WindowIndex")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+			require.NoError(t, err)
+			pp := &ProjectOp{}
+			parseStmt(pp, stmt.Fields)
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			opResult := pp.Apply(ctx, tt.data, fv, afv)
+			result, err := parseResult(opResult, pp.IsAggregate)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestProjectPlan_Limit(t *testing.T) {
+	data := &xsql.WindowTuples{
+		Content: []xsql.Row{
+			&xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{"a": 53},
+			}, &xsql.Tuple{
+				Emitter: "test",
+				Message: xsql.Message{"a": 27},
+			},
+		},
+		WindowRange: xsql.NewWindowRange(1541152486013, 1541152487013, 1541152487013),
+	}
+	tests := []struct {
+		name   string
+		limit  int
+		data   interface{}
+		result interface{}
+	}{
+		{
+			name:   "limit 0 tuple",
+			limit:  0,
+			data:   &xsql.Tuple{Emitter: "test", Message: xsql.Message{"a": 1}},
+			result: []xsql.Row{},
+		},
+		{
+			name:   "limit 0 collection",
+			limit:  0,
+			data:   data,
+			result: []xsql.Row{},
+		},
+		{
+			name:   "limit 1 collection",
+			limit:  1,
+			data:   data,
+			result: []map[string]interface{}{{"a": 53}},
+		},
+	}
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_Limit")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader("SELECT a FROM test")).Parse()
+			require.NoError(t, err)
+			pp := &ProjectOp{EnableLimit: true, LimitCount: tt.limit}
+			parseStmt(pp, stmt.Fields)
+			fv, afv := xsql.NewFunctionValuersForOp(nil)
+			opResult := pp.Apply(ctx, tt.data, fv, afv)
+			if rows, ok := opResult.([]xsql.Row); ok {
+				require.Equal(t, tt.result, rows)
+				return
+			}
+			result, err := parseResult(opResult, pp.IsAggregate)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestProjectPlan_ProfileFields(t *testing.T) {
+	contextLogger := conf.Log.WithField("rule", "TestProjectPlan_ProfileFields")
+	ctx := context.WithValue(context.Background(), context.LoggerKey, contextLogger)
+	stmt, err := xsql.NewParser(strings.NewReader("SELECT a, abs(b) AS c, concat(d, \"x\") AS e FROM test")).Parse()
+	require.NoError(t, err)
+	data := &xsql.Tuple{
+		Emitter: "test",
+		Message: xsql.Message{"a": 1, "b": -2, "d": "v"},
+	}
+
+	pp := &ProjectOp{}
+	parseStmt(pp, stmt.Fields)
+	fv, afv := xsql.NewFunctionValuersForOp(nil)
+	pp.Apply(ctx, data.Clone(), fv, afv)
+	keys, values := pp.ExtraMetrics()
+	require.Nil(t, keys)
+	require.Nil(t, values)
+
+	pp = &ProjectOp{ProfileFields: true}
+	parseStmt(pp, stmt.Fields)
+	opResult := pp.Apply(ctx, data.Clone(), fv, afv)
+	result, err := parseResult(opResult, pp.IsAggregate)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{"a": 1, "c": 2, "e": "vx"}}, result)
+	keys, values = pp.ExtraMetrics()
+	require.ElementsMatch(t, []string{"field_cost_us_c", "field_cost_us_e"}, keys)
+	require.Len(t, values, 2)
+	for _, v := range values {
+		require.GreaterOrEqual(t, v.(int64), int64(0))
+	}
+}
+
+func TestFieldProfileTop(t *testing.T) {
+	p := &fieldProfile{}
+	p.add("a", 3*time.Millisecond)
+	p.add("b", 5*time.Millisecond)
+	p.add("c", 1*time.Millisecond)
+	p.add("a", 4*time.Millisecond)
+	require.Equal(t, []fieldCost{{Name: "a", Cost: 7 * time.Millisecond}, {Name: "b", Cost: 5 * time.Millisecond}}, p.top(2))
+	require.Len(t, p.top(profileTopN), 3)
+}
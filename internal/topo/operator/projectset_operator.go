@@ -35,6 +35,7 @@ type ProjectSetOperator struct {
 // For Collection, ProjectSetOperator will do the following transform:
 // [{"a":[1,2],"b":3},{"a":[1,2],"b":4}] = > [{"a":"1","b":3},{"a":"2","b":3},{"a":"1","b":4},{"a":"2","b":4}]
 func (ps *ProjectSetOperator) Apply(ctx api.StreamContext, data interface{}, _ *xsql.FunctionValuer, _ *xsql.AggregateFunctionValuer) interface{} {
+	// LIMIT 0 emits no rows. Negative limit is rejected by the planner.
 	if ps.LimitCount == 0 && ps.EnableLimit {
 		return []xsql.Row{}
 	}
@@ -45,6 +45,16 @@ func (p *AggregatePlan) BuildExplainInfo() {
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *AggregatePlan) explainDetails() map[string]interface{} {
+	keys := make([]string, 0, len(p.dimensions))
+	for _, dimension := range p.dimensions {
+		if dimension.Expr != nil {
+			keys = append(keys, dimension.Expr.String())
+		}
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
 func (p *AggregatePlan) PruneColumns(fields []ast.Expr) error {
 	f := getFields(p.dimensions)
 	return p.baseLogicalPlan.PruneColumns(append(fields, f...))
@@ -402,6 +402,7 @@ func validate(stmt *ast.SelectStatement) error {
 var stmtCheckers = []validateOptStmt{
 	&aggFuncChecker{},
 	&groupChecker{},
+	&setMetaChecker{},
 }
 
 type aggFuncChecker struct{}
@@ -449,6 +450,24 @@ func (c *aggFuncChecker) validate(s *ast.SelectStatement) (err error) {
 	return
 }
 
+// setMetaChecker rejects set_meta in the join or aggregate queries. Their rows combine multiple records,
+// so there is no single record metadata to write.
+type setMetaChecker struct{}
+
+func (c *setMetaChecker) validate(s *ast.SelectStatement) (err error) {
+	if s.Joins == nil && len(s.Dimensions.GetGroups()) == 0 && s.Having == nil && !xsql.HasAggFuncs(s.Fields) {
+		return nil
+	}
+	ast.WalkFunc(s, func(n ast.Node) bool {
+		if f, ok := n.(*ast.Call); ok && f.Name == "set_meta" {
+			err = fmt.Errorf("function %s is not allowed in a join or aggregate query", f.Name)
+			return false
+		}
+		return true
+	})
+	return
+}
+
 type groupChecker struct{}
 
 func (c *groupChecker) validate(s *ast.SelectStatement) error {
@@ -528,7 +547,7 @@ func (f *fieldsMap) save(fieldName string, streamName ast.StreamName, field *ast
 			fm = newStreamFieldStore(f.isSchemaless, f.defaultStream)
 			f.content[lname] = fm
 		} else {
-			return fmt.Errorf("unknown field %s", fieldName)
+			return unknownFieldErr(fieldName, streamName)
 		}
 	}
 	err := fm.ref(streamName, field)
@@ -551,7 +570,7 @@ func (f *fieldsMap) bind(fr *ast.FieldRef) error {
 			fm = newStreamFieldStore(f.isSchemaless, f.defaultStream)
 			f.content[lname] = fm
 		} else {
-			return fmt.Errorf("unknown field %s", fr.Name)
+			return unknownFieldErr(fr.Name, fr.StreamName)
 		}
 	}
 	if fm != nil || ok2 {
@@ -563,6 +582,14 @@ func (f *fieldsMap) bind(fr *ast.FieldRef) error {
 	return nil
 }
 
+// unknownFieldErr names the stream if the field is qualified by a concrete stream
+func unknownFieldErr(fieldName string, streamName ast.StreamName) error {
+	if streamName == "" || streamName == ast.DefaultStream || streamName == ast.AliasStream {
+		return fmt.Errorf("unknown field %s", fieldName)
+	}
+	return fmt.Errorf("unknown field %s in stream %s", fieldName, streamName)
+}
+
 type streamFieldStore interface {
 	add(k ast.StreamName)
 	ref(k ast.StreamName, v *ast.AliasRef) error
@@ -140,6 +140,18 @@ var tests = []struct {
 		sql: "select a + 1 as b, b * 2 as c, c + 1 as a from src1",
 		r:   newErrorStruct("select fields have cycled alias"),
 	},
+	{
+		sql: `SELECT temp, set_meta("topic", name) FROM src1 GROUP BY TumblingWindow(ss, 10)`,
+		r:   newErrorStruct(""),
+	},
+	{
+		sql: `SELECT count(*), set_meta("topic", "a") FROM src1 GROUP BY TumblingWindow(ss, 10)`,
+		r:   newErrorStruct("function set_meta is not allowed in a join or aggregate query"),
+	},
+	{
+		sql: `SELECT name FROM src1 WHERE isnull(set_meta("topic", name)) GROUP BY name, TumblingWindow(ss, 10)`,
+		r:   newErrorStruct("function set_meta is not allowed in a join or aggregate query"),
+	},
 	//{ // 19 already captured in parser
 	//	sql: `SELECT * FROM src1 GROUP BY SlidingWindow(ss,5) Over (WHEN abs(sum(a)) > 1) HAVING last_agg_hit_count() < 3`,
 	//	r:   newErrorStruct("error compile sql: Not allowed to call aggregate functions in GROUP BY clause."),
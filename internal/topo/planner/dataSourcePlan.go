@@ -141,6 +141,27 @@ func (p *DataSourcePlan) BuildExplainInfo() {
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *DataSourcePlan) explainDetails() map[string]interface{} {
+	details := map[string]interface{}{"stream": string(p.name)}
+	if len(p.fields) != 0 {
+		keys := make([]string, 0, len(p.fields))
+		for k := range p.fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		details["fields"] = keys
+	}
+	if len(p.streamFields) != 0 {
+		keys := make([]string, 0, len(p.streamFields))
+		for k := range p.streamFields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		details["streamFields"] = keys
+	}
+	return details
+}
+
 // PushDownPredicate Presume no children for data source
 func (p *DataSourcePlan) PushDownPredicate(condition ast.Expr) (ast.Expr, LogicalPlan) {
 	if p.streamStmt.StreamType == ast.TypeTable {
@@ -15,6 +15,7 @@
 package planner
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -693,6 +694,41 @@ func TestProjectPlanExplainInfo(t *testing.T) {
 			res: `{"op":"ProjectPlan_1","info":"Fields:[ $$alias.col,aliasRef:Call:{ name:unnest, args:[src1.myarray] } ]"}`,
 			t:   "ProjectPlan",
 		},
+		{
+			p: &ProjectPlan{
+				fields: []ast.Field{
+					{
+						Name: "name",
+						Expr: &ast.FieldRef{
+							StreamName: "src1",
+							Name:       "name",
+						},
+					},
+				},
+				enableLimit: true,
+				limitCount:  0,
+			},
+			res: `{"op":"ProjectPlan_2","info":"Fields:[ src1.name ], Limit:0"}`,
+			t:   "ProjectPlan",
+		},
+		{
+			p: &ProjectPlan{
+				fields: []ast.Field{
+					{
+						Name: "name",
+						Expr: &ast.FieldRef{
+							StreamName: "src1",
+							Name:       "name",
+						},
+					},
+				},
+				distinct:    true,
+				enableLimit: true,
+				limitCount:  2,
+			},
+			res: `{"op":"ProjectPlan_3","info":"Fields:[ src1.name ], Distinct:true, Limit:2"}`,
+			t:   "ProjectPlan",
+		},
 	}
 
 	for i := 0; i < len(test); i++ {
@@ -768,6 +804,17 @@ func TestProjectSetPlanExplainInfo(t *testing.T) {
 			res: `{"op":"ProjectSetPlan_0","info":"SrfMap:{key:unnest}, EnableLimit:false"}`,
 			t:   "ProjectSetPlan",
 		},
+		{
+			p: &ProjectSetPlan{
+				SrfMapping: map[string]struct{}{
+					"unnest": {},
+				},
+				enableLimit: true,
+				limitCount:  0,
+			},
+			res: `{"op":"ProjectSetPlan_1","info":"SrfMap:{key:unnest}, EnableLimit:true, Limit:0"}`,
+			t:   "ProjectSetPlan",
+		},
 	}
 
 	for i := 0; i < len(test); i++ {
@@ -915,3 +962,27 @@ func TestSchemaInfo(t *testing.T) {
 	}, false)
 	require.Equal(t, " ConverterSchema:[a]", p.buildSchemaInfo("r1"))
 }
+
+func TestExplainTreeDetails(t *testing.T) {
+	agg := AggregatePlan{
+		dimensions: ast.Dimensions{{Expr: &ast.FieldRef{Name: "id", StreamName: "src1"}}},
+	}.Init()
+	filter := FilterPlan{
+		condition: &ast.BinaryExpr{
+			LHS: &ast.FieldRef{Name: "temp", StreamName: "src1"},
+			OP:  ast.GT,
+			RHS: &ast.IntegerLiteral{Val: 20},
+		},
+	}.Init()
+	filter.SetChildren([]LogicalPlan{agg})
+	project := ProjectPlan{
+		fields:      ast.Fields{{Expr: &ast.FieldRef{Name: "temp", StreamName: "src1"}, Name: "temp"}},
+		enableLimit: true,
+		limitCount:  1,
+	}.Init()
+	project.SetChildren([]LogicalPlan{filter})
+	tree := ExplainTreeFromLogicalPlan(project, "r1")
+	b, err := json.Marshal(tree)
+	require.NoError(t, err)
+	require.Equal(t, `{"op":"ProjectPlan_0","type":"ProjectPlan","id":0,"info":"Fields:[ src1.temp ], Limit:1","details":{"fields":["src1.temp"],"limit":1},"children":[{"op":"FilterPlan_1","type":"FilterPlan","id":1,"info":"Condition:{ binaryExpr:{ src1.temp > 20 } }, ","details":{"condition":"binaryExpr:{ src1.temp > 20 }"},"children":[{"op":"AggregatePlan_2","type":"AggregatePlan","id":2,"info":"Dimension:{ src1.id }","details":{"keys":["src1.id"]}}]}]}`, string(b))
+}
@@ -49,6 +49,17 @@ func (p *FilterPlan) BuildExplainInfo() {
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *FilterPlan) explainDetails() map[string]interface{} {
+	details := map[string]interface{}{}
+	if p.condition != nil {
+		details["condition"] = p.condition.String()
+	}
+	if len(p.stateFuncs) != 0 {
+		details["stateFuncs"] = exprStrings(p.stateFuncs)
+	}
+	return details
+}
+
 func (p *FilterPlan) PushDownPredicate(condition ast.Expr) (ast.Expr, LogicalPlan) {
 	// if no child, swallow all conditions
 	a := combine(condition, p.condition)
@@ -44,6 +44,17 @@ func (p *HavingPlan) BuildExplainInfo() {
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *HavingPlan) explainDetails() map[string]interface{} {
+	details := map[string]interface{}{}
+	if p.condition != nil {
+		details["condition"] = p.condition.String()
+	}
+	if len(p.stateFuncs) != 0 {
+		details["stateFuncs"] = exprStrings(p.stateFuncs)
+	}
+	return details
+}
+
 func (p HavingPlan) Init() *HavingPlan {
 	p.baseLogicalPlan.self = &p
 	p.baseLogicalPlan.setPlanType(HAVING)
@@ -47,6 +47,18 @@ func (p *JoinPlan) BuildExplainInfo() {
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *JoinPlan) explainDetails() map[string]interface{} {
+	joins := make([]map[string]interface{}, 0, len(p.joins))
+	for _, join := range p.joins {
+		j := map[string]interface{}{"joinType": join.JoinType.String(), "name": join.Name}
+		if join.Expr != nil {
+			j["condition"] = join.Expr.String()
+		}
+		joins = append(joins, j)
+	}
+	return map[string]interface{}{"joins": joins}
+}
+
 func (p *JoinPlan) PushDownPredicate(condition ast.Expr) (ast.Expr, LogicalPlan) {
 	// TODO multiple join support
 	// Assume only one join
@@ -47,6 +47,7 @@ type ExplainInfo interface {
 	Type() string
 	ChildrenID() []int64
 	Explain() string
+	ExplainTree() *PlanExplainNode
 	BuildExplainInfo()
 	SetID(id int64)
 }
@@ -62,6 +63,22 @@ type PlanExplainInfo struct {
 	Info string   `json:"info"`
 }
 
+// PlanExplainNode is the structured explain info of a plan and its children for the tools to visualize the plan.
+// Details is the structured form of the info such as fields, condition and keys, set by the plans implementing explainDetailer
+type PlanExplainNode struct {
+	Op       string                 `json:"op"`
+	Type     string                 `json:"type"`
+	ID       int64                  `json:"id"`
+	Info     string                 `json:"info"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Children []*PlanExplainNode     `json:"children,omitempty"`
+}
+
+// explainDetailer is implemented by the plans which provide the structured explain details
+type explainDetailer interface {
+	explainDetails() map[string]interface{}
+}
+
 func (p *PlanExplainInfo) SetOp() {
 	p.Op = fmt.Sprintf("%s_%v", p.T, p.ID)
 }
@@ -74,6 +91,33 @@ func (p *baseLogicalPlan) Explain() string {
 	return bf.String()
 }
 
+// ExplainTree returns the explain info of the plan and all its children as a tree.
+// The explain info of the plans must be built before.
+func (p *baseLogicalPlan) ExplainTree() *PlanExplainNode {
+	n := &PlanExplainNode{
+		Op:   p.ExplainInfo.Op,
+		Type: string(p.ExplainInfo.T),
+		ID:   p.ExplainInfo.ID,
+		Info: p.ExplainInfo.Info,
+	}
+	if d, ok := p.self.(explainDetailer); ok {
+		n.Details = d.explainDetails()
+	}
+	for _, child := range p.Children() {
+		n.Children = append(n.Children, child.ExplainTree())
+	}
+	return n
+}
+
+// exprStrings returns the string form of the expressions for the explain details
+func exprStrings[T fmt.Stringer](exprs []T) []string {
+	result := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		result = append(result, e.String())
+	}
+	return result
+}
+
 func (p *baseLogicalPlan) BuildExplainInfo() {
 	p.self.BuildExplainInfo()
 }
@@ -42,6 +42,14 @@ func (p *OrderPlan) BuildExplainInfo() {
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *OrderPlan) explainDetails() map[string]interface{} {
+	fields := make([]string, 0, len(p.SortFields))
+	for i := range p.SortFields {
+		fields = append(fields, p.SortFields[i].String())
+	}
+	return map[string]interface{}{"sortFields": fields}
+}
+
 func (p *OrderPlan) PruneColumns(fields []ast.Expr) error {
 	f := getFields(p.SortFields)
 	return p.baseLogicalPlan.PruneColumns(append(fields, f...))
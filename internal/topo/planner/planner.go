@@ -224,6 +224,19 @@ func validateStmt(stmt *ast.SelectStatement) error {
 	return vErr
 }
 
+// extractLimit returns whether the limit is enabled and the limit count.
+// LIMIT 0 is allowed and means no rows will be emitted while negative limit is rejected.
+func extractLimit(stmt *ast.SelectStatement) (bool, int, error) {
+	if stmt.Limit == nil {
+		return false, 0, nil
+	}
+	l := stmt.Limit.(*ast.LimitExpr)
+	if err := l.ValidateExpr(); err != nil {
+		return false, 0, err
+	}
+	return true, int(l.LimitCount.Val), nil
+}
+
 func createTopo(rule *def.Rule, lp LogicalPlan, mockSourcesProp map[string]map[string]any, streamsFromStmt []string, schema map[string]*ast.JsonStreamField) (t *topo.Topo, err error) {
 	defer func() {
 		if err != nil {
@@ -254,42 +267,46 @@ func createTopo(rule *def.Rule, lp LogicalPlan, mockSourcesProp map[string]map[s
 }
 
 func GetExplainInfoFromLogicalPlan(rule *def.Rule) (string, error) {
+	lp, err := createExplainLogicalPlan(rule)
+	if err != nil {
+		return "", err
+	}
+	return ExplainFromLogicalPlan(lp, rule.Id)
+}
+
+// GetExplainTreeFromLogicalPlan returns the plan of the rule as a tree which can be serialized to json
+func GetExplainTreeFromLogicalPlan(rule *def.Rule) (*PlanExplainNode, error) {
+	lp, err := createExplainLogicalPlan(rule)
+	if err != nil {
+		return nil, err
+	}
+	return ExplainTreeFromLogicalPlan(lp, rule.Id), nil
+}
+
+func createExplainLogicalPlan(rule *def.Rule) (LogicalPlan, error) {
 	sql := rule.Sql
 
 	conf.Log.Infof("Init rule with options %+v", rule.Options)
 	stmt, err := xsql.GetStatementFromSql(sql)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	// validation
 	streamsFromStmt := xsql.GetStreams(stmt)
 
 	if rule.Options.SendMetaToSink && (len(streamsFromStmt) > 1 || stmt.Dimensions != nil) {
-		return "", fmt.Errorf("invalid option sendMetaToSink, it can not be applied to window")
+		return nil, fmt.Errorf("invalid option sendMetaToSink, it can not be applied to window")
 	}
 	store, err := store2.GetKV("stream")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	// Create logical plan and optimize. Logical plans are a linked list
-	lp, err := CreateLogicalPlan(stmt, rule.Options, store)
-	if err != nil {
-		return "", err
-	}
-	return ExplainFromLogicalPlan(lp, rule.Id)
+	return CreateLogicalPlan(stmt, rule.Options, store)
 }
 
 func ExplainFromLogicalPlan(lp LogicalPlan, ruleID string) (string, error) {
-	var setId func(p LogicalPlan, id int64)
-	setId = func(p LogicalPlan, id int64) {
-		p.SetID(id)
-		children := p.Children()
-		for i := 0; i < len(children); i++ {
-			id++
-			setId(children[i], id)
-		}
-	}
-	setId(lp, 0)
+	buildExplainInfo(lp, ruleID)
 	var getExplainInfo func(p LogicalPlan, level int) string
 	getExplainInfo = func(p LogicalPlan, level int) string {
 		tmp := ""
@@ -297,10 +314,6 @@ func ExplainFromLogicalPlan(lp LogicalPlan, ruleID string) (string, error) {
 		for i := 0; i < level; i++ {
 			tmp += "\t"
 		}
-		p.BuildExplainInfo()
-		if info, ok := p.(RuleRuntimeInfo); ok {
-			info.BuildSchemaInfo(ruleID)
-		}
 		// Build the explainInfo of the current layer
 		res += tmp + strings.TrimSuffix(p.Explain(), "\n")
 		if len(p.Children()) != 0 {
@@ -316,6 +329,37 @@ func ExplainFromLogicalPlan(lp LogicalPlan, ruleID string) (string, error) {
 	return strings.Trim(res, "\n"), nil
 }
 
+// ExplainTreeFromLogicalPlan returns the plan and its children as a tree
+func ExplainTreeFromLogicalPlan(lp LogicalPlan, ruleID string) *PlanExplainNode {
+	buildExplainInfo(lp, ruleID)
+	return lp.ExplainTree()
+}
+
+// buildExplainInfo sets the id and builds the explain info of the plan and all its children
+func buildExplainInfo(lp LogicalPlan, ruleID string) {
+	var setId func(p LogicalPlan, id int64)
+	setId = func(p LogicalPlan, id int64) {
+		p.SetID(id)
+		children := p.Children()
+		for i := 0; i < len(children); i++ {
+			id++
+			setId(children[i], id)
+		}
+	}
+	setId(lp, 0)
+	var build func(p LogicalPlan)
+	build = func(p LogicalPlan) {
+		p.BuildExplainInfo()
+		if info, ok := p.(RuleRuntimeInfo); ok {
+			info.BuildSchemaInfo(ruleID)
+		}
+		for _, child := range p.Children() {
+			build(child)
+		}
+	}
+	build(lp)
+}
+
 // return the last schema if there are multiple sources
 func buildOps(lp LogicalPlan, tp *topo.Topo, options *def.RuleOption, sources map[string]map[string]any, streamsFromStmt []string, index int) (node.Emitter, int, error) {
 	var inputs []node.Emitter
@@ -446,7 +490,7 @@ func buildOps(lp LogicalPlan, tp *topo.Topo, options *def.RuleOption, sources ma
 	case *OrderPlan:
 		op = Transform(&operator.OrderOp{SortFields: t.SortFields}, fmt.Sprintf("%d_order", newIndex), options)
 	case *ProjectPlan:
-		op = Transform(&operator.ProjectOp{Fields: t.fields, FieldLen: t.fieldLen, ColNames: t.colNames, AliasFields: t.aliasFields, ExprFields: t.exprFields, ExceptNames: t.exceptNames, IsAggregate: t.isAggregate, AllWildcard: t.allWildcard, WildcardEmitters: t.wildcardEmitters, SendMeta: t.sendMeta, SendNil: t.sendNil, LimitCount: t.limitCount, EnableLimit: t.enableLimit}, fmt.Sprintf("%d_project", newIndex), options)
+		op = Transform(&operator.ProjectOp{Fields: t.fields, FieldLen: t.fieldLen, ColNames: t.colNames, AliasFields: t.aliasFields, ExprFields: t.exprFields, InvariantFields: t.invariantFields, ExceptNames: t.exceptNames, IsAggregate: t.isAggregate, AllWildcard: t.allWildcard, WildcardEmitters: t.wildcardEmitters, WildcardPrefixes: t.wildcardPrefixes, SendMeta: t.sendMeta, SendNil: t.sendNil, SendSchema: t.sendSchema, Distinct: t.distinct, DistinctCacheSize: options.DistinctCacheSize, LimitCount: t.limitCount, EnableLimit: t.enableLimit, PassThrough: t.passThrough, ProfileFields: options.Experiment != nil && options.Experiment.ProfileFields}, fmt.Sprintf("%d_project", newIndex), options)
 	case *ProjectSetPlan:
 		op = Transform(&operator.ProjectSetOperator{SrfMapping: t.SrfMapping, LimitCount: t.limitCount, EnableLimit: t.enableLimit}, fmt.Sprintf("%d_projectset", newIndex), options)
 	case *WindowFuncPlan:
@@ -803,20 +847,24 @@ func createLogicalPlanFull(stmt *ast.SelectStatement, opt *def.RuleOption, store
 				fieldLen++
 			}
 		}
-		enableLimit := false
-		limitCount := 0
-		if stmt.Limit != nil && len(srfMapping) == 0 {
-			enableLimit = true
-			limitCount = int(stmt.Limit.(*ast.LimitExpr).LimitCount.Val)
+		enableLimit, limitCount := false, 0
+		if len(srfMapping) == 0 {
+			enableLimit, limitCount, err = extractLimit(stmt)
+			if err != nil {
+				return nil, nil, nil, err
+			}
 		}
 		p = ProjectPlan{
-			fields:      fields,
-			fieldLen:    fieldLen,
-			isAggregate: xsql.WithAggFields(stmt) && len(rewriteRes.incAggFields) < 1,
-			sendMeta:    opt.SendMetaToSink,
-			sendNil:     opt.SendNil,
-			enableLimit: enableLimit,
-			limitCount:  limitCount,
+			fields:           fields,
+			fieldLen:         fieldLen,
+			isAggregate:      xsql.WithAggFields(stmt) && len(rewriteRes.incAggFields) < 1,
+			sendMeta:         opt.SendMetaToSink,
+			sendNil:          opt.SendNil,
+			sendSchema:       opt.SendSchema,
+			distinct:         stmt.Distinct,
+			enableLimit:      enableLimit,
+			limitCount:       limitCount,
+			wildcardPrefixes: opt.WildcardPrefix,
 		}.Init()
 		p.SetChildren(children)
 		children = []LogicalPlan{p}
@@ -826,11 +874,9 @@ func createLogicalPlanFull(stmt *ast.SelectStatement, opt *def.RuleOption, store
 		if opt.Experiment != nil && opt.Experiment.UseSliceTuple {
 			return nil, nil, nil, errors.New("slice tuple mode do not support project set yet")
 		}
-		enableLimit := false
-		limitCount := 0
-		if stmt.Limit != nil {
-			enableLimit = true
-			limitCount = int(stmt.Limit.(*ast.LimitExpr).LimitCount.Val)
+		enableLimit, limitCount, err := extractLimit(stmt)
+		if err != nil {
+			return nil, nil, nil, err
 		}
 		p = ProjectSetPlan{
 			SrfMapping:  srfMapping,
@@ -21,11 +21,18 @@ import (
 	"testing"
 
 	"github.com/gdexlab/go-render/render"
+	"github.com/lf-edge/ekuiper/contract/v2/api"
+	"github.com/stretchr/testify/require"
 
+	"github.com/lf-edge/ekuiper/v2/internal/binder"
+	"github.com/lf-edge/ekuiper/v2/internal/binder/function"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/def"
 	"github.com/lf-edge/ekuiper/v2/internal/pkg/store"
+	"github.com/lf-edge/ekuiper/v2/internal/plugin"
+	"github.com/lf-edge/ekuiper/v2/internal/topo/operator"
 	"github.com/lf-edge/ekuiper/v2/internal/xsql"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	mockContext "github.com/lf-edge/ekuiper/v2/pkg/mock/context"
 )
 
 func TestPlannerAlias(t *testing.T) {
@@ -260,3 +267,125 @@ func TestPlannerAlias(t *testing.T) {
 		}
 	}
 }
+
+type countEvalFunc struct {
+	count int
+}
+
+func (f *countEvalFunc) Validate(_ []interface{}) error {
+	return nil
+}
+
+func (f *countEvalFunc) Exec(_ api.FunctionContext, args []any) (interface{}, bool) {
+	f.count++
+	return args[0], true
+}
+
+func (f *countEvalFunc) IsAggregate() bool {
+	return false
+}
+
+type countEvalFactory struct {
+	f *countEvalFunc
+}
+
+func (c *countEvalFactory) Function(name string) (api.Function, error) {
+	if name == "count_eval" {
+		return c.f, nil
+	}
+	return nil, nil
+}
+
+func (c *countEvalFactory) HasFunctionSet(funcName string) bool {
+	return funcName == "count_eval"
+}
+
+func (c *countEvalFactory) ConvName(funcName string) (string, bool) {
+	return funcName, funcName == "count_eval"
+}
+
+func (c *countEvalFactory) FunctionPluginInfo(_ string) (plugin.EXTENSION_TYPE, string, string) {
+	return plugin.NONE_EXTENSION, "", ""
+}
+
+func TestAliasEvaluatedOnce(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+	cf := &countEvalFunc{}
+	require.NoError(t, function.Initialize([]binder.FactoryEntry{{Name: "count eval", Factory: &countEvalFactory{f: cf}}}))
+
+	stmt, err := xsql.NewParser(strings.NewReader("SELECT count_eval(a) AS e, e + 1 AS f, e * 2 AS g, abs(e) FROM stream")).Parse()
+	require.NoError(t, err)
+	lp, err := CreateLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	pp, ok := lp.(*ProjectPlan)
+	require.True(t, ok)
+	op := &operator.ProjectOp{Fields: pp.fields, FieldLen: pp.fieldLen, ColNames: pp.colNames, AliasFields: pp.aliasFields, ExprFields: pp.exprFields, ExceptNames: pp.exceptNames, AllWildcard: pp.allWildcard, WildcardEmitters: pp.wildcardEmitters}
+
+	ctx := mockContext.NewMockContext("testAlias", "project")
+	fv, afv := xsql.NewFunctionValuersForOp(ctx)
+	for i := 1; i <= 2; i++ {
+		data := &xsql.Tuple{Emitter: "stream", Message: xsql.Message{"a": int64(3)}}
+		r := op.Apply(ctx, data, fv, afv)
+		row, ok := r.(xsql.Row)
+		require.True(t, ok)
+		require.Equal(t, map[string]interface{}{"e": int64(3), "f": int64(4), "g": int64(6), "abs": int64(3)}, row.ToMap())
+		require.Equal(t, i, cf.count)
+	}
+}
+
+func TestInvariantFieldEvaluatedOnce(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+	require.NoError(t, function.Initialize([]binder.FactoryEntry{{Name: "count eval", Factory: &countEvalFactory{f: &countEvalFunc{}}}}))
+	// the factory may be registered by other tests, use the one that is actually resolved
+	f, err := function.Function("count_eval")
+	require.NoError(t, err)
+	cf := f.(*countEvalFunc)
+	base := cf.count
+
+	stmt, err := xsql.NewParser(strings.NewReader("SELECT count_eval(1) AS v, 'v1' AS version, concat(version, '-', rule_id()) AS tag, upper('x'), newuuid() AS id, a + 1 AS b, count_eval(a) AS c FROM stream")).Parse()
+	require.NoError(t, err)
+	lp, err := CreateLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	pp, ok := lp.(*ProjectPlan)
+	require.True(t, ok)
+	// the plugin function is not known to be invariant
+	require.Equal(t, map[string]bool{"version": true, "tag": true, "upper": true}, pp.invariantFields)
+
+	// mark the plugin function call as invariant to count the evaluations
+	pp.invariantFields["v"] = true
+	op := &operator.ProjectOp{Fields: pp.fields, FieldLen: pp.fieldLen, ColNames: pp.colNames, AliasFields: pp.aliasFields, ExprFields: pp.exprFields, InvariantFields: pp.invariantFields, ExceptNames: pp.exceptNames, AllWildcard: pp.allWildcard, WildcardEmitters: pp.wildcardEmitters}
+	ctx := mockContext.NewMockContext("testInvariant", "project")
+	fv, afv := xsql.NewFunctionValuersForOp(ctx)
+	var ids []interface{}
+	for i := 1; i <= 3; i++ {
+		data := &xsql.Tuple{Emitter: "stream", Message: xsql.Message{"a": int64(i)}}
+		r := op.Apply(ctx, data, fv, afv)
+		row, ok := r.(xsql.Row)
+		require.True(t, ok)
+		m := row.ToMap()
+		ids = append(ids, m["id"])
+		delete(m, "id")
+		require.Equal(t, map[string]interface{}{"v": int64(1), "version": "v1", "tag": "v1-testInvariant", "upper": "X", "b": int64(i + 1), "c": int64(i)}, m)
+		// count_eval(1) is evaluated once while count_eval(a) is evaluated for each row
+		require.Equal(t, i+1, cf.count-base)
+	}
+	require.NotEqual(t, ids[0], ids[1])
+}
+
+func TestStateWriteFieldNotInvariant(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	require.NoError(t, prepareStream())
+	stmt, err := xsql.NewParser(strings.NewReader("SELECT set_keyed_state('k', 1) AS s, set_keyed_states(array_create('a', 'b'), array_create(1, 2)) AS ss, upper('x') AS u FROM stream")).Parse()
+	require.NoError(t, err)
+	lp, err := CreateLogicalPlan(stmt, &def.RuleOption{}, kv)
+	require.NoError(t, err)
+	pp, ok := lp.(*ProjectPlan)
+	require.True(t, ok)
+	// the state writes must run for every row even if all the arguments are literals
+	require.Equal(t, map[string]bool{"u": true}, pp.invariantFields)
+}
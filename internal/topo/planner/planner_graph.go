@@ -151,7 +151,7 @@ func PlanByGraph(rule *def.Rule) (*topo.Topo, error) {
 				op := Transform(fop, nodeName, rule.Options)
 				nodeMap[nodeName] = op
 			case "pick":
-				pop, err := parsePick(gn.Props, sourceNames)
+				pop, err := parsePick(gn.Props, sourceNames, rule.Options)
 				if err != nil {
 					return nil, fmt.Errorf("parse pick %s with %v error: %w", nodeName, gn.Props, err)
 				}
@@ -714,7 +714,7 @@ func parseWindow(props map[string]interface{}) (*node.WindowConfig, error) {
 	}, nil
 }
 
-func parsePick(props map[string]interface{}, sourceNames []string) (*operator.ProjectOp, error) {
+func parsePick(props map[string]interface{}, sourceNames []string, options *def.RuleOption) (*operator.ProjectOp, error) {
 	n := &graph.Select{}
 	err := cast.MapToStruct(props, n)
 	if err != nil {
@@ -724,11 +724,18 @@ func parsePick(props map[string]interface{}, sourceNames []string) (*operator.Pr
 	if err != nil {
 		return nil, err
 	}
+	var prefixes map[string]string
+	if options != nil {
+		prefixes = options.WildcardPrefix
+	}
 	t := ProjectPlan{
-		fields:      stmt.Fields,
-		isAggregate: n.IsAgg,
+		fields:           stmt.Fields,
+		isAggregate:      n.IsAgg,
+		sendNil:          n.SendNil || (options != nil && options.SendNil),
+		sendSchema:       options != nil && options.SendSchema,
+		wildcardPrefixes: prefixes,
 	}.Init()
-	return &operator.ProjectOp{Fields: t.fields, FieldLen: len(t.fields), ColNames: t.colNames, AliasFields: t.aliasFields, ExprFields: t.exprFields, ExceptNames: t.exceptNames, IsAggregate: t.isAggregate, AllWildcard: t.allWildcard, WildcardEmitters: t.wildcardEmitters, SendMeta: t.sendMeta, SendNil: t.sendNil}, nil
+	return &operator.ProjectOp{Fields: t.fields, FieldLen: len(t.fields), ColNames: t.colNames, AliasFields: t.aliasFields, ExprFields: t.exprFields, InvariantFields: t.invariantFields, ExceptNames: t.exceptNames, IsAggregate: t.isAggregate, AllWildcard: t.allWildcard, WildcardEmitters: t.wildcardEmitters, WildcardPrefixes: t.wildcardPrefixes, SendMeta: t.sendMeta, SendNil: t.sendNil, SendSchema: t.sendSchema, PassThrough: t.passThrough}, nil
 }
 
 func parseFunc(props map[string]interface{}, sourceNames []string) (*operator.FuncOp, error) {
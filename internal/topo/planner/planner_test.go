@@ -410,6 +410,46 @@ func Test_createLogicalPlan(t *testing.T) {
 				enableLimit: true,
 			}.Init(),
 		},
+		{
+			sql: "select name from src1 limit 0",
+			p: ProjectPlan{
+				baseLogicalPlan: baseLogicalPlan{
+					children: []LogicalPlan{
+						DataSourcePlan{
+							baseLogicalPlan: baseLogicalPlan{},
+							name:            "src1",
+							streamFields: map[string]*ast.JsonStreamField{
+								"name": {
+									Type: "string",
+								},
+							},
+							streamStmt:  streams["src1"],
+							metaFields:  []string{},
+							pruneFields: []string{},
+						}.Init(),
+					},
+				},
+				fields: []ast.Field{
+					{
+						Name: "name",
+						Expr: &ast.FieldRef{
+							StreamName: "src1",
+							Name:       "name",
+						},
+					},
+				},
+				limitCount:  0,
+				enableLimit: true,
+			}.Init(),
+		},
+		{
+			sql: "select name from src1 limit -1",
+			err: "limit count must not be negative but got -1",
+		},
+		{
+			sql: "select unnest(myarray) as col from src1 limit -1",
+			err: "limit count must not be negative but got -1",
+		},
 		{
 			sql: "select unnest(myarray) as col from src1 limit 1",
 			p: ProjectSetPlan{
@@ -4627,3 +4667,93 @@ func TestCheckSharedSourceOption(t *testing.T) {
 	}
 	require.Error(t, checkSharedSourceOption(s1, r1))
 }
+
+func TestProjectPlanPassThrough(t *testing.T) {
+	tests := []struct {
+		sql         string
+		passThrough bool
+	}{
+		{sql: "SELECT * FROM src1", passThrough: true},
+		{sql: "SELECT * EXCEPT(a) FROM src1", passThrough: false},
+		{sql: "SELECT * REPLACE(a + 1 as a) FROM src1", passThrough: false},
+		{sql: "SELECT *, a + 1 as b FROM src1", passThrough: false},
+		{sql: "SELECT *, abs(a) FROM src1", passThrough: false},
+		{sql: "SELECT a FROM src1", passThrough: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+			require.NoError(t, err)
+			p := ProjectPlan{fields: stmt.Fields}.Init()
+			require.Equal(t, tt.passThrough, p.passThrough)
+		})
+	}
+}
+
+func TestProjectPlanWildcardPrefix(t *testing.T) {
+	option := map[string]string{"src1": "", "src2": "s2_", "src3": "s3_"}
+	tests := []struct {
+		sql      string
+		prefixes map[string]string
+	}{
+		{sql: "SELECT src1.*, src2.a FROM src1 INNER JOIN src2 ON src1.id = src2.id", prefixes: map[string]string{"src1": "src1_"}},
+		{sql: "SELECT src1.*, src2.* FROM src1 INNER JOIN src2 ON src1.id = src2.id", prefixes: map[string]string{"src1": "src1_", "src2": "s2_"}},
+		{sql: "SELECT * FROM src1 INNER JOIN src2 ON src1.id = src2.id", prefixes: map[string]string{"src1": "src1_", "src2": "s2_", "src3": "s3_"}},
+		{sql: "SELECT src1.a, src2.a AS b FROM src1 INNER JOIN src2 ON src1.id = src2.id", prefixes: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+			require.NoError(t, err)
+			p := ProjectPlan{fields: stmt.Fields, wildcardPrefixes: option}.Init()
+			require.Equal(t, tt.prefixes, p.wildcardPrefixes)
+			require.False(t, p.passThrough)
+		})
+	}
+}
+
+func TestProjectPlanValidateSchema(t *testing.T) {
+	kv, err := store.GetKV("stream")
+	require.NoError(t, err)
+	streamSqls := map[string]string{
+		"src1": `CREATE STREAM src1 (
+					id1 BIGINT,
+					temp FLOAT
+				) WITH (DATASOURCE="src1", FORMAT="json", KEY="ts");`,
+		"src2": `CREATE STREAM src2 (
+				) WITH (DATASOURCE="src2", FORMAT="json", KEY="ts");`,
+	}
+	for name, sql := range streamSqls {
+		s, err := json.Marshal(&xsql.StreamInfo{
+			StreamType: ast.TypeStream,
+			Statement:  sql,
+		})
+		require.NoError(t, err)
+		require.NoError(t, kv.Set(name, string(s)))
+	}
+	tests := []struct {
+		sql string
+		err string
+	}{
+		{sql: "SELECT src1.id1, src1.temp FROM src1"},
+		{sql: "SELECT src1.ID1 FROM src1"},
+		{sql: "SELECT src1.id3 FROM src1", err: "unknown field id3 in stream src1"},
+		{sql: "SELECT *, src1.id1 FROM src1"},
+		{sql: "SELECT *, src1.id3 FROM src1", err: "unknown field id3 in stream src1"},
+		{sql: "SELECT src1.temp + 1 AS t, src1.id1 FROM src1"},
+		{sql: "SELECT src2.whatever FROM src2"},
+		{sql: "SELECT whatever FROM src1", err: "unknown field whatever"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			stmt, err := xsql.NewParser(strings.NewReader(tt.sql)).Parse()
+			require.NoError(t, err)
+			_, err = CreateLogicalPlan(stmt, defaultOption, kv)
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
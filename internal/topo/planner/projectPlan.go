@@ -17,6 +17,7 @@ package planner
 import (
 	"strconv"
 
+	"github.com/lf-edge/ekuiper/v2/internal/binder/function"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 )
 
@@ -26,20 +27,30 @@ type ProjectPlan struct {
 	allWildcard      bool
 	sendMeta         bool
 	sendNil          bool
+	sendSchema       bool
+	distinct         bool
 	fields           ast.Fields
 	fieldLen         int
 	colNames         [][]string
 	exceptNames      []string
 	wildcardEmitters map[string]bool
+	// wildcardPrefixes is the column prefix of each stream selected by wildcard. Before Init, it is the rule option.
+	wildcardPrefixes map[string]string
 	aliasFields      ast.Fields
 	exprFields       ast.Fields
-	enableLimit      bool
-	limitCount       int
+	// invariantFields is the name set of the expression and alias fields whose values do not change in a rule run
+	invariantFields map[string]bool
+	enableLimit     bool
+	limitCount      int
+	// passThrough is set when the projection is a plain SELECT * which does not need to evaluate any field
+	passThrough bool
 }
 
 func (p ProjectPlan) Init() *ProjectPlan {
 	p.allWildcard = false
 	p.wildcardEmitters = make(map[string]bool)
+	prefixOption := p.wildcardPrefixes
+	p.wildcardPrefixes = nil
 	for _, field := range p.fields {
 		if field.AName != "" {
 			p.aliasFields = append(p.aliasFields, field)
@@ -54,6 +65,7 @@ func (p ProjectPlan) Init() *ProjectPlan {
 			case *ast.FieldRef:
 				if ft.Name == "*" {
 					p.wildcardEmitters[string(ft.StreamName)] = true
+					p.addWildcardPrefix(prefixOption, string(ft.StreamName))
 				} else {
 					if !field.Invisible {
 						p.colNames = append(p.colNames, []string{ft.Name, string(ft.StreamName)})
@@ -64,11 +76,68 @@ func (p ProjectPlan) Init() *ProjectPlan {
 			}
 		}
 	}
+	for _, fields := range []ast.Fields{p.exprFields, p.aliasFields} {
+		for _, field := range fields {
+			if isInvariantExpr(field.Expr) {
+				if p.invariantFields == nil {
+					p.invariantFields = make(map[string]bool)
+				}
+				p.invariantFields[field.GetName()] = true
+			}
+		}
+	}
+	if p.allWildcard {
+		for streamName := range prefixOption {
+			p.addWildcardPrefix(prefixOption, streamName)
+		}
+	}
+	p.passThrough = p.allWildcard && !p.isAggregate && p.fieldLen == 0 && len(p.aliasFields) == 0 && len(p.exprFields) == 0 && len(p.exceptNames) == 0 && len(p.wildcardPrefixes) == 0
 	p.baseLogicalPlan.self = &p
 	p.baseLogicalPlan.setPlanType(PROJECT)
 	return &p
 }
 
+// addWildcardPrefix records the column prefix of the stream if configured. An empty prefix means the stream name
+// followed by an underscore.
+func (p *ProjectPlan) addWildcardPrefix(prefixOption map[string]string, streamName string) {
+	prefix, ok := prefixOption[streamName]
+	if !ok {
+		return
+	}
+	if prefix == "" {
+		prefix = streamName + "_"
+	}
+	if p.wildcardPrefixes == nil {
+		p.wildcardPrefixes = make(map[string]string)
+	}
+	p.wildcardPrefixes[streamName] = prefix
+}
+
+// isInvariantExpr returns whether the expression is evaluated to the same value for all rows in a rule run so that it
+// only needs to be evaluated once. It must not refer to any field or metadata and can only call the invariant builtin
+// functions such as rule_id() or upper('a').
+func isInvariantExpr(expr ast.Expr) bool {
+	if expr == nil {
+		return false
+	}
+	invariant := true
+	ast.WalkFunc(expr, func(n ast.Node) bool {
+		switch nt := n.(type) {
+		case *ast.FieldRef:
+			// the alias is invariant if its expression is
+			invariant = nt.IsAlias() && nt.AliasRef != nil && isInvariantExpr(nt.Expression)
+		case *ast.MetaRef, *ast.JsonFieldRef, *ast.Wildcard, *ast.ColFuncField:
+			invariant = false
+		case *ast.Call:
+			if nt.FuncType != ast.FuncTypeScalar || nt.Cached || !function.IsInvariantFunc(nt.Name) {
+				invariant = false
+			}
+		}
+		return invariant
+	})
+	return invariant
+}
+
 func (p *ProjectPlan) BuildExplainInfo() {
 	info := ""
 	if p.fields != nil && len(p.fields) != 0 {
@@ -83,12 +152,32 @@ func (p *ProjectPlan) BuildExplainInfo() {
 		}
 		info += " ]"
 	}
+	if p.distinct {
+		info += ", Distinct:true"
+	}
 	if p.enableLimit {
 		info += ", Limit:" + strconv.Itoa(p.limitCount)
 	}
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *ProjectPlan) explainDetails() map[string]interface{} {
+	fields := make([]string, 0, len(p.fields))
+	for _, field := range p.fields {
+		if field.Expr != nil {
+			fields = append(fields, field.Expr.String())
+		}
+	}
+	details := map[string]interface{}{"fields": fields}
+	if p.distinct {
+		details["distinct"] = true
+	}
+	if p.enableLimit {
+		details["limit"] = p.limitCount
+	}
+	return details
+}
+
 func (p *ProjectPlan) PruneColumns(fields []ast.Expr) error {
 	f := getFields(p.fields)
 	return p.baseLogicalPlan.PruneColumns(append(fields, f...))
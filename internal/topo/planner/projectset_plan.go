@@ -49,5 +49,8 @@ func (p *ProjectSetPlan) BuildExplainInfo() {
 		info += "}"
 	}
 	info += ", EnableLimit:" + strconv.FormatBool(p.enableLimit)
+	if p.enableLimit {
+		info += ", Limit:" + strconv.Itoa(p.limitCount)
+	}
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
@@ -68,6 +68,9 @@ func TestStmtValidate(t *testing.T) {
 		{
 			sql: "select a[2:1] from src1",
 		},
+		{
+			sql: "select a from src1 limit -1",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.sql, func(t *testing.T) {
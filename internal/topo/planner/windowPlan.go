@@ -79,6 +79,21 @@ func (p *WindowPlan) BuildExplainInfo() {
 	p.baseLogicalPlan.ExplainInfo.Info = info
 }
 
+func (p *WindowPlan) explainDetails() map[string]interface{} {
+	details := map[string]interface{}{
+		"windowType": p.wtype.String(),
+		"length":     p.length,
+		"limit":      p.limit,
+	}
+	if p.condition != nil {
+		details["condition"] = p.condition.String()
+	}
+	if len(p.stateFuncs) != 0 {
+		details["stateFuncs"] = exprStrings(p.stateFuncs)
+	}
+	return details
+}
+
 func (p *WindowPlan) PushDownPredicate(condition ast.Expr) (ast.Expr, LogicalPlan) {
 	// not time window depends on the event, so should not filter any
 	if p.wtype == ast.COUNT_WINDOW || p.wtype == ast.SLIDING_WINDOW {
@@ -37,6 +37,7 @@ import (
 	"github.com/lf-edge/ekuiper/v2/internal/topo/node/metric"
 	"github.com/lf-edge/ekuiper/v2/internal/topo/state"
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
+	"github.com/lf-edge/ekuiper/v2/pkg/cast"
 	"github.com/lf-edge/ekuiper/v2/pkg/infra"
 	"github.com/lf-edge/ekuiper/v2/pkg/timex"
 )
@@ -265,6 +266,17 @@ func (s *Topo) prepareContext() {
 		ctx := kctx.WithValue(kctx.RuleBackground(s.name), kctx.LoggerKey, contextLogger)
 		ctx = kctx.WithValue(ctx, kctx.RuleStartKey, timex.GetNowInMilli())
 		ctx = kctx.WithValue(ctx, kctx.RuleWaitGroupKey, s.opsWg)
+		if s.options != nil && s.options.TimeZone != "" {
+			// the rule option has been validated
+			if loc, err := cast.LoadLocation(s.options.TimeZone); err == nil {
+				ctx = kctx.WithValue(ctx, kctx.TimeZoneKey, loc)
+			} else {
+				contextLogger.Warnf("invalid timezone %s, use the global timezone instead: %v", s.options.TimeZone, err)
+			}
+		}
+		if s.options != nil && s.options.ShareKeyedState {
+			ctx = kctx.WithValue(ctx, kctx.KeyedStateSharedKey, true)
+		}
 		nctx := ctx.WithRuleId(s.name)
 		s.ctx, s.cancel = nctx.WithCancel()
 	}
@@ -398,6 +410,12 @@ func (s *Topo) GetMetricsV2() map[string]map[string]any {
 			value := v
 			operatorMetrics[key] = value
 		}
+		if en, ok := so.(node.ExtraMetricNode); ok {
+			ekeys, evalues := en.ExtraMetrics()
+			for i, key := range ekeys {
+				operatorMetrics["op_"+so.GetName()+"_0_"+key] = evalues[i]
+			}
+		}
 		allMetrics[so.GetName()] = operatorMetrics
 	}
 	for _, sn := range s.sinks {
@@ -431,6 +449,13 @@ func (s *Topo) GetMetrics() (keys []string, values []any) {
 			keys = append(keys, "op_"+so.GetName()+"_0_"+metric.MetricNames[i])
 			values = append(values, v)
 		}
+		if en, ok := so.(node.ExtraMetricNode); ok {
+			ekeys, evalues := en.ExtraMetrics()
+			for _, key := range ekeys {
+				keys = append(keys, "op_"+so.GetName()+"_0_"+key)
+			}
+			values = append(values, evalues...)
+		}
 	}
 	for _, sn := range s.sinks {
 		for i, v := range sn.GetMetrics() {
@@ -612,6 +612,26 @@ func (r *WindowRange) FuncValue(key string) (interface{}, bool) {
 	}
 }
 
+// WindowIndexValuer provides the zero-based position of the current row in the window
+type WindowIndexValuer struct {
+	Index int
+}
+
+func (w *WindowIndexValuer) Value(_, _ string) (interface{}, bool) {
+	return nil, false
+}
+
+func (w *WindowIndexValuer) Meta(_, _ string) (interface{}, bool) {
+	return nil, false
+}
+
+func (w *WindowIndexValuer) FuncValue(key string) (interface{}, bool) {
+	if key == "window_index" {
+		return w.Index, true
+	}
+	return nil, false
+}
+
 type TransformedTupleList struct {
 	Ctx     api.StreamContext
 	Content []api.MessageTuple
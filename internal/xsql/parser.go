@@ -121,6 +121,24 @@ func NewParserWithSources(r io.Reader, sources []string) *Parser {
 	return &Parser{s: NewScanner(r), sourceNames: sources}
 }
 
+// parseDistinct consumes the DISTINCT after SELECT. DISTINCT is not a reserved keyword, so it is only taken as the
+// keyword when a field expression follows. Otherwise, such as followed by FROM, comma or AS, it is a field named distinct.
+func (p *Parser) parseDistinct() bool {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != ast.IDENT || !strings.EqualFold(lit, "DISTINCT") {
+		p.unscan()
+		return false
+	}
+	tok, _ := p.scanIgnoreWhitespace()
+	p.unscan()
+	switch tok {
+	case ast.IDENT, ast.ASTERISK, ast.LPAREN, ast.INTEGER, ast.NUMBER, ast.STRING, ast.SINGLEQUOTE, ast.TRUE, ast.FALSE, ast.CASE, ast.NOT:
+		return true
+	default:
+		p.unscan()
+		return false
+	}
+}
+
 func (p *Parser) ParseQueries() ([]ast.SelectStatement, error) {
 	var stmts []ast.SelectStatement
 
@@ -156,6 +174,7 @@ func (p *Parser) Parse() (*ast.SelectStatement, error) {
 		return nil, fmt.Errorf("Found %q, Expected SELECT.\n", lit)
 	}
 	p.clause = "select"
+	selects.Distinct = p.parseDistinct()
 	if fields, err := p.parseFields(); err != nil {
 		return nil, err
 	} else {
@@ -950,6 +969,11 @@ func (p *Parser) parseCall(n string) (ast.Expr, error) {
 		if name == "deduplicate" {
 			args = append([]ast.Expr{&ast.Wildcard{Token: ast.ASTERISK}}, args...)
 		}
+		// staleness defaults to the event time of the current record
+		if name == "staleness" && len(args) == 0 {
+			args = []ast.Expr{&ast.Call{Name: "event_time", FuncId: p.fn, FuncType: ast.FuncTypeScalar}}
+			p.fn += 1
+		}
 		c := &ast.Call{Name: name, Args: args, FuncId: p.fn, FuncType: ft}
 		p.fn += 1
 		e := p.parseOver(c)
@@ -2339,9 +2339,9 @@ func TestParser_ParseStatement(t *testing.T) {
 		},
 
 		{
-			s:    `SELECT sample(-.3,) FROM tbl`,
+			s:    `SELECT sample_func(-.3,) FROM tbl`,
 			stmt: nil,
-			err:  "function sample not found",
+			err:  "function sample_func not found",
 		},
 
 		{
@@ -4489,6 +4489,103 @@ func TestParser_ParseStatements(t *testing.T) {
 	}
 }
 
+func TestParser_ParseDistinct(t *testing.T) {
+	tests := []struct {
+		s    string
+		stmt *ast.SelectStatement
+	}{
+		{
+			s: "SELECT DISTINCT name FROM tbl",
+			stmt: &ast.SelectStatement{
+				Distinct: true,
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "name", StreamName: ast.DefaultStream},
+						Name: "name",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+			},
+		},
+		{
+			s: "select distinct * from tbl",
+			stmt: &ast.SelectStatement{
+				Distinct: true,
+				Fields: []ast.Field{
+					{
+						Expr: &ast.Wildcard{Token: ast.ASTERISK},
+						Name: "*",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+			},
+		},
+		{
+			s: "SELECT `distinct` FROM tbl",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "distinct", StreamName: ast.DefaultStream},
+						Name: "distinct",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+			},
+		},
+		{
+			s: "SELECT distinct FROM tbl",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "distinct", StreamName: ast.DefaultStream},
+						Name: "distinct",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+			},
+		},
+		{
+			s: "SELECT distinct, name FROM tbl",
+			stmt: &ast.SelectStatement{
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "distinct", StreamName: ast.DefaultStream},
+						Name: "distinct",
+					},
+					{
+						Expr: &ast.FieldRef{Name: "name", StreamName: ast.DefaultStream},
+						Name: "name",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+			},
+		},
+		{
+			s: "SELECT DISTINCT distinct FROM tbl WHERE distinct > 1",
+			stmt: &ast.SelectStatement{
+				Distinct: true,
+				Fields: []ast.Field{
+					{
+						Expr: &ast.FieldRef{Name: "distinct", StreamName: ast.DefaultStream},
+						Name: "distinct",
+					},
+				},
+				Sources: []ast.Source{&ast.Table{Name: "tbl"}},
+				Condition: &ast.BinaryExpr{
+					LHS: &ast.FieldRef{Name: "distinct", StreamName: ast.DefaultStream},
+					OP:  ast.GT,
+					RHS: &ast.IntegerLiteral{Val: 1},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		stmt, err := NewParser(strings.NewReader(tt.s)).Parse()
+		require.NoError(t, err)
+		require.Equal(t, tt.stmt, stmt, tt.s)
+	}
+}
+
 func TestParser_ParseLimit(t *testing.T) {
 	tests := []struct {
 		s    string
@@ -82,6 +82,11 @@ type MetaData interface {
 	MetaData() Metadata
 }
 
+// ColumnPrefixer renames the columns of the rows emitted by the given streams with the prefix of each stream
+type ColumnPrefixer interface {
+	PrefixColumns(prefixes map[string]string)
+}
+
 // EmittedData is data that is produced by a specific source
 type EmittedData interface {
 	// GetEmitter returns the emitter of the row
@@ -346,9 +351,11 @@ func (t *Tuple) AllMeta() map[string]any {
 }
 
 var (
-	_ Row          = &Tuple{}
-	_ MetaData     = &Tuple{}
-	_ api.MetaInfo = &Tuple{}
+	_ Row            = &Tuple{}
+	_ MetaData       = &Tuple{}
+	_ MetaSetter     = &Tuple{}
+	_ ColumnPrefixer = &Tuple{}
+	_ api.MetaInfo   = &Tuple{}
 )
 
 // JoinTuple is a row produced by a join operation
@@ -368,7 +375,10 @@ func (jt *JoinTuple) SetTracerCtx(ctx api.StreamContext) {
 	jt.Ctx = ctx
 }
 
-var _ Row = &JoinTuple{}
+var (
+	_ Row            = &JoinTuple{}
+	_ ColumnPrefixer = &JoinTuple{}
+)
 
 // GroupedTuples is a collection of tuples grouped by a key
 type GroupedTuples struct {
@@ -388,7 +398,10 @@ func (s *GroupedTuples) SetTracerCtx(ctx api.StreamContext) {
 	s.Ctx = ctx
 }
 
-var _ CollectionRow = &GroupedTuples{}
+var (
+	_ CollectionRow  = &GroupedTuples{}
+	_ ColumnPrefixer = &GroupedTuples{}
+)
 
 /*
  *   Implementations
@@ -507,6 +520,17 @@ func (t *Tuple) MetaData() Metadata {
 	return t.Metadata
 }
 
+// SetMeta sets the metadata key of this tuple. The metadata may be shared with other tuples, so it is copied on write.
+func (t *Tuple) SetMeta(key string, value any) bool {
+	m := make(Metadata, len(t.Metadata)+1)
+	for k, v := range t.Metadata {
+		m[k] = v
+	}
+	m[key] = value
+	t.Metadata = m
+	return true
+}
+
 func (t *Tuple) GetEmitter() string {
 	return t.Emitter
 }
@@ -541,6 +565,15 @@ func (t *Tuple) FuncValue(key string) (interface{}, bool) {
 	}
 }
 
+// Set invalidates the cached map if any so that the value set after ToMap is exported.
+// Like Pick, it runs in the operator before the row is shared, so the lock is not needed.
+func (t *Tuple) Set(col string, value interface{}) {
+	if t.cachedMap != nil {
+		t.cachedMap = nil
+	}
+	t.AffiliateRow.Set(col, value)
+}
+
 func (t *Tuple) Pick(allWildcard bool, cols [][]string, wildcardEmitters map[string]bool, except []string, sendNil bool) {
 	// invalidate cache, will calculate again
 	t.cachedMap = nil
@@ -575,6 +608,23 @@ func (t *Tuple) Pick(allWildcard bool, cols [][]string, wildcardEmitters map[str
 	}
 }
 
+// PrefixColumns prefixes all the columns of the message if the tuple is emitted by a stream in the prefixes.
+// The message is replaced by a new map so that the shared original message is not changed.
+func (t *Tuple) PrefixColumns(prefixes map[string]string) {
+	prefix, ok := prefixes[t.Emitter]
+	if !ok {
+		return
+	}
+	m := make(Message, len(t.Message))
+	for k, v := range t.Message {
+		m[prefix+k] = v
+	}
+	t.lock.Lock()
+	t.cachedMap = nil
+	t.lock.Unlock()
+	t.Message = m
+}
+
 // JoinTuple implementation
 
 func (jt *JoinTuple) AddTuple(tuple Row) {
@@ -682,6 +732,14 @@ func (jt *JoinTuple) ToMap() map[string]interface{} {
 	return jt.cachedMap
 }
 
+// Set invalidates the cached map if any, see Tuple.Set
+func (jt *JoinTuple) Set(col string, value interface{}) {
+	if jt.cachedMap != nil {
+		jt.cachedMap = nil
+	}
+	jt.AffiliateRow.Set(col, value)
+}
+
 func (jt *JoinTuple) Pick(allWildcard bool, cols [][]string, wildcardEmitters map[string]bool, except []string, sendNil bool) {
 	cols = jt.AffiliateRow.Pick(cols)
 	if !allWildcard {
@@ -703,6 +761,27 @@ func (jt *JoinTuple) Pick(allWildcard bool, cols [][]string, wildcardEmitters ma
 	jt.cachedMap = nil
 }
 
+func (jt *JoinTuple) PrefixColumns(prefixes map[string]string) {
+	for i, tuple := range jt.Tuples {
+		et, ok := tuple.(EmittedData)
+		if !ok {
+			continue
+		}
+		if _, ok := prefixes[et.GetEmitter()]; !ok {
+			continue
+		}
+		// the tuple of the wildcard emitter is not cloned in Pick
+		nt := tuple.Clone()
+		if cp, ok := nt.(ColumnPrefixer); ok {
+			cp.PrefixColumns(prefixes)
+			jt.Tuples[i] = nt
+		}
+	}
+	jt.lock.Lock()
+	jt.cachedMap = nil
+	jt.lock.Unlock()
+}
+
 func (jt *JoinTuple) AggregateEval(expr ast.Expr, v CallValuer) []interface{} {
 	return []interface{}{Eval(expr, MultiValuer(jt, v, &WildcardValuer{jt}))}
 }
@@ -760,9 +839,27 @@ func (s *GroupedTuples) Clone() Row {
 	return c
 }
 
+// Set invalidates the cached map if any, see Tuple.Set
+func (s *GroupedTuples) Set(col string, value interface{}) {
+	if s.cachedMap != nil {
+		s.cachedMap = nil
+	}
+	s.AffiliateRow.Set(col, value)
+}
+
 func (s *GroupedTuples) Pick(allWildcard bool, cols [][]string, wildcardEmitters map[string]bool, except []string, sendNil bool) {
 	cols = s.AffiliateRow.Pick(cols)
 	sc := s.Content[0].Clone()
 	sc.Pick(allWildcard, cols, wildcardEmitters, except, sendNil)
 	s.Content[0] = sc
 }
+
+// PrefixColumns prefixes the columns of the first row which is cloned in Pick
+func (s *GroupedTuples) PrefixColumns(prefixes map[string]string) {
+	if cp, ok := s.Content[0].(ColumnPrefixer); ok {
+		cp.PrefixColumns(prefixes)
+	}
+	s.lock.Lock()
+	s.cachedMap = nil
+	s.lock.Unlock()
+}
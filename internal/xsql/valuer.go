@@ -19,6 +19,7 @@ import (
 	"math"
 	"reflect"
 	"regexp"
+	"sort"
 	"time"
 
 	"github.com/lf-edge/ekuiper/v2/internal/binder/function"
@@ -34,6 +35,7 @@ var (
 		"window_end":     true,
 		"event_time":     true,
 		"window_trigger": true,
+		"window_index":   true,
 	}
 	// ImplicitStateFuncs is a set of functions that read/update global state implicitly.
 	ImplicitStateFuncs = map[string]bool{
@@ -42,8 +44,19 @@ var (
 		"last_agg_hit_time":  true,
 		"last_agg_hit_count": true,
 	}
+	// rowFuncs is a set of functions that read or write the current row directly instead of calling the function registry.
+	rowFuncs map[string]func(v *ValuerEval, call *ast.Call) interface{}
 )
 
+func init() {
+	rowFuncs = map[string]func(v *ValuerEval, call *ast.Call) interface{}{
+		"metakeys": func(v *ValuerEval, _ *ast.Call) interface{} {
+			return metaKeys(v.Valuer)
+		},
+		"set_meta": (*ValuerEval).setMeta,
+	}
+}
+
 /*
  *  Valuer definitions
  */
@@ -76,6 +89,11 @@ type FuncValuer interface {
 	FuncValue(key string) (interface{}, bool)
 }
 
+// MetaSetter can set the metadata of the outgoing row
+type MetaSetter interface {
+	SetMeta(key string, value any) bool
+}
+
 type AggregateCallValuer interface {
 	CallValuer
 	GetAllTuples() AggregateData
@@ -154,6 +172,15 @@ func (a multiValuer) FuncValue(key string) (interface{}, bool) {
 	return nil, false
 }
 
+func (a multiValuer) SetMeta(key string, value any) bool {
+	for _, valuer := range a {
+		if ms, ok := valuer.(MetaSetter); ok {
+			return ms.SetMeta(key, value)
+		}
+	}
+	return false
+}
+
 func (a multiValuer) Call(name string, funcId int, args []interface{}) (interface{}, bool) {
 	for _, valuer := range a {
 		if valuer, ok := valuer.(CallValuer); ok {
@@ -337,6 +364,9 @@ func (v *ValuerEval) Eval(expr ast.Expr) interface{} {
 			// nil is also cached
 			return val
 		}
+		if rf, ok := rowFuncs[et.Name]; ok {
+			return rf(v, et)
+		}
 		if _, ok := implicitValueFuncs[et.Name]; ok {
 			if vv, ok := v.Valuer.(FuncValuer); ok {
 				val, ok := vv.FuncValue(et.Name)
@@ -1269,3 +1299,42 @@ func isFloat(para interface{}) bool {
 	}
 	return false
 }
+
+// setMeta writes the value to the metadata of the row instead of the payload. It returns nil so that the field is omitted.
+func (v *ValuerEval) setMeta(call *ast.Call) interface{} {
+	key := v.Eval(call.Args[0])
+	if e, ok := key.(error); ok {
+		return e
+	}
+	k, ok := key.(string)
+	if !ok {
+		return fmt.Errorf("the metadata key should be a string but got %v", key)
+	}
+	value := v.Eval(call.Args[1])
+	if e, ok := value.(error); ok {
+		return e
+	}
+	if ms, ok := v.Valuer.(MetaSetter); !ok || !ms.SetMeta(k, value) {
+		return fmt.Errorf("set_meta is not supported for the current row")
+	}
+	return nil
+}
+
+// metaKeys returns the sorted keys of the metadata of the current row
+func metaKeys(valuer Valuer) []interface{} {
+	all, _ := valuer.Meta("*", "")
+	m, ok := all.(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]interface{}, len(keys))
+	for i, k := range keys {
+		result[i] = k
+	}
+	return result
+}
@@ -20,6 +20,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 
 	"github.com/lf-edge/ekuiper/v2/pkg/ast"
 	"github.com/lf-edge/ekuiper/v2/pkg/cast"
@@ -463,3 +466,60 @@ func TestLike(t *testing.T) {
 		}
 	}
 }
+
+func TestMetaKeys(t *testing.T) {
+	stmt, err := NewParser(strings.NewReader("select metakeys() as k from src")).Parse()
+	require.NoError(t, err)
+	tests := []struct {
+		meta Metadata
+		r    []interface{}
+	}{
+		{
+			meta: Metadata{"topic": "a/b", "qos": 1, "messageId": "1"},
+			r:    []interface{}{"messageId", "qos", "topic"},
+		},
+		{
+			meta: nil,
+			r:    []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		tuple := &Tuple{Emitter: "src", Message: Message{"a": 1}, Timestamp: timex.GetNow(), Metadata: tt.meta}
+		ve := &ValuerEval{Valuer: MultiValuer(tuple)}
+		require.Equal(t, tt.r, ve.Eval(stmt.Fields[0].Expr))
+	}
+}
+
+func TestSetMeta(t *testing.T) {
+	stmt, err := NewParser(strings.NewReader(`select set_meta("topic", a) as x, set_meta(b, 1) as y from src`)).Parse()
+	require.NoError(t, err)
+	shared := Metadata{"topic": "a/b", "qos": 1}
+	tuple := &Tuple{Emitter: "src", Message: Message{"a": "c/d", "b": 2}, Timestamp: timex.GetNow(), Metadata: shared}
+	ve := &ValuerEval{Valuer: MultiValuer(tuple, &FunctionValuer{})}
+	require.Nil(t, ve.Eval(stmt.Fields[0].Expr))
+	require.Equal(t, Metadata{"topic": "c/d", "qos": 1}, tuple.MetaData())
+	v, ok := tuple.Meta("topic", "")
+	require.True(t, ok)
+	require.Equal(t, "c/d", v)
+	// the shared metadata is not changed
+	require.Equal(t, Metadata{"topic": "a/b", "qos": 1}, shared)
+	require.EqualError(t, ve.Eval(stmt.Fields[1].Expr).(error), "the metadata key should be a string but got 2")
+	// the row without metadata cannot be set
+	ve = &ValuerEval{Valuer: MultiValuer(Message{"a": "c/d"})}
+	require.EqualError(t, ve.Eval(stmt.Fields[0].Expr).(error), "set_meta is not supported for the current row")
+}
+
+func TestStalenessEventTime(t *testing.T) {
+	stmt, err := NewParser(strings.NewReader(`select staleness() as s, staleness(ts) as t from src`)).Parse()
+	require.NoError(t, err)
+	// the omitted arg is filled with the event time during parsing
+	c := stmt.Fields[0].Expr.(*ast.Call)
+	require.Len(t, c.Args, 1)
+	require.Equal(t, "event_time", c.Args[0].(*ast.Call).Name)
+	now := timex.GetNow()
+	tuple := &Tuple{Emitter: "src", Message: Message{"ts": now.UnixMilli() + 500}, Timestamp: now.Add(-time.Minute)}
+	fv, _ := NewFunctionValuersForOp(nil)
+	ve := &ValuerEval{Valuer: MultiValuer(tuple, fv)}
+	require.Equal(t, int64(60000), ve.Eval(stmt.Fields[0].Expr))
+	require.Equal(t, int64(-500), ve.Eval(stmt.Fields[1].Expr))
+}
@@ -408,6 +408,14 @@ type LimitExpr struct {
 
 func (l *LimitExpr) expr() {}
 func (l *LimitExpr) node() {}
+
+// ValidateExpr rejects negative limit. LIMIT 0 is valid and emits no rows.
+func (l *LimitExpr) ValidateExpr() error {
+	if l.LimitCount != nil && l.LimitCount.Val < 0 {
+		return fmt.Errorf("limit count must not be negative but got %d", l.LimitCount.Val)
+	}
+	return nil
+}
 func (l *LimitExpr) String() string {
 	if l.LimitCount != nil {
 		return "limitExpr:{ " + l.LimitCount.String() + " }"
@@ -22,6 +22,8 @@ type Statement interface {
 }
 
 type SelectStatement struct {
+	// Distinct is set by SELECT DISTINCT to suppress the duplicate output rows
+	Distinct   bool
 	Fields     Fields
 	Sources    Sources
 	Joins      Joins
@@ -16,6 +16,7 @@ package cast
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"math"
 	"reflect"
@@ -814,23 +815,31 @@ func ToBool(input interface{}, sn Strictness) (bool, error) {
 		if sn == CONVERT_ALL {
 			return false, nil
 		}
-	case int:
+	case int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8:
 		if sn == CONVERT_ALL {
-			if b != 0 {
-				return true, nil
+			// 0 is false and any other number is true
+			i, err := ToInt64(b, CONVERT_ALL)
+			if err == nil {
+				return i != 0, nil
 			}
-			return false, nil
 		}
 	case string:
 		if sn == CONVERT_ALL {
-			return strconv.ParseBool(b)
+			if r, err := strconv.ParseBool(b); err == nil {
+				return r, nil
+			}
+			// numeric string like "2" or "0.0"
+			if f, err := strconv.ParseFloat(b, 64); err == nil {
+				return f != 0, nil
+			}
 		}
 	case float64:
 		if sn == CONVERT_ALL {
-			if b != 0 {
-				return true, nil
-			}
-			return false, nil
+			return b != 0, nil
+		}
+	case float32:
+		if sn == CONVERT_ALL {
+			return b != 0, nil
 		}
 	}
 	return false, fmt.Errorf("cannot convert %[1]T(%[1]v) to bool", input)
@@ -867,6 +876,43 @@ func ToByteA(input interface{}, sn Strictness) ([]byte, error) {
 	return nil, fmt.Errorf("cannot convert %[1]T(%[1]v) to bytea", input)
 }
 
+// toByteAWithEncoding decodes the string by the encoding hint which can be hex or base64. Without a hint,
+// a string prefixed by 0x is decoded as hex and other strings are decoded like ToByteA. The raw bytes are used
+// if the string cannot be decoded.
+func toByteAWithEncoding(input interface{}, encoding string) ([]byte, error) {
+	s, ok := input.(string)
+	if !ok {
+		return ToByteA(input, CONVERT_ALL)
+	}
+	switch encoding {
+	case "":
+		if len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X") {
+			if r, err := hex.DecodeString(s[2:]); err == nil {
+				return r, nil
+			}
+			return []byte(s), nil
+		}
+		return ToByteA(s, CONVERT_ALL)
+	case "hex":
+		if len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X") {
+			s = s[2:]
+		}
+		r, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("illegal string %s, must be hex encoded string", s)
+		}
+		return r, nil
+	case "base64":
+		r, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("illegal string %s, must be base64 encoded string", s)
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("unknown bytea encoding %s, only support hex and base64", encoding)
+	}
+}
+
 func ToStringMap(input interface{}) (map[string]interface{}, error) {
 	m := map[string]interface{}{}
 
@@ -1180,7 +1226,9 @@ func ConvertSlice(v interface{}) []interface{} {
 
 // ToType cast value into newType type
 // newType support bigint, float, string, boolean, datetime, bytea
-func ToType(value interface{}, newType interface{}) (interface{}, bool) {
+// The optional format is the layout to parse a string to datetime, or the encoding (hex or base64)
+// to decode a string to bytea.
+func ToType(value interface{}, newType interface{}, format ...string) (interface{}, bool) {
 	if v, ok := newType.(string); ok {
 		switch v {
 		case "bigint":
@@ -1212,14 +1260,17 @@ func ToType(value interface{}, newType interface{}) (interface{}, bool) {
 				return r, true
 			}
 		case "datetime":
-			dt, err := InterfaceToTime(value, "")
-			if err != nil {
-				return err, false
-			} else {
-				return dt, true
+			f := ""
+			if len(format) > 0 {
+				f = format[0]
 			}
+			return ToDatetime(value, f, localTimeZone)
 		case "bytea":
-			r, e := ToByteA(value, CONVERT_ALL)
+			encoding := ""
+			if len(format) > 0 {
+				encoding = format[0]
+			}
+			r, e := toByteAWithEncoding(value, encoding)
 			if e != nil {
 				return e, false
 			} else {
@@ -1232,3 +1283,16 @@ func ToType(value interface{}, newType interface{}) (interface{}, bool) {
 		return fmt.Errorf("expect string type for type parameter"), false
 	}
 }
+
+// ToDatetime converts the value to datetime with the optional layout. The time without zone information is
+// interpreted in the given location.
+func ToDatetime(value interface{}, f string, loc *time.Location) (interface{}, bool) {
+	dt, err := InterfaceToTimeInLocation(value, f, loc)
+	if err != nil {
+		if s, ok := value.(string); ok && f != "" {
+			return fmt.Errorf("cannot parse %s to datetime with layout %s: %v", s, f, err), false
+		}
+		return err, false
+	}
+	return dt, true
+}
@@ -919,6 +919,118 @@ func TestToByteA(t *testing.T) {
 	}
 }
 
+func TestToBool(t *testing.T) {
+	tests := []struct {
+		input  interface{}
+		output bool
+		err    string
+	}{
+		{input: true, output: true},
+		{input: int64(1), output: true},
+		{input: int64(0), output: false},
+		{input: -3, output: true},
+		{input: uint8(0), output: false},
+		{input: 2.5, output: true},
+		{input: 0.0, output: false},
+		{input: float32(0.1), output: true},
+		{input: "true", output: true},
+		{input: "F", output: false},
+		{input: "0", output: false},
+		{input: "2", output: true},
+		{input: "0.0", output: false},
+		{input: "yes", err: "cannot convert string(yes) to bool"},
+		{input: []int{1}, err: "cannot convert []int([1]) to bool"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%[1]T(%[1]v)", tt.input), func(t *testing.T) {
+			r, err := ToBool(tt.input, CONVERT_ALL)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.output, r)
+			}
+		})
+	}
+	_, err := ToBool(int64(1), CONVERT_SAMEKIND)
+	assert.EqualError(t, err, "cannot convert int64(1) to bool")
+}
+
+func TestToTypeBoolInt(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		newType string
+		result  interface{}
+	}{
+		{value: true, newType: "bigint", result: 1},
+		{value: false, newType: "bigint", result: 0},
+		{value: true, newType: "float", result: 1.0},
+		{value: int64(1), newType: "boolean", result: true},
+		{value: int64(0), newType: "boolean", result: false},
+		{value: int64(-5), newType: "boolean", result: true},
+		{value: 0.0, newType: "boolean", result: false},
+		{value: 0.01, newType: "boolean", result: true},
+		{value: "1", newType: "boolean", result: true},
+		{value: "false", newType: "boolean", result: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%[1]T(%[1]v) to %[2]s", tt.value, tt.newType), func(t *testing.T) {
+			r, ok := ToType(tt.value, tt.newType)
+			assert.True(t, ok)
+			assert.Equal(t, tt.result, r)
+		})
+	}
+}
+
+func TestToTypeDatetimeFormat(t *testing.T) {
+	r, ok := ToType("20240315083000", "datetime", "yyyyMMddHHmmss")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 15, 8, 30, 0, 0, GetConfiguredTimeZone()), r)
+	r, ok = ToType("bad", "datetime", "yyyyMMddHHmmss")
+	assert.False(t, ok)
+	assert.Contains(t, r.(error).Error(), "cannot parse bad to datetime with layout yyyyMMddHHmmss")
+	// format is ignored for other types
+	r, ok = ToType("12", "bigint", "yyyyMMddHHmmss")
+	assert.True(t, ok)
+	assert.Equal(t, 12, r)
+}
+
+func TestToTypeBytea(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		encoding []string
+		result   interface{}
+		err      string
+	}{
+		{name: "bytes", value: []byte{1, 2}, result: []byte{1, 2}},
+		{name: "hex", value: "0x0102ff", result: []byte{1, 2, 0xff}},
+		{name: "upper hex prefix", value: "0X0A", result: []byte{0x0a}},
+		{name: "invalid hex", value: "0xzz", result: []byte("0xzz")},
+		{name: "base64", value: "AQI=", result: []byte{1, 2}},
+		{name: "raw", value: "foo", result: []byte("foo")},
+		{name: "hex hint", value: "0102", encoding: []string{"hex"}, result: []byte{1, 2}},
+		{name: "hex hint with prefix", value: "0x0102", encoding: []string{"hex"}, result: []byte{1, 2}},
+		{name: "hex hint invalid", value: "AQI=", encoding: []string{"hex"}, err: "illegal string AQI=, must be hex encoded string"},
+		{name: "base64 hint", value: "0102", encoding: []string{"base64"}, result: []byte{0xd3, 0x5d, 0x36}},
+		{name: "base64 hint invalid", value: "0x1", encoding: []string{"base64"}, err: "illegal string 0x1, must be base64 encoded string"},
+		{name: "unknown hint", value: "0102", encoding: []string{"utf16"}, err: "unknown bytea encoding utf16, only support hex and base64"},
+		{name: "not string", value: 1, err: "cannot convert int(1) to bytea"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := ToType(tt.value, "bytea", tt.encoding...)
+			if tt.err != "" {
+				assert.False(t, ok)
+				assert.EqualError(t, r.(error), tt.err)
+			} else {
+				assert.True(t, ok)
+				assert.Equal(t, tt.result, r)
+			}
+		})
+	}
+}
+
 type mockconf struct {
 	Interval  time.Duration
 	Interval2 DurationConf
@@ -17,7 +17,10 @@ package cast
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -79,8 +82,43 @@ func GetConfiguredTimeZone() *time.Location {
 
 var localTimeZone = time.Local
 
-func SetTimeZone(name string) error {
+var (
+	// zoneInfoPath is the directory of a timezone database to load the named zones from if the system one is not available
+	zoneInfoPath string
+	// zoneCache caches the locations loaded from zoneInfoPath
+	zoneCache sync.Map
+)
+
+// SetZoneInfoPath sets the directory of the timezone database such as /usr/share/zoneinfo
+func SetZoneInfoPath(path string) {
+	zoneInfoPath = path
+	zoneCache.Clear()
+}
+
+// LoadLocation loads the named zone like time.LoadLocation. If the zone is not found in the system timezone database,
+// it is loaded from the configured zone info path.
+func LoadLocation(name string) (*time.Location, error) {
 	loc, err := time.LoadLocation(name)
+	if err == nil || zoneInfoPath == "" || !filepath.IsLocal(name) {
+		return loc, err
+	}
+	if l, ok := zoneCache.Load(name); ok {
+		return l.(*time.Location), nil
+	}
+	data, e := os.ReadFile(filepath.Join(zoneInfoPath, name))
+	if e != nil {
+		return nil, err
+	}
+	loc, e = time.LoadLocationFromTZData(name, data)
+	if e != nil {
+		return nil, fmt.Errorf("invalid time zone %s in %s: %v", name, zoneInfoPath, e)
+	}
+	zoneCache.Store(name, loc)
+	return loc, nil
+}
+
+func SetTimeZone(name string) error {
+	loc, err := LoadLocation(name)
 	if err != nil {
 		return err
 	}
@@ -111,32 +149,47 @@ func InterfaceToUnixMilli(i interface{}, format string) (int64, error) {
 }
 
 func InterfaceToTime(i interface{}, format string) (time.Time, error) {
+	return InterfaceToTimeInLocation(i, format, localTimeZone)
+}
+
+// InterfaceToTimeInLocation is like InterfaceToTime but uses the given location instead of the configured time zone
+// for the input without zone information.
+func InterfaceToTimeInLocation(i interface{}, format string, loc *time.Location) (time.Time, error) {
 	switch t := i.(type) {
 	case int64:
-		return TimeFromUnixMilli(t), nil
+		return timeFromUnixMilli(t, loc), nil
 	case int:
-		return TimeFromUnixMilli(int64(t)), nil
+		return timeFromUnixMilli(int64(t), loc), nil
 	case float64:
-		return TimeFromUnixMilli(int64(t)), nil
+		return timeFromUnixMilli(int64(t), loc), nil
 	case time.Time:
 		return t, nil
 	case string:
-		return ParseTime(t, format)
+		return ParseTimeInLocation(t, format, loc)
 	default:
 		return time.Now(), fmt.Errorf("unsupported type to convert to timestamp %v", t)
 	}
 }
 
 func TimeFromUnixMilli(t int64) time.Time {
-	return time.Unix(t/1000, (t%1000)*1e6).In(localTimeZone)
+	return timeFromUnixMilli(t, localTimeZone)
+}
+
+func timeFromUnixMilli(t int64, loc *time.Location) time.Time {
+	return time.Unix(t/1000, (t%1000)*1e6).In(loc)
 }
 
 func ParseTime(t string, f string) (_ time.Time, err error) {
+	return ParseTimeInLocation(t, f, localTimeZone)
+}
+
+// ParseTimeInLocation is like ParseTime but interprets the time without zone information in the given location
+func ParseTimeInLocation(t string, f string, loc *time.Location) (_ time.Time, err error) {
 	if f, err = convertFormat(f); err != nil {
 		return time.Time{}, err
 	}
 	c := &now.Config{
-		TimeLocation: localTimeZone,
+		TimeLocation: loc,
 		TimeFormats:  now.TimeFormats,
 	}
 	if f != "" {
@@ -15,6 +15,11 @@
 package cast
 
 import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -350,3 +355,55 @@ func TestParseTimeIssue(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "2025-06-04 08:54:00.753 +0000 UTC", t1.String())
 }
+
+func TestLoadLocationFromZoneInfoPath(t *testing.T) {
+	// Extract a zone from the go distribution as a custom named zone which is not in the system database
+	zr, err := zip.OpenReader(filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip"))
+	if err != nil {
+		t.Skipf("zoneinfo.zip is not available: %v", err)
+	}
+	defer zr.Close()
+	f, err := zr.Open("Asia/Shanghai")
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Custom"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Custom", "Zone"), data, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Custom", "Bad"), []byte("bad"), 0o644))
+
+	_, err = LoadLocation("Custom/Zone")
+	require.Error(t, err)
+	SetZoneInfoPath(dir)
+	defer SetZoneInfoPath("")
+	loc, err := LoadLocation("Custom/Zone")
+	require.NoError(t, err)
+	require.Equal(t, "Custom/Zone", loc.String())
+	_, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone()
+	require.Equal(t, 8*3600, offset)
+	// The system database is still used first
+	loc, err = LoadLocation("UTC")
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, loc)
+
+	_, err = LoadLocation("Custom/None")
+	require.Error(t, err)
+	_, err = LoadLocation("Custom/Bad")
+	require.Error(t, err)
+	_, err = LoadLocation("../Custom/Zone")
+	require.Error(t, err)
+}
+
+func TestInterfaceToTimeInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	tt, err := InterfaceToTimeInLocation("2024-01-01 00:00:00", "", loc)
+	require.NoError(t, err)
+	require.Equal(t, int64(1704067200-8*3600), tt.Unix())
+	tt, err = InterfaceToTimeInLocation("2024-01-01T00:00:00Z", "", loc)
+	require.NoError(t, err)
+	require.Equal(t, int64(1704067200), tt.Unix())
+	tt, err = InterfaceToTimeInLocation(int64(1704067200000), "", loc)
+	require.NoError(t, err)
+	require.Equal(t, loc, tt.Location())
+}
@@ -14,6 +14,8 @@
 
 package kv
 
+import "time"
+
 type KeyValue interface {
 	// Setnx sets key to hold string value if key does not exist otherwise return an error
 	Setnx(key string, value interface{}) error
@@ -29,3 +31,27 @@ type KeyValue interface {
 	Clean() error
 	Drop() error
 }
+
+// KeyedStateBatcher is implemented by the KeyValue which can get or set multiple keyed states in one round trip
+type KeyedStateBatcher interface {
+	// GetKeyedStates returns the values of the keys in the same order. The value is nil if the key does not exist
+	GetKeyedStates(keys []string) ([]interface{}, error)
+	SetKeyedStates(states map[string]interface{}) error
+}
+
+// KeyedStateTransactor is implemented by the KeyValue which can commit a batch of keyed state changes atomically.
+// Either all the changes are applied or none of them, so that a failure in the middle never leaves the states
+// partially updated.
+type KeyedStateTransactor interface {
+	// CommitKeyedStates sets the states and deletes the keys in one transaction. Deleting an absent key is not an error.
+	// A key must not be both set and deleted.
+	CommitKeyedStates(sets map[string]interface{}, deletes []string) error
+}
+
+// KeyedStateExpirer is implemented by the KeyValue which can expire keyed states
+type KeyedStateExpirer interface {
+	// SetKeyedStateWithTTL sets the keyed state which reads as absent after ttl. A zero ttl means no expiry
+	SetKeyedStateWithTTL(key string, value interface{}, ttl time.Duration) error
+	// SweepExpired deletes the expired keyed states from the storage
+	SweepExpired() error
+}
@@ -31,6 +31,7 @@ const (
 
 	DefaultField = "self"
 	MetaKey      = "__meta"
+	SchemaKey    = "__schema__"
 )
 
 // Converter converts bytes & map or []map according to the schema
@@ -41,6 +41,14 @@ type KuiperConf struct {
 		RotateSize              int64                 `yaml:"rotateSize"`
 		RotateCount             int                   `yaml:"rotateCount"`
 		TimeZone                string                `yaml:"timezone"`
+		ZoneInfoPath            string                `yaml:"zoneInfoPath"`
+		JsonPathCacheSize       int                   `yaml:"jsonPathCacheSize"`
+		ParseJsonMaxSize        int                   `yaml:"parseJsonMaxSize"`
+		ParseJsonMaxDepth       int                   `yaml:"parseJsonMaxDepth"`
+		RangeMaxLength          int                   `yaml:"rangeMaxLength"`
+		EnvAllowList            []string              `yaml:"envAllowList"`
+		PropsDumpAllowList      []string              `yaml:"propsDumpAllowList"`
+		RedactErrorArgs         bool                  `yaml:"redactErrorArgs"`
 		Ip                      string                `yaml:"ip"`
 		Port                    int                   `yaml:"port"`
 		RestIp                  string                `yaml:"restIp"`
@@ -81,6 +89,7 @@ type KuiperConf struct {
 		Fdb struct {
 			Path string `yaml:"path"`
 		}
+		Metrics bool `yaml:"metrics"`
 	}
 	Portable struct {
 		PythonBin   string            `yaml:"pythonBin"`
@@ -0,0 +1,43 @@
+// Copyright 2025 EMQ Technologies Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"github.com/lf-edge/ekuiper/v2/pkg/message"
+)
+
+type (
+	// CompressorProvider creates a compressor with the extended properties. Implementation can read and handle the props.
+	CompressorProvider func(props map[string]any) (message.Compressor, error)
+	// DecompressorProvider creates a decompressor
+	DecompressorProvider func() (message.Decompressor, error)
+)
+
+var (
+	Compressors   = map[string]CompressorProvider{}
+	Decompressors = map[string]DecompressorProvider{}
+)
+
+// RegisterCompressor registers a compressor with the given name. It is used by compress function and the sinks
+// in addition to the built-in compressors. Register it in init to make it available before the rules start.
+func RegisterCompressor(name string, provider CompressorProvider) {
+	Compressors[name] = provider
+}
+
+// RegisterDecompressor registers a decompressor with the given name. It is used by decompress function and the sources
+// in addition to the built-in decompressors. Register it in init to make it available before the rules start.
+func RegisterDecompressor(name string, provider DecompressorProvider) {
+	Decompressors[name] = provider
+}
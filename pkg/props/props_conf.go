@@ -76,3 +76,14 @@ func (s *StaticConf) Set(propName string, value string) {
 	defer s.Unlock()
 	s.props[propName] = value
 }
+
+// GetAll returns a copy of all the static properties. The dynamic properties like et are not included.
+func (s *StaticConf) GetAll() map[string]string {
+	s.RLock()
+	defer s.RUnlock()
+	result := make(map[string]string, len(s.props))
+	for k, v := range s.props {
+		result[k] = v
+	}
+	return result
+}
@@ -42,4 +42,9 @@ func TestPropsConf(t *testing.T) {
 	sf, ok := SC.Get("snowflake")
 	require.True(t, ok)
 	require.Equal(t, 19, len(sf))
+	all := SC.GetAll()
+	require.Equal(t, "value1", all["var1"])
+	require.Equal(t, "value2", all["vin"])
+	_, ok = all["et"]
+	require.False(t, ok)
 }